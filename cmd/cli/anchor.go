@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/anchor"
+	"ai-companion-cli-go/internal/models"
+)
+
+const anchorUsage = "usage: anchor show | init [--force] [file] | set <file> | clear | check [--llm] <text> | flags [--limit N]"
+
+// runAnchor manages the persona anchor of the selected character and the contradictions it caught
+func runAnchor(a *app, args []string) error {
+	sub := "show"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "show":
+		return showAnchor(a, profile)
+	case "init":
+		return initAnchor(a, profile, args)
+	case "set":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: anchor set <file>")
+		}
+		return setAnchorRef(a, profile, args[0])
+	case "clear":
+		return setAnchorRef(a, profile, "")
+	case "check":
+		return checkAnchor(a, profile, args)
+	case "flags":
+		return listPersonaFlags(a, profile, args)
+	default:
+		return fmt.Errorf(anchorUsage)
+	}
+}
+
+func showAnchor(a *app, profile *models.CharacterProfile) error {
+	var anc *anchor.Anchor
+	if profile.AnchorRef == "" {
+		fmt.Printf("%s has no anchor; facts come from the profile only (create one with `anchor init`).\n", profile.Name)
+	} else {
+		var err error
+		if anc, err = anchor.Load(profile, a.cfg.AnchorDir); err != nil {
+			return err
+		}
+		fmt.Printf("Anchor:     %s\n", anc.Path)
+	}
+	fmt.Printf("Check mode: %s\n", a.cfg.AnchorCheck)
+
+	fmt.Println("\nFacts:")
+	for _, f := range anchor.Facts(profile, anc) {
+		fmt.Printf("  %-12s %s\n", f.Key, f.Value)
+	}
+	if anc == nil {
+		return nil
+	}
+	if appearance := anchor.Appearance(profile, anc); appearance != "" {
+		fmt.Printf("\nAppearance: %s\n", appearance)
+	}
+	if anc.ArtStyle != "" {
+		fmt.Printf("Art style:  %s\n", anc.ArtStyle)
+	}
+	if len(anc.Voice) > 0 {
+		fmt.Println("\nVoice:")
+		for _, v := range anc.Voice {
+			fmt.Printf("  %s\n", v)
+		}
+	}
+	if anc.Canon != "" {
+		fmt.Printf("\nCanon:\n%s\n", anc.Canon)
+	}
+	return nil
+}
+
+// initAnchor drafts an anchor file from the profile and points the character at it
+func initAnchor(a *app, profile *models.CharacterProfile, args []string) error {
+	fs := flag.NewFlagSet("anchor init", flag.ContinueOnError)
+	force := fs.Bool("force", false, "overwrite an existing file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ref := profile.AnchorRef
+	switch {
+	case fs.NArg() == 1:
+		ref = fs.Arg(0)
+	case fs.NArg() > 1:
+		return fmt.Errorf("usage: anchor init [--force] [file]")
+	case ref == "":
+		ref = profile.CharacterID + ".anchor.json"
+	}
+
+	path := anchor.Resolve(ref, a.cfg.AnchorDir)
+	if _, err := os.Stat(path); err == nil && !*force {
+		return fmt.Errorf("%s already exists (use --force to overwrite, or `anchor set %s` to use it)", path, ref)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := anchor.FromProfile(profile).Save(path); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s; add hard facts (job, family, ...) and voice samples, then check with `anchor show`\n", path)
+	return setAnchorRef(a, profile, ref)
+}
+
+func setAnchorRef(a *app, profile *models.CharacterProfile, ref string) error {
+	profile.AnchorRef = ref
+	if ref != "" {
+		if _, err := anchor.Load(profile, a.cfg.AnchorDir); err != nil {
+			return err
+		}
+	}
+	if err := a.repo.SaveCharacter(profile); err != nil {
+		return err
+	}
+	if ref == "" {
+		fmt.Printf("%s no longer uses an anchor\n", profile.Name)
+	} else {
+		fmt.Printf("%s is anchored to %s\n", profile.Name, ref)
+	}
+	return nil
+}
+
+// checkAnchor runs the consistency checker on a piece of text, as if the character had said it
+func checkAnchor(a *app, profile *models.CharacterProfile, args []string) error {
+	fs := flag.NewFlagSet("anchor check", flag.ContinueOnError)
+	useLLM := fs.Bool("llm", a.cfg.AnchorCheck == anchor.CheckLLM, "also ask the model")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: anchor check [--llm] <text>")
+	}
+
+	_, orch := a.newOrchestrator()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	findings, err := orch.CheckConsistency(ctx, profile, strings.Join(fs.Args(), " "), *useLLM)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		fmt.Println("Consistent with the anchored facts.")
+		return nil
+	}
+	for _, f := range findings {
+		fmt.Printf("[%s] %s: expected %q, found %q\n", f.Source, f.FactKey, f.Expected, f.Found)
+	}
+	return nil
+}
+
+func listPersonaFlags(a *app, profile *models.CharacterProfile, args []string) error {
+	fs := flag.NewFlagSet("anchor flags", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "number of flags to list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	flags, err := a.repo.ListPersonaFlags(profile.CharacterID, *limit)
+	if err != nil {
+		return err
+	}
+	if len(flags) == 0 {
+		fmt.Printf("No contradictions flagged for %s.\n", profile.Name)
+		return nil
+	}
+	fmt.Printf("%-6s %-19s %-6s %-8s %-10s %-16s %s\n", "ID", "TIME", "SOURCE", "MESSAGE", "FACT", "EXPECTED", "FOUND")
+	for _, f := range flags {
+		fmt.Printf("%-6d %-19s %-6s %-8d %-10s %-16s %s\n", f.ID, f.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			f.Source, f.MessageID, f.FactKey, f.Expected, f.Found)
+	}
+	return nil
+}
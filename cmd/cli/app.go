@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-companion-cli-go/internal/anchor"
+	"ai-companion-cli-go/internal/backup"
+	"ai-companion-cli-go/internal/config"
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/lorebook"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/prompts"
+	"ai-companion-cli-go/internal/relationship"
+	"ai-companion-cli-go/internal/storage"
+	"ai-companion-cli-go/internal/usage"
+	"ai-companion-cli-go/internal/vault"
+)
+
+// app holds the shared dependencies every command runs against
+type app struct {
+	cfg       *config.AppConfig
+	db        *storage.DB
+	repo      *storage.Repository
+	vault     *vault.Vault
+	backups   *backup.Manager
+	usage     *usage.Accountant
+	stages    *relationship.Table
+	character string // --character flag, ID or name
+}
+
+func newApp(cfg *config.AppConfig, character string) (*app, error) {
+	db := storage.NewDB(cfg.DBPath)
+	if err := db.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to init database: %w", err)
+	}
+	repo := storage.NewRepository(db)
+
+	v, err := vault.Open(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption settings: %w", err)
+	}
+
+	prices, err := usage.LoadPrices(cfg.PriceTableFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load price table: %w", err)
+	}
+	if cfg.BudgetAction != usage.ActionBlock && cfg.BudgetAction != usage.ActionDowngrade {
+		return nil, fmt.Errorf("BUDGET_ACTION must be %q or %q, got %q", usage.ActionBlock, usage.ActionDowngrade, cfg.BudgetAction)
+	}
+	if !anchor.ValidCheck(cfg.AnchorCheck) {
+		return nil, fmt.Errorf("ANCHOR_CHECK must be %q, %q or %q, got %q", anchor.CheckOff, anchor.CheckRules, anchor.CheckLLM, cfg.AnchorCheck)
+	}
+	accountant := usage.NewAccountant(repo, prices, usage.Budget{
+		DailyUSD:    cfg.BudgetDailyUSD,
+		MonthlyUSD:  cfg.BudgetMonthlyUSD,
+		Action:      cfg.BudgetAction,
+		DowngradeTo: cfg.BudgetDowngradeModel,
+	})
+
+	stages, err := relationship.LoadTable(cfg.StageTableFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load intimacy stage table: %w", err)
+	}
+
+	return &app{
+		cfg:       cfg,
+		db:        db,
+		repo:      repo,
+		vault:     v,
+		backups:   backup.NewManager(db, cfg.BackupDir, cfg.BackupKeep, cfg.BackupEveryTurns),
+		usage:     accountant,
+		stages:    stages,
+		character: character,
+	}, nil
+}
+
+// newOrchestrator wires the LLM client and orchestrator with the app's turn hooks
+func (a *app) newOrchestrator() (*llm.Client, *orchestrator.Orchestrator) {
+	client := llm.NewClient(a.cfg.APIKey, a.cfg.ModelProfile)
+	client.SetMeter(a.usage)
+	client.SetImages(llm.ImageOptions{Model: a.cfg.ImageModel, Size: a.cfg.ImageSize, BaseURL: a.cfg.ImageBaseURL})
+	client.SetAudio(llm.AudioOptions{
+		TranscriptionModel: a.cfg.TranscriptionModel,
+		SpeechModel:        a.cfg.SpeechModel,
+		Voice:              a.cfg.SpeechVoice,
+		Format:             a.cfg.SpeechFormat,
+		BaseURL:            a.cfg.AudioBaseURL,
+	})
+	orch := orchestrator.NewOrchestrator(a.repo, client)
+	orch.AddTurnHook(a.backups.AfterTurn)
+	orch.EnableTracing(a.cfg.TraceKeep)
+	orch.SetPrompts(prompts.NewStore(a.cfg.PromptDir, a.cfg.PromptLocale))
+	orch.SetStages(a.stages)
+	orch.SetLorebook(lorebook.Settings{ScanDepth: a.cfg.LorebookScanDepth, TokenBudget: a.cfg.LorebookTokenBudget, Recursive: true})
+	orch.SetDialogue(a.cfg.UserName, a.cfg.ExampleBudget)
+	orch.SetAnchors(a.cfg.AnchorDir, a.cfg.AnchorCheck)
+	orch.SetAttachments(a.cfg.Vision, a.cfg.AttachmentBudget)
+	orch.SetTools(a.cfg.Tools)
+	return client, orch
+}
+
+// findCharacter resolves an ID or (case-insensitive) name; nil when nothing matches
+func (a *app) findCharacter(ref string) (*models.CharacterProfile, error) {
+	profile, err := a.repo.GetCharacter(ref)
+	if err != nil || profile != nil {
+		return profile, err
+	}
+	chars, err := a.repo.ListCharacters()
+	if err != nil {
+		return nil, err
+	}
+	for i := range chars {
+		if strings.EqualFold(chars[i].Name, ref) {
+			return &chars[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// selectedCharacter returns the --character choice, or the first character when none was given.
+// With createDefault set, a starter companion is created on an empty database.
+func (a *app) selectedCharacter(createDefault bool) (*models.CharacterProfile, error) {
+	if a.character != "" {
+		profile, err := a.findCharacter(a.character)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			return nil, fmt.Errorf("character %q not found", a.character)
+		}
+		return profile, nil
+	}
+
+	chars, err := a.repo.ListCharacters()
+	if err != nil {
+		return nil, err
+	}
+	if len(chars) > 0 {
+		return &chars[0], nil
+	}
+	if !createDefault {
+		return nil, fmt.Errorf("no characters yet; create one with `characters create`")
+	}
+
+	// If no characters exist in DB, create a default one for the user so TUI handles nicely
+	profile := &models.CharacterProfile{
+		CharacterID:      orchestrator.GenerateCharacterID(),
+		Name:             "苏晚晴",
+		Age:              24,
+		Gender:           "女性",
+		RelationshipType: "恋人",
+		MBTI:             "INFJ",
+		PersonalityTags:  []string{"温柔体贴", "知性"},
+		Catchphrase:      "我在呢。",
+		SpeechStyle:      "温柔自然，像恋人日常聊天",
+	}
+	if err := a.repo.CreateCharacter(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import "fmt"
+
+// runBackup handles `backup create|list|restore <name>`
+func runBackup(a *app, args []string) error {
+	mgr := a.backups
+	if len(args) == 0 {
+		return fmt.Errorf("usage: backup create|list|restore <name>")
+	}
+
+	switch args[0] {
+	case "create":
+		s, err := mgr.Create("manual")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s (%d bytes)\n", s.Path, s.Size)
+		return nil
+
+	case "list":
+		snapshots, err := mgr.List()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Printf("No backups in %s\n", mgr.Dir())
+			return nil
+		}
+		for _, s := range snapshots {
+			fmt.Printf("%s  %s  %-12s %8d bytes\n", s.Name, s.CreatedAt.Format("2006-01-02 15:04:05"), s.Reason, s.Size)
+		}
+		return nil
+
+	case "restore":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: backup restore <name>")
+		}
+		if err := mgr.Restore(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Restored %s. The previous database was saved as a pre-restore backup.\n", args[1])
+		return nil
+
+	default:
+		return fmt.Errorf("unknown backup command %q", args[0])
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"ai-companion-cli-go/internal/bot"
+	"ai-companion-cli-go/internal/bot/telegram"
+)
+
+// runBot connects characters to an external chat platform
+func runBot(a *app, args []string) error {
+	if len(args) == 0 || args[0] != "telegram" {
+		return fmt.Errorf("usage: bot telegram [--token T] [--api-url URL] [--allow id,id | --public] [--metrics-addr host:port]")
+	}
+
+	fs := flag.NewFlagSet("bot telegram", flag.ContinueOnError)
+	token := fs.String("token", os.Getenv("TELEGRAM_BOT_TOKEN"), "bot token from @BotFather")
+	apiURL := fs.String("api-url", os.Getenv("TELEGRAM_API_URL"), "Bot API base URL (default "+telegram.DefaultAPIURL+")")
+	allow := fs.String("allow", os.Getenv("TELEGRAM_ALLOWED_USERS"), "comma-separated Telegram user IDs allowed to chat")
+	public := fs.Bool("public", false, "let any Telegram user talk to the bot")
+	metricsAddr := fs.String("metrics-addr", os.Getenv("METRICS_ADDR"), "serve Prometheus metrics at http://<addr>/metrics")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *token == "" {
+		return errors.New("no bot token: pass --token or set TELEGRAM_BOT_TOKEN")
+	}
+	if *allow == "" && !*public {
+		return errors.New("no allowed users: pass --allow <user ids> (or TELEGRAM_ALLOWED_USERS), or --public to answer everyone")
+	}
+
+	// chats without a binding start on the --character choice, or the first character
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+
+	_, orch := a.newOrchestrator()
+	bridge := bot.NewBridge(a.repo, orch, telegram.New(*token, *apiURL), profile.CharacterID)
+	if *allow != "" {
+		bridge.AllowUsers(strings.Split(*allow, ","))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *metricsAddr != "" {
+		go serveMetrics(ctx, *metricsAddr)
+	}
+
+	if sched := a.newScheduler(orch); sched != nil {
+		sched.Lock = bridge.CharacterLock
+		sched.AddDeliverer(bridge.Push)
+		go sched.Start(ctx, a.proactiveInterval())
+	}
+
+	log.Printf("Telegram bot running, new chats talk to %s (%s)", profile.Name, profile.CharacterID)
+	return bridge.Run(ctx)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"ai-companion-cli-go/internal/charcard"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// runCard handles `card import <file>` and `card export [--image avatar.png] <character_id> <file>`
+func runCard(a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: card import|export ...")
+	}
+
+	switch args[0] {
+	case "import":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: card import <card.json|card.png>")
+		}
+		card, err := charcard.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+		profile := card.ToProfile()
+		entries := card.LorebookEntries()
+		err = a.repo.Transaction(func(tx *storage.Repository) error {
+			if err := tx.CreateCharacter(profile); err != nil {
+				return err
+			}
+			for i := range entries {
+				entries[i].CharacterID = profile.CharacterID
+				if err := tx.SaveLorebookEntry(&entries[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %s as %s", profile.Name, profile.CharacterID)
+		if len(entries) > 0 {
+			fmt.Printf(" with %d lorebook entries", len(entries))
+		}
+		fmt.Println()
+		warnInvalidEntries(entries)
+		return nil
+
+	case "export":
+		fs := flag.NewFlagSet("card export", flag.ContinueOnError)
+		image := fs.String("image", "", "PNG avatar to embed the card into (PNG output only)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			return fmt.Errorf("usage: card export [--image avatar.png] <character_id> <card.json|card.png>")
+		}
+
+		profile, err := a.repo.GetCharacter(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if profile == nil {
+			return fmt.Errorf("character %s not found", fs.Arg(0))
+		}
+
+		var base []byte
+		if *image != "" {
+			if base, err = os.ReadFile(*image); err != nil {
+				return err
+			}
+		}
+		entries, err := a.repo.ListLorebookEntries(profile.CharacterID)
+		if err != nil {
+			return err
+		}
+		card := charcard.FromProfile(profile)
+		charcard.AttachLorebook(card, entries)
+		if err := charcard.WriteFile(card, fs.Arg(1), base); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %s to %s\n", profile.Name, fs.Arg(1))
+		return nil
+
+	default:
+		return fmt.Errorf("unknown card command %q", args[0])
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"ai-companion-cli-go/internal/dialogue"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/relationship"
+)
+
+// runCharacters handles `characters list|create|show|delete|relationship|dialogue`
+func runCharacters(a *app, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		chars, err := a.repo.ListCharacters()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tRELATIONSHIP\tLEVEL")
+		for _, c := range chars {
+			level := "-"
+			if rel, _ := a.repo.GetRelationshipState(c.CharacterID); rel != nil {
+				level = fmt.Sprintf("%d (%.0f%%)", rel.IntimacyLevel, rel.IntimacyScore)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.CharacterID, c.Name, c.RelationshipType, level)
+		}
+		return w.Flush()
+
+	case "create":
+		return createCharacter(a, args[1:])
+
+	case "show":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: characters show <id|name>")
+		}
+		profile, err := a.findCharacter(args[1])
+		if err != nil {
+			return err
+		}
+		if profile == nil {
+			return fmt.Errorf("character %q not found", args[1])
+		}
+		out, err := json.MarshalIndent(profile, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+
+	case "delete":
+		fs := flag.NewFlagSet("characters delete", flag.ContinueOnError)
+		yes := fs.Bool("yes", false, "do not ask for confirmation")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: characters delete [--yes] <id|name>")
+		}
+		profile, err := a.findCharacter(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if profile == nil {
+			return fmt.Errorf("character %q not found", fs.Arg(0))
+		}
+		if !*yes && !confirm(fmt.Sprintf("Delete %s (%s) and its whole history?", profile.Name, profile.CharacterID)) {
+			return fmt.Errorf("aborted")
+		}
+		if err := a.repo.DeleteCharacter(profile.CharacterID); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %s\n", profile.Name)
+		return nil
+
+	case "relationship":
+		return characterRelationship(a, args[1:])
+
+	case "dialogue":
+		return characterDialogue(a, args[1:])
+
+	default:
+		return fmt.Errorf("unknown characters command %q", args[0])
+	}
+}
+
+func createCharacter(a *app, args []string) error {
+	fs := flag.NewFlagSet("characters create", flag.ContinueOnError)
+	name := fs.String("name", "", "display name (required)")
+	age := fs.Int("age", 0, "age")
+	gender := fs.String("gender", "", "gender")
+	relationship := fs.String("relationship", "", "relationship type, e.g. 恋人")
+	mbti := fs.String("mbti", "", "MBTI type")
+	tags := fs.String("tags", "", "comma separated personality tags")
+	catchphrase := fs.String("catchphrase", "", "catchphrase")
+	speechStyle := fs.String("speech-style", "", "speech style")
+	voice := fs.String("voice", "", "text-to-speech voice, e.g. nova (default SPEECH_VOICE)")
+	backstory := fs.String("backstory", "", "character backstory")
+	greeting := fs.String("greeting", "", "first message of every new session")
+	examplesFile := fs.String("examples-file", "", "example dialogues ({{user}}: / {{char}}: lines, exchanges separated by <START>)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("--name is required")
+	}
+
+	profile := &models.CharacterProfile{
+		CharacterID:        orchestrator.GenerateCharacterID(),
+		Name:               *name,
+		Age:                *age,
+		Gender:             *gender,
+		RelationshipType:   *relationship,
+		MBTI:               *mbti,
+		Catchphrase:        *catchphrase,
+		SpeechStyle:        *speechStyle,
+		Voice:              *voice,
+		CharacterBackstory: *backstory,
+		ProfileJSON:        models.MapJSON{},
+	}
+	for _, t := range strings.Split(*tags, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			profile.PersonalityTags = append(profile.PersonalityTags, t)
+		}
+	}
+	if *greeting != "" {
+		profile.ProfileJSON[dialogue.GreetingKey] = *greeting
+	}
+	if *examplesFile != "" {
+		data, err := os.ReadFile(*examplesFile)
+		if err != nil {
+			return err
+		}
+		profile.ProfileJSON[dialogue.ExamplesKey] = string(data)
+	}
+
+	if err := a.repo.CreateCharacter(profile); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s as %s\n", profile.Name, profile.CharacterID)
+	return nil
+}
+
+// characterRelationship shows how a relationship progresses, or changes its type with `characters relationship <id|name> <type>`
+func characterRelationship(a *app, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: characters relationship <id|name> [type]")
+	}
+	profile, err := a.findCharacter(args[0])
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("character %q not found", args[0])
+	}
+	_, orch := a.newOrchestrator()
+
+	if len(args) == 2 {
+		transition, err := orch.ChangeRelationshipType(profile, args[1])
+		if err != nil {
+			return err
+		}
+		if transition == nil {
+			fmt.Printf("%s is still a %s (saved as %q)\n", profile.Name, relationship.NormalizeType(profile.RelationshipType), profile.RelationshipType)
+			return nil
+		}
+		fmt.Printf("%s: %s -> %s, level %d -> %d\n", profile.Name, transition.FromType, transition.ToType, transition.FromLevel, transition.ToLevel)
+	}
+
+	orch.EnsureSession(profile.CharacterID)
+	rel, err := a.repo.GetRelationshipState(profile.CharacterID)
+	if err != nil {
+		return err
+	}
+	p := orch.Progression(profile)
+	st := orch.Stage(profile, rel.IntimacyLevel)
+	fmt.Printf("Type:    %s (%s)\n", relationship.NormalizeType(profile.RelationshipType), profile.RelationshipType)
+	fmt.Printf("Level:   %d (%.0f%%) %s\n", rel.IntimacyLevel, rel.IntimacyScore, st.Name)
+	fmt.Printf("Range:   starts at %d, ceiling %d of %d\n", p.StartLevel, p.Ceiling, relationship.MaxLevel)
+
+	transitions, err := a.repo.ListRelationshipTransitions(profile.CharacterID)
+	if err != nil {
+		return err
+	}
+	if len(transitions) > 0 {
+		fmt.Println("\nTransitions:")
+		for _, t := range transitions {
+			fmt.Printf("  %s  %s -> %s  level %d -> %d  (turn %d)\n", t.CreatedAt.Local().Format("2006-01-02 15:04"), t.FromType, t.ToType, t.FromLevel, t.ToLevel, t.TurnIndex)
+		}
+	}
+	return nil
+}
+
+// characterDialogue shows or edits the greetings and example dialogues that anchor a character's voice
+func characterDialogue(a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: characters dialogue <id|name> [--greeting text] [--add-greeting text] [--examples-file file] [--clear-examples]")
+	}
+	profile, err := a.findCharacter(args[0])
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("character %q not found", args[0])
+	}
+
+	fs := flag.NewFlagSet("characters dialogue", flag.ContinueOnError)
+	greeting := fs.String("greeting", "", "set the first greeting")
+	addGreeting := fs.String("add-greeting", "", "add an alternate greeting (one greeting is picked at random per session)")
+	examplesFile := fs.String("examples-file", "", "replace the example dialogues with this file")
+	clearExamples := fs.Bool("clear-examples", false, "remove the example dialogues")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	changed := false
+	if profile.ProfileJSON == nil {
+		profile.ProfileJSON = models.MapJSON{}
+	}
+	if *greeting != "" {
+		profile.ProfileJSON[dialogue.GreetingKey] = *greeting
+		changed = true
+	}
+	if *addGreeting != "" {
+		alts := dialogue.Greetings(profile)
+		if _, ok := profile.ProfileJSON[dialogue.GreetingKey].(string); ok && len(alts) > 0 {
+			alts = alts[1:] // the first is first_mes itself
+		}
+		profile.ProfileJSON[dialogue.AlternateGreetingsKey] = append(alts, *addGreeting)
+		changed = true
+	}
+	if *examplesFile != "" {
+		data, err := os.ReadFile(*examplesFile)
+		if err != nil {
+			return err
+		}
+		if len(dialogue.Parse(string(data), profile.Name)) == 0 {
+			return fmt.Errorf("%s has no {{user}}: / {{char}}: lines", *examplesFile)
+		}
+		profile.ProfileJSON[dialogue.ExamplesKey] = string(data)
+		changed = true
+	}
+	if *clearExamples {
+		delete(profile.ProfileJSON, dialogue.ExamplesKey)
+		changed = true
+	}
+	if changed {
+		if err := a.repo.SaveCharacter(profile); err != nil {
+			return err
+		}
+	}
+
+	greetings := dialogue.Greetings(profile)
+	fmt.Printf("Greetings (%d):\n", len(greetings))
+	for i, g := range greetings {
+		fmt.Printf("  %d. %s\n", i+1, g)
+	}
+
+	examples := dialogue.Examples(profile)
+	kept := len(dialogue.Fit(examples, a.cfg.ExampleBudget))
+	fmt.Printf("\nExample dialogues (%d, %d fit EXAMPLE_TOKEN_BUDGET=%d):\n", len(examples), kept, a.cfg.ExampleBudget)
+	for i, ex := range examples {
+		marker := " "
+		if i >= kept {
+			marker = "x" // over budget, left out of the prompt
+		}
+		fmt.Printf("%s %d.\n", marker, i+1)
+		for _, l := range ex {
+			fmt.Printf("     %-4s %s\n", l.Speaker, l.Text)
+		}
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	var answer string
+	_, _ = fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"ai-companion-cli-go/internal/logging"
+	"ai-companion-cli-go/internal/ui"
+	"ai-companion-cli-go/internal/vault"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runChat opens the interactive TUI, or runs headlessly with --message / --pipe
+func runChat(a *app, args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	fs.StringVar(&a.character, "character", a.character, "character ID or name")
+	message := fs.String("message", "", "send one message, print the reply and exit")
+	pipe := fs.Bool("pipe", false, "read one message per line from stdin and write replies to stdout")
+	asJSON := fs.Bool("json", false, "print each turn as a JSON object with metadata instead of streaming text")
+	voice := fs.String("voice", "", "WAV recording to transcribe and send instead of --message")
+	speak := fs.Bool("speak", false, "read replies aloud (with --message, --voice or --pipe)")
+	var attach pathList
+	fs.Var(&attach, "attach", "image or text file to send with --message or --voice (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *voice != "" && (*message != "" || *pipe) {
+		return fmt.Errorf("--voice replaces --message and cannot be piped")
+	}
+	if len(attach) > 0 && (*message == "" && *voice == "" || *pipe) {
+		return fmt.Errorf("--attach needs --message or --voice")
+	}
+	if *message != "" || *voice != "" || *pipe {
+		return runHeadless(a, headlessOptions{
+			message: *message, voice: *voice, attach: attach, speak: *speak, pipe: *pipe, asJSON: *asJSON,
+		})
+	}
+
+	fmt.Println("Starting AI Companion CLI (Go Edition)...")
+	if a.cfg.APIKey == "" {
+		log.Println("WARNING: OPENAI_API_KEY is not set. Chat features will error out until it is configured.")
+	}
+
+	client, orch := a.newOrchestrator()
+
+	// Snapshot on every launch so a bad session can always be rolled back
+	if _, err := a.backups.Create("startup"); err != nil {
+		log.Printf("WARNING: startup backup failed: %v", err)
+	}
+
+	profile, err := a.selectedCharacter(true)
+	if err != nil {
+		return err
+	}
+
+	// Session management
+	session := orch.EnsureSession(profile.CharacterID)
+
+	// The TUI shows its own unlock prompt, but scripts may already provide the passphrase
+	if pass := os.Getenv(vault.PassphraseEnv); pass != "" {
+		if err := a.vault.Unlock(pass); err != nil {
+			return fmt.Errorf("%s: %w", vault.PassphraseEnv, err)
+		}
+	}
+
+	// Messages the companion left while the chat was closed show up in the history below
+	if !a.vault.Locked() {
+		a.markProactiveSeen(profile, session)
+	}
+
+	// Log lines on stderr would tear up the full-screen UI
+	if a.cfg.LogFile == "" {
+		_ = logging.Setup(io.Discard, a.cfg.LogLevel, a.cfg.LogFormat)
+	}
+
+	// Build and Run TUI
+	model := ui.InitialModel(a.repo, client, orch, profile, session, a.vault)
+	model.SetImageProtocol(a.cfg.ImageProtocol)
+	model.SetVoice(a.cfg.RecordCommand, a.cfg.PlayCommand, a.cfg.VoiceReplies == "on")
+	model.SetReminders(a.chatReminders(orch, profile, session))
+	model.SetProactive(a.chatProactive(orch, profile, session))
+	p := tea.NewProgram(model, tea.WithAltScreen())
+
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("error running program: %w", err)
+	}
+	return nil
+}
+
+// pathList collects a repeatable path flag
+type pathList []string
+
+func (p *pathList) String() string { return strings.Join(*p, ",") }
+
+func (p *pathList) Set(path string) error {
+	*p = append(*p, path)
+	return nil
+}
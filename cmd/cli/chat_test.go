@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"ai-companion-cli-go/internal/llm/llmtest"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/vault"
+)
+
+func TestChatRejectsWrongPassphrase(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	dir := t.TempDir()
+	t.Cleanup(func() { models.SetFieldCipher(nil) })
+	if err := testApp(t, dir, fake.URL).vault.Enable("secret"); err != nil {
+		t.Fatal(err)
+	}
+	models.SetFieldCipher(nil)
+
+	t.Setenv(vault.PassphraseEnv, "not the secret")
+	if err := runChat(testApp(t, dir, fake.URL), nil); !errors.Is(err, vault.ErrWrongPassphrase) {
+		t.Fatalf("err = %v, want the wrong passphrase reported before the chat opens", err)
+	}
+}
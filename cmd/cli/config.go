@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+)
+
+// runConfig prints the effective configuration after env, .env and flags
+func runConfig(a *app, args []string) error {
+	c := a.cfg
+	endpoint := c.ModelProfile.BaseURL
+	if endpoint == "" {
+		endpoint = "https://api.openai.com/v1 (default)"
+	}
+	backupDir := a.backups.Dir()
+	promptDir := c.PromptDir
+	if promptDir == "" {
+		promptDir = "(embedded templates only)"
+	}
+	audioEndpoint := c.AudioBaseURL
+	if audioEndpoint == "" {
+		audioEndpoint = "(chat endpoint)"
+	}
+	vision := "text only for " + c.ModelProfile.PrimaryModel
+	if llm.VisionEnabled(c.Vision, c.ModelProfile.PrimaryModel) {
+		vision = "pictures sent to " + c.ModelProfile.PrimaryModel
+	}
+	imageEndpoint := c.ImageBaseURL
+	if imageEndpoint == "" {
+		imageEndpoint = "(chat endpoint)"
+	}
+	anchorDir := c.AnchorDir
+	if anchorDir == "" {
+		anchorDir = "(working directory)"
+	}
+	_, orch := a.newOrchestrator()
+	var tools []string
+	for _, t := range orch.Tools() {
+		tools = append(tools, t.Name)
+	}
+	toolList := strings.Join(tools, ", ")
+	if toolList == "" {
+		toolList = "none"
+	}
+
+	fmt.Printf("api_key            %s\n", maskKey(c.APIKey))
+	fmt.Printf("endpoint           %s\n", endpoint)
+	fmt.Printf("primary_model      %s\n", c.ModelProfile.PrimaryModel)
+	fmt.Printf("fallback_model     %s\n", c.ModelProfile.FallbackModel)
+	fmt.Printf("timeout_ms         %d\n", c.ModelProfile.TimeoutMs)
+	fmt.Printf("db_path            %s\n", a.db.GetDBPath())
+	fmt.Printf("encryption         %t\n", a.vault.Enabled())
+	fmt.Printf("backup_dir         %s\n", backupDir)
+	fmt.Printf("backup_keep        %d\n", c.BackupKeep)
+	fmt.Printf("backup_every_turns %d\n", c.BackupEveryTurns)
+	fmt.Printf("proactive_minutes  %d\n", c.ProactiveEveryMinutes)
+	fmt.Printf("budget_daily_usd   %s\n", budgetLimit(c.BudgetDailyUSD))
+	fmt.Printf("budget_monthly_usd %s\n", budgetLimit(c.BudgetMonthlyUSD))
+	fmt.Printf("budget_action      %s\n", c.BudgetAction)
+	fmt.Printf("prompt_dir         %s\n", promptDir)
+	fmt.Printf("prompt_locale      %s\n", c.PromptLocale)
+	fmt.Printf("lorebook_depth     %d\n", c.LorebookScanDepth)
+	fmt.Printf("lorebook_budget    %d\n", c.LorebookTokenBudget)
+	fmt.Printf("user_name          %s\n", c.UserName)
+	fmt.Printf("example_budget     %d\n", c.ExampleBudget)
+	fmt.Printf("anchor_dir         %s\n", anchorDir)
+	fmt.Printf("anchor_check       %s\n", c.AnchorCheck)
+	fmt.Printf("image_model        %s\n", c.ImageModel)
+	fmt.Printf("image_size         %s\n", c.ImageSize)
+	fmt.Printf("image_endpoint     %s\n", imageEndpoint)
+	fmt.Printf("image_protocol     %s\n", c.ImageProtocol)
+	fmt.Printf("vision             %s (%s)\n", c.Vision, vision)
+	fmt.Printf("attachment_budget  %d\n", c.AttachmentBudget)
+	fmt.Printf("transcription      %s\n", c.TranscriptionModel)
+	fmt.Printf("speech             %s, voice %s, %s\n", c.SpeechModel, c.SpeechVoice, c.SpeechFormat)
+	fmt.Printf("audio_endpoint     %s\n", audioEndpoint)
+	fmt.Printf("record_command     %s\n", c.RecordCommand)
+	fmt.Printf("play_command       %s\n", c.PlayCommand)
+	fmt.Printf("voice_replies      %s\n", c.VoiceReplies)
+	fmt.Printf("tools              %s (%s)\n", c.Tools, toolList)
+	return nil
+}
+
+// runDoctor checks the pieces a working companion depends on
+func runDoctor(a *app, args []string) error {
+	failed := 0
+	check := func(name string, err error, okDetail string) {
+		if err != nil {
+			failed++
+			fmt.Printf("[FAIL] %-10s %v\n", name, err)
+			return
+		}
+		fmt.Printf("[ OK ] %-10s %s\n", name, okDetail)
+	}
+
+	if a.cfg.APIKey == "" {
+		check("api key", fmt.Errorf("OPENAI_API_KEY is not set"), "")
+	} else {
+		check("api key", nil, maskKey(a.cfg.APIKey))
+
+		client := llm.NewClient(a.cfg.APIKey, a.cfg.ModelProfile)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ids, err := client.ListModels(ctx)
+		cancel()
+		check("endpoint", err, fmt.Sprintf("reachable, %d models", len(ids)))
+	}
+
+	check("database", a.db.IntegrityCheck(), a.db.GetDBPath()+" passed integrity_check")
+
+	if a.vault.Enabled() {
+		fmt.Printf("[ OK ] %-10s enabled\n", "encryption")
+	} else {
+		fmt.Printf("[INFO] %-10s disabled (run `encryption enable` to protect your history)\n", "encryption")
+	}
+
+	chars, err := a.repo.ListCharacters()
+	check("characters", err, fmt.Sprintf("%d found", len(chars)))
+
+	snapshots, err := a.backups.List()
+	if err == nil {
+		err = checkWritable(a.backups.Dir())
+	}
+	detail := fmt.Sprintf("%d snapshots in %s", len(snapshots), a.backups.Dir())
+	if len(snapshots) > 0 {
+		detail += ", latest " + snapshots[0].CreatedAt.Format("2006-01-02 15:04")
+	}
+	check("backups", err, detail)
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(filepath.Clean(f.Name()))
+}
+
+func maskKey(key string) string {
+	if key == "" {
+		return "(not set)"
+	}
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:3] + "…" + key[len(key)-4:]
+}
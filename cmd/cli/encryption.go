@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"ai-companion-cli-go/internal/vault"
+)
+
+// runEncryption handles `encryption status|enable|rekey`
+func runEncryption(a *app, args []string) error {
+	v := a.vault
+	if len(args) != 1 {
+		return fmt.Errorf("usage: encryption status|enable|rekey")
+	}
+
+	switch args[0] {
+	case "status":
+		if v.Enabled() {
+			fmt.Println("Encryption: enabled (argon2id + AES-256-GCM)")
+		} else {
+			fmt.Println("Encryption: disabled")
+		}
+		return nil
+
+	case "enable":
+		pass, err := readNewPassphrase()
+		if err != nil {
+			return err
+		}
+		if err := v.Enable(pass); err != nil {
+			return err
+		}
+		fmt.Println("Messages, memory facts and summaries are now encrypted.")
+		return nil
+
+	case "rekey":
+		if !v.Enabled() {
+			return errors.New("database is not encrypted; run `encryption enable` first")
+		}
+		if err := v.UnlockInteractive(); err != nil {
+			return err
+		}
+		pass, err := readNewPassphrase()
+		if err != nil {
+			return err
+		}
+		if err := v.Rekey(pass); err != nil {
+			return err
+		}
+		fmt.Println("Database re-encrypted with the new passphrase.")
+		return nil
+
+	default:
+		return fmt.Errorf("unknown encryption command %q", args[0])
+	}
+}
+
+// readNewPassphrase asks for a new passphrase twice
+func readNewPassphrase() (string, error) {
+	pass, err := vault.ReadPassphrase("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := vault.ReadPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if pass != confirm {
+		return "", errors.New("passphrases do not match")
+	}
+	return pass, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"ai-companion-cli-go/internal/audio"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/vault"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// headlessOptions are the chat flags of a run without the TUI
+type headlessOptions struct {
+	message string   // --message
+	voice   string   // --voice: a WAV recording transcribed into the message
+	attach  []string // --attach
+	speak   bool     // --speak: read replies aloud
+	pipe    bool     // --pipe
+	asJSON  bool     // --json
+}
+
+// runHeadless drives the orchestrator without the TUI: one --message or --voice recording (with the files
+// in --attach), or one turn per stdin line with --pipe
+func runHeadless(a *app, opts headlessOptions) error {
+	if err := a.vault.UnlockInteractive(); err != nil {
+		return err
+	}
+	var attachments []models.Media
+	for _, path := range opts.attach {
+		m, err := media.Load(path)
+		if err != nil {
+			return err
+		}
+		attachments = append(attachments, *m)
+	}
+
+	profile, err := a.selectedCharacter(true)
+	if err != nil {
+		return err
+	}
+	_, orch := a.newOrchestrator()
+	session := orch.EnsureSession(profile.CharacterID)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	message := opts.message
+	if opts.voice != "" {
+		data, err := os.ReadFile(opts.voice)
+		if err != nil {
+			return err
+		}
+		if _, err := audio.Duration(data); err != nil {
+			return fmt.Errorf("%s: %w", opts.voice, err)
+		}
+		text, recording, err := orch.Transcribe(ctx, profile, session, filepath.Base(opts.voice), data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "You said: %s\n", text)
+		message = text
+		attachments = append(attachments, *recording)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	enc := json.NewEncoder(out)
+
+	turn := func(text string, attachments ...models.Media) error {
+		var onToken func(string)
+		if !opts.asJSON {
+			onToken = func(chunk string) {
+				out.WriteString(chunk)
+				out.Flush()
+			}
+		}
+
+		result, err := orch.Reply(ctx, text, profile, session, onToken, attachments...)
+		if err != nil {
+			return err
+		}
+		if opts.asJSON {
+			err = enc.Encode(result)
+		} else {
+			out.WriteString("\n")
+			err = out.Flush()
+		}
+		if err != nil || !opts.speak {
+			return err
+		}
+		return speakLastReply(ctx, a, orch, profile, session)
+	}
+
+	if !opts.pipe {
+		return turn(message, attachments...)
+	}
+
+	// The passphrase prompt may have buffered some of the piped lines already
+	in := bufio.NewScanner(vault.Stdin)
+	in.Buffer(make([]byte, 64*1024), 1024*1024)
+	for in.Scan() {
+		text := strings.TrimSpace(in.Text())
+		if text == "" {
+			continue
+		}
+		if err := turn(text); err != nil {
+			if opts.asJSON {
+				enc.Encode(map[string]string{"error": err.Error()})
+				out.Flush()
+			}
+			return err
+		}
+	}
+	return in.Err()
+}
+
+// speakLastReply reads the newest reply of the session aloud and keeps the audio with it
+func speakLastReply(ctx context.Context, a *app, orch *orchestrator.Orchestrator, profile *models.CharacterProfile, session *models.SessionState) error {
+	msg, err := a.repo.GetLastMessageByRole(session.SessionID, openai.ChatMessageRoleAssistant)
+	if err != nil || msg == nil {
+		return err
+	}
+	m, err := orch.Speak(ctx, profile, msg)
+	if err != nil {
+		return err
+	}
+	return playMedia(a, m)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ai-companion-cli-go/internal/config"
+	"ai-companion-cli-go/internal/llm/llmtest"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/vault"
+)
+
+// testApp opens an app on the database in dir, talking to the model endpoint at baseURL
+func testApp(t *testing.T, dir, baseURL string) *app {
+	t.Helper()
+	a, err := newApp(&config.AppConfig{
+		APIKey:       "sk-test",
+		DBPath:       filepath.Join(dir, "companion.db"),
+		ModelProfile: models.ModelProfile{PrimaryModel: "gpt-test", BaseURL: baseURL},
+		BackupDir:    filepath.Join(dir, "backups"),
+		BudgetAction: "downgrade",
+		AnchorCheck:  "off",
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if conn, err := a.db.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	return a
+}
+
+// pipeStdin replaces standard input with input for the rest of the test
+func pipeStdin(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	stdin, reader := os.Stdin, vault.Stdin
+	os.Stdin, vault.Stdin = r, bufio.NewReader(r)
+	t.Cleanup(func() {
+		os.Stdin, vault.Stdin = stdin, reader
+		r.Close()
+	})
+}
+
+func TestPipeAfterPassphrase(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	dir := t.TempDir()
+	t.Cleanup(func() { models.SetFieldCipher(nil) })
+	if err := testApp(t, dir, fake.URL).vault.Enable("secret"); err != nil {
+		t.Fatal(err)
+	}
+	models.SetFieldCipher(nil)
+
+	a := testApp(t, dir, fake.URL)
+	if !a.vault.Locked() {
+		t.Fatal("the reopened vault is not locked")
+	}
+	t.Setenv(vault.PassphraseEnv, "")
+	pipeStdin(t, "secret\nhello\nbye\n")
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	if err := runHeadless(a, headlessOptions{pipe: true}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(fake.Requests()); n != 2 {
+		t.Fatalf("%d chat requests, want one per piped line after the passphrase", n)
+	}
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := a.repo.GetLastMessageByRole("sess_"+profile.CharacterID, "user")
+	if err != nil || msg == nil || msg.Content.String() != "bye" {
+		t.Fatalf("last user message = %+v, %v; want the last piped line", msg, err)
+	}
+}
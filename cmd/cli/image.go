@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+)
+
+const imageUsage = "usage: image portrait | selfie [scene] | list [--limit N] | show [--protocol P] <id|last> | save <id|last> <file>"
+
+// runImage generates, lists and shows the pictures of the selected character
+func runImage(a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(imageUsage)
+	}
+	sub, args := args[0], args[1:]
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "portrait", "selfie":
+		_, orch := a.newOrchestrator()
+		ctx, cancel := context.WithTimeout(context.Background(), llm.ImageTimeout)
+		defer cancel()
+
+		var m *models.Media
+		if sub == "portrait" {
+			m, err = orch.Portrait(ctx, profile)
+		} else {
+			session := orch.EnsureSession(profile.CharacterID)
+			m, _, err = orch.Selfie(ctx, profile, session, strings.Join(args, " "))
+		}
+		if err != nil {
+			return err
+		}
+		if err := media.Render(os.Stdout, m, a.cfg.ImageProtocol); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %s #%d (%s)\n", m.Kind, m.ID, m.Model)
+		return nil
+	case "list":
+		return listImages(a, profile, args)
+	case "show":
+		fs := flag.NewFlagSet("image show", flag.ContinueOnError)
+		protocol := fs.String("protocol", a.cfg.ImageProtocol, "auto, kitty, iterm, sixel or text")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		m, err := imageByRef(a, profile, fs.Args())
+		if err != nil {
+			return err
+		}
+		return media.Render(os.Stdout, m, *protocol)
+	case "save":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: image save <id|last> <file>")
+		}
+		m, err := imageByRef(a, profile, args[:1])
+		if err != nil {
+			return err
+		}
+		data, err := media.Bytes(m)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(args[1], data, 0o600); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s #%d to %s\n", m.Kind, m.ID, args[1])
+		return nil
+	default:
+		return fmt.Errorf(imageUsage)
+	}
+}
+
+func listImages(a *app, profile *models.CharacterProfile, args []string) error {
+	fs := flag.NewFlagSet("image list", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "number of pictures to list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	items, err := a.repo.ListMediaPage(profile.CharacterID, 0, *limit, media.KindPortrait, media.KindSelfie, media.KindImage)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Printf("No pictures of %s yet; try `image portrait` or `image selfie`.\n", profile.Name)
+		return nil
+	}
+	fmt.Printf("%-6s %-19s %-9s %-8s %-12s %s\n", "ID", "TIME", "KIND", "MESSAGE", "MODEL", "FILE")
+	for _, m := range items {
+		fmt.Printf("%-6d %-19s %-9s %-8d %-12s %s\n", m.ID, m.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			m.Kind, m.MessageID, m.Model, media.Placeholder(&m))
+	}
+	return nil
+}
+
+// imageByRef loads the picture named by args[0] (an ID or "last") and checks it belongs to the character
+func imageByRef(a *app, profile *models.CharacterProfile, args []string) (*models.Media, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf(imageUsage)
+	}
+	if args[0] == "last" {
+		m, err := a.repo.GetLastMedia(profile.CharacterID)
+		if err == nil && m == nil {
+			err = fmt.Errorf("no pictures of %s yet", profile.Name)
+		}
+		return m, err
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid picture ID %q", args[0])
+	}
+	m, err := a.repo.GetMedia(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || m.CharacterID != profile.CharacterID {
+		return nil, fmt.Errorf("picture %d not found for %s", id, profile.Name)
+	}
+	return m, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"ai-companion-cli-go/internal/charcard"
+	"ai-companion-cli-go/internal/lorebook"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/storage"
+	"ai-companion-cli-go/internal/tokens"
+)
+
+const lorebookUsage = "usage: lorebook list | show <id> | add --keys k1,k2 --content text [flags] | edit <id> [flags] | delete <id> | enable <id> | disable <id> | import [card.json|card.png] | test <text>"
+
+// runLorebook manages the world-info entries of the selected character
+func runLorebook(a *app, args []string) error {
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "list":
+		return listLorebook(a, profile)
+	case "show":
+		entry, err := lorebookEntry(a, profile, args)
+		if err != nil {
+			return err
+		}
+		printLorebookEntry(entry)
+		return nil
+	case "add":
+		entry := &models.LorebookEntry{CharacterID: profile.CharacterID, Enabled: true}
+		if err := parseEntryFlags("lorebook add", entry, args); err != nil {
+			return err
+		}
+		if err := saveLorebookEntry(a, entry); err != nil {
+			return err
+		}
+		fmt.Printf("Added lorebook entry %d\n", entry.ID)
+		return nil
+	case "edit":
+		entry, err := lorebookEntry(a, profile, args[:min(1, len(args))])
+		if err != nil {
+			return err
+		}
+		if err := parseEntryFlags("lorebook edit", entry, args[1:]); err != nil {
+			return err
+		}
+		if err := saveLorebookEntry(a, entry); err != nil {
+			return err
+		}
+		fmt.Printf("Updated lorebook entry %d\n", entry.ID)
+		return nil
+	case "enable", "disable":
+		entry, err := lorebookEntry(a, profile, args)
+		if err != nil {
+			return err
+		}
+		entry.Enabled = sub == "enable"
+		if err := a.repo.SaveLorebookEntry(entry); err != nil {
+			return err
+		}
+		fmt.Printf("Lorebook entry %d %sd\n", entry.ID, sub)
+		return nil
+	case "delete":
+		entry, err := lorebookEntry(a, profile, args)
+		if err != nil {
+			return err
+		}
+		if err := a.repo.DeleteLorebookEntry(entry.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted lorebook entry %d\n", entry.ID)
+		return nil
+	case "import":
+		return importLorebook(a, profile, args)
+	case "test":
+		return testLorebook(a, profile, args)
+	default:
+		return fmt.Errorf(lorebookUsage)
+	}
+}
+
+func listLorebook(a *app, profile *models.CharacterProfile) error {
+	entries, err := a.repo.ListLorebookEntries(profile.CharacterID)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("%s has no lorebook entries.\n", profile.Name)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tON\tORDER\tPRIO\tPOSITION\tNAME\tKEYS\tTOKENS")
+	for _, e := range entries {
+		on := "yes"
+		if !e.Enabled {
+			on = "no"
+		}
+		keys := strings.Join(e.Keys, ", ")
+		if e.Constant {
+			keys = "(constant) " + keys
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\t%s\t%s\t%d\n", e.ID, on, e.InsertionOrder, e.Priority,
+			positionOrDefault(e.Position), e.Name, keys, tokens.Estimate(e.Content))
+	}
+	return w.Flush()
+}
+
+// lorebookEntry loads the entry named by args[0] and checks it belongs to the character
+func lorebookEntry(a *app, profile *models.CharacterProfile, args []string) (*models.LorebookEntry, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf(lorebookUsage)
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry ID %q", args[0])
+	}
+	entry, err := a.repo.GetLorebookEntry(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || entry.CharacterID != profile.CharacterID {
+		return nil, fmt.Errorf("lorebook entry %d not found for %s", id, profile.Name)
+	}
+	return entry, nil
+}
+
+// parseEntryFlags sets the fields of an entry from flags; fields without a flag keep their value
+func parseEntryFlags(name string, e *models.LorebookEntry, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&e.Name, "name", e.Name, "label shown in listings")
+	fs.Func("keys", "comma separated keys that activate the entry", func(s string) error {
+		e.Keys = splitList(s)
+		return nil
+	})
+	fs.Func("secondary", "comma separated keys of which one must also appear", func(s string) error {
+		e.SecondaryKeys = splitList(s)
+		return nil
+	})
+	fs.StringVar(&e.Content, "content", e.Content, "text inserted into the prompt")
+	fs.Func("content-file", "read the content from a file", func(path string) error {
+		data, err := os.ReadFile(path)
+		e.Content = string(data)
+		return err
+	})
+	fs.BoolVar(&e.Regex, "regex", e.Regex, "keys are regular expressions")
+	fs.BoolVar(&e.CaseSensitive, "case-sensitive", e.CaseSensitive, "match keys case-sensitively")
+	fs.StringVar(&e.Position, "position", e.Position, "before_char (default) or after_char")
+	fs.IntVar(&e.Priority, "priority", e.Priority, "higher entries are kept first when the token budget runs out")
+	fs.IntVar(&e.InsertionOrder, "order", e.InsertionOrder, "insertion order, lower first")
+	fs.IntVar(&e.MaxTokens, "max-tokens", e.MaxTokens, "cut the content to this many tokens (0 = no cap)")
+	fs.BoolVar(&e.Constant, "constant", e.Constant, "always insert, regardless of keys")
+	fs.BoolVar(&e.NoRecursion, "no-recursion", e.NoRecursion, "do not let this entry's content activate other entries")
+	fs.BoolVar(&e.Enabled, "enabled", e.Enabled, "entry is active")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return nil
+}
+
+func saveLorebookEntry(a *app, e *models.LorebookEntry) error {
+	if err := lorebook.Validate(e); err != nil {
+		return err
+	}
+	return a.repo.SaveLorebookEntry(e)
+}
+
+func printLorebookEntry(e *models.LorebookEntry) {
+	fmt.Printf("ID:        %d\n", e.ID)
+	fmt.Printf("Name:      %s\n", e.Name)
+	fmt.Printf("Enabled:   %t\n", e.Enabled)
+	fmt.Printf("Keys:      %s\n", strings.Join(e.Keys, ", "))
+	if len(e.SecondaryKeys) > 0 {
+		fmt.Printf("Secondary: %s\n", strings.Join(e.SecondaryKeys, ", "))
+	}
+	fmt.Printf("Matching:  regex=%t case_sensitive=%t constant=%t no_recursion=%t\n", e.Regex, e.CaseSensitive, e.Constant, e.NoRecursion)
+	fmt.Printf("Placement: %s, order %d, priority %d, max_tokens %d\n", positionOrDefault(e.Position), e.InsertionOrder, e.Priority, e.MaxTokens)
+	fmt.Printf("\n%s\n", e.Content)
+}
+
+// importLorebook adds the character_book of a card file, or of the card the character was imported from
+func importLorebook(a *app, profile *models.CharacterProfile, args []string) error {
+	var entries []models.LorebookEntry
+	switch len(args) {
+	case 0:
+		entries = charcard.StoredLorebook(profile)
+	case 1:
+		card, err := charcard.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		entries = card.LorebookEntries()
+	default:
+		return fmt.Errorf("usage: lorebook import [card.json|card.png]")
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no character_book entries to import")
+	}
+
+	err := a.repo.Transaction(func(tx *storage.Repository) error {
+		for i := range entries {
+			entries[i].CharacterID = profile.CharacterID
+			if err := tx.SaveLorebookEntry(&entries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d lorebook entries into %s\n", len(entries), profile.Name)
+	warnInvalidEntries(entries)
+	return nil
+}
+
+// warnInvalidEntries points out imported entries that lorebook add would have refused, e.g. with a
+// broken regex key: they are kept, but never activate
+func warnInvalidEntries(entries []models.LorebookEntry) {
+	for i := range entries {
+		if err := lorebook.Validate(&entries[i]); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: lorebook entry %d (%s): %v\n", entries[i].ID, entries[i].Name, err)
+		}
+	}
+}
+
+// testLorebook shows which entries a message would activate, on top of the recent conversation
+func testLorebook(a *app, profile *models.CharacterProfile, args []string) error {
+	fs := flag.NewFlagSet("lorebook test", flag.ContinueOnError)
+	history := fs.Bool("history", true, "scan the recent conversation as well")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: lorebook test [--history=false] <text>")
+	}
+
+	_, orch := a.newOrchestrator()
+	var msgs []models.ChatMessage
+	if *history {
+		var err error
+		if msgs, err = a.repo.GetRecentSessionMessages(orch.EnsureSession(profile.CharacterID).SessionID, 10); err != nil {
+			return err
+		}
+	}
+	msgs = append(msgs, models.ChatMessage{Role: "user", Content: models.EncryptedString(strings.Join(fs.Args(), " "))})
+
+	res := orch.ScanLorebook(profile, msgs)
+	settings := orch.LorebookSettings(profile)
+	fmt.Printf("%d entries activated, %d of %d tokens (scan depth %d)\n", len(res.Activated), res.Tokens, settings.TokenBudget, settings.ScanDepth)
+	for _, e := range res.Activated {
+		fmt.Printf("  + %d %s [%s]\n", e.ID, e.Name, positionOrDefault(e.Position))
+	}
+	for _, e := range res.Skipped {
+		fmt.Printf("  - %d %s (over budget)\n", e.ID, e.Name)
+	}
+	return nil
+}
+
+func positionOrDefault(position string) string {
+	if position == "" {
+		return lorebook.PositionBeforeChar
+	}
+	return position
+}
+
+// splitList splits a comma separated flag value, dropping blanks
+func splitList(s string) models.StringSlice {
+	var out models.StringSlice
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
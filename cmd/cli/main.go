@@ -1,72 +1,129 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 
 	"ai-companion-cli-go/internal/config"
-	"ai-companion-cli-go/internal/llm"
-	"ai-companion-cli-go/internal/models"
-	"ai-companion-cli-go/internal/orchestrator"
-	"ai-companion-cli-go/internal/storage"
-	"ai-companion-cli-go/internal/ui"
-
-	tea "github.com/charmbracelet/bubbletea"
+	"ai-companion-cli-go/internal/logging"
 )
 
-func main() {
-	fmt.Println("Starting AI Companion CLI (Go Edition)...")
+// command is one entry of the CLI command tree
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, args []string) error
+	// skipUnlock commands either never read encrypted columns or unlock the vault themselves
+	skipUnlock bool
+}
 
-	// 1. Load config
-	appCfg := config.LoadConfig()
+var commands []command
 
-	// 2. Initialize Database layer
-	db := storage.NewDB(appCfg.DBPath)
-	err := db.Initialize()
-	if err != nil {
-		log.Fatalf("Failed to init database: %v", err)
+func init() {
+	commands = []command{
+		{name: "chat", summary: "Open the interactive chat (default)", run: runChat, skipUnlock: true},
+		{name: "characters", summary: "list | create | show <id> | delete <id> | relationship <id> [type] | dialogue <id>", run: runCharacters},
+		{name: "serve", summary: "Serve companions over an OpenAI-compatible HTTP API", run: runServe},
+		{name: "mcp", summary: "Serve companion memory to other agents over MCP (stdio)", run: runMCP, skipUnlock: true},
+		{name: "bot", summary: "Chat from other apps: bot telegram [--allow ids]", run: runBot},
+		{name: "proactive", summary: "Let companions send due proactive messages now [--dry-run]", run: runProactive},
+		{name: "reminders", summary: "Reminders the character promised: reminders [--all] | cancel <id>", run: runReminders},
+		{name: "sessions", summary: "List sessions of the selected character", run: runSessions},
+		{name: "memory", summary: "Show relationship, emotion, facts and summaries", run: runMemory},
+		{name: "export", summary: "Export a character bundle: export <id> <file.json|file.zip>", run: runExport},
+		{name: "import", summary: "Import a character bundle: import [--on-conflict mode] <file>", run: runImport},
+		{name: "card", summary: "Character Card V2: card import <file> | card export <id> <file>", run: runCard},
+		{name: "lorebook", summary: "World info: lorebook list | add --keys k --content c | edit <id> | delete <id> | import [card] | test <text>", run: runLorebook},
+		{name: "anchor", summary: "Persona anchor: anchor show | init [file] | set <file> | clear | check [--llm] <text> | flags", run: runAnchor},
+		{name: "image", summary: "Pictures: image portrait | selfie [scene] | list | show <id|last> | save <id> <file>", run: runImage},
+		{name: "voice", summary: "Voice: voice transcribe <file.wav> | record | say [message-id] | play <id|last> | list | set [voice]", run: runVoice},
+		{name: "prompts", summary: "Prompt templates: prompts list | preview [--name N] [--locale L] | stages | export [dir]", run: runPrompts, skipUnlock: true},
+		{name: "traces", summary: "Per-turn debug traces: traces [--limit N] | traces show <id|last>", run: runTraces},
+		{name: "usage", summary: "Token usage and cost: usage [--monthly] [--by model|character|feature] | usage prices", run: runUsage, skipUnlock: true},
+		{name: "backup", summary: "create | list | restore <name>", run: runBackup, skipUnlock: true},
+		{name: "encryption", summary: "status | enable | rekey", run: runEncryption, skipUnlock: true},
+		{name: "config", summary: "Print the effective configuration", run: runConfig, skipUnlock: true},
+		{name: "doctor", summary: "Check API key, endpoint, database and backups", run: runDoctor, skipUnlock: true},
 	}
-	repo := storage.NewRepository(db)
+}
 
-	// 3. Initialize OpenAI Client
-	client := llm.NewClient(appCfg.APIKey, appCfg.ModelProfile)
+func main() {
+	// 1. Load config (env / .env), then let global flags override it
+	appCfg := config.LoadConfig()
 
-	// 4. Initialize Orchestrator
-	orch := orchestrator.NewOrchestrator(repo, client)
+	global := flag.NewFlagSet("ai-companion", flag.ExitOnError)
+	global.StringVar(&appCfg.DBPath, "db", appCfg.DBPath, "path to the SQLite database")
+	global.StringVar(&appCfg.ModelProfile.PrimaryModel, "model", appCfg.ModelProfile.PrimaryModel, "chat model name")
+	global.StringVar(&appCfg.ModelProfile.BaseURL, "endpoint", appCfg.ModelProfile.BaseURL, "OpenAI-compatible base URL")
+	character := global.String("character", "", "character ID or name (defaults to the first character)")
+	global.Usage = func() { printUsage(global) }
+	_ = global.Parse(os.Args[1:])
 
-	// --- CLI MOCKING BEHAVIOR FOR FIRST RUN ---
-	// If no characters exist in DB, create a default one for the user so TUI handles nicely
-	chars, _ := repo.ListCharacters()
-	var currentUserProfile *models.CharacterProfile
+	name, args := "chat", global.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage(global)
+		return
+	}
 
-	if len(chars) == 0 {
-		currentUserProfile = &models.CharacterProfile{
-			CharacterID:      orchestrator.GenerateCharacterID(),
-			Name:             "苏晚晴",
-			Age:              24,
-			Gender:           "女性",
-			RelationshipType: "恋人",
-			MBTI:             "INFJ",
-			PersonalityTags:  []string{"温柔体贴", "知性"},
-			Catchphrase:      "我在呢。",
-			SpeechStyle:      "温柔自然，像恋人日常聊天",
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
 		}
-		repo.CreateCharacter(currentUserProfile)
-	} else {
-		currentUserProfile = &chars[0]
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q\n\n", name)
+		printUsage(global)
+		os.Exit(2)
 	}
 
-	// Session management
-	session := orch.EnsureSession(currentUserProfile.CharacterID)
+	// 2. Structured logs go to LOG_FILE, or stderr
+	logOut := io.Writer(os.Stderr)
+	if appCfg.LogFile != "" {
+		f, err := logging.OpenFile(appCfg.LogFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		logOut = f
+	}
+	if err := logging.Setup(logOut, appCfg.LogLevel, appCfg.LogFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
 
-	// 5. Build and Run TUI
-	// The original POC Python uses Textual or Rich. Here we use Bubbletea model
-	model := ui.InitialModel(repo, client, orch, currentUserProfile, session)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	// 3. Initialize database, vault and backups
+	a, err := newApp(appCfg, *character)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running program: %v\n", err)
+	if !cmd.skipUnlock {
+		err = a.vault.UnlockInteractive()
+	}
+	if err == nil {
+		err = cmd.run(a, args)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+func printUsage(global *flag.FlagSet) {
+	out := global.Output()
+	fmt.Fprintln(out, "Usage: ai-companion [global flags] <command> [args]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(out, "\nGlobal flags:")
+	global.PrintDefaults()
+}
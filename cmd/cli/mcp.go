@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"ai-companion-cli-go/internal/mcp"
+	"ai-companion-cli-go/internal/vault"
+)
+
+// runMCP serves companion memory to other agents as an MCP server over stdio
+func runMCP(a *app, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: mcp (takes no arguments; configure it as a stdio server in your MCP client)")
+	}
+
+	// stdin carries the protocol, so an encrypted database can only be unlocked from the environment
+	if a.vault.Locked() {
+		pass := os.Getenv(vault.PassphraseEnv)
+		if pass == "" {
+			return fmt.Errorf("database is encrypted: set %s for the MCP server", vault.PassphraseEnv)
+		}
+		if err := a.vault.Unlock(pass); err != nil {
+			return err
+		}
+	}
+
+	_, orch := a.newOrchestrator()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return mcp.NewServer(a.repo, orch).Serve(ctx, os.Stdin, os.Stdout)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// runSessions lists the sessions held with the selected character
+func runSessions(a *app, args []string) error {
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+	sessions, err := a.repo.ListSessionsByCharacter(profile.CharacterID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tSTATE\tTURNS\tSTARTED\tUPDATED")
+	for _, s := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", s.SessionID, s.State, s.TurnIndex,
+			s.StartedAt.Format("2006-01-02 15:04"), s.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// runMemory prints what the selected character knows and feels about the user
+func runMemory(a *app, args []string) error {
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s (%s)\n", profile.Name, profile.CharacterID)
+
+	if rel, err := a.repo.GetRelationshipState(profile.CharacterID); err != nil {
+		return err
+	} else if rel != nil {
+		fmt.Printf("\nRelationship: level %d, %.1f/100 (turn %d)\n", rel.IntimacyLevel, rel.IntimacyScore, rel.LastUpdatedTurn)
+		if rel.RelationshipNarrative != "" {
+			fmt.Printf("  %s\n", rel.RelationshipNarrative)
+		}
+	}
+
+	if emo, err := a.repo.GetEmotionState(profile.CharacterID); err != nil {
+		return err
+	} else if emo != nil {
+		fmt.Printf("\nEmotion: %s (%s)\n", emo.CurrentEmotion, emo.EmotionCause)
+	}
+
+	facts, err := a.repo.ListMemoryFactsByCharacter(profile.CharacterID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nFacts (%d):\n", len(facts))
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, f := range facts {
+		fmt.Fprintf(w, "  %s\t%s\t%s = %s\t(%.2f)\n", f.FactID, f.FactType, f.FactKey, f.FactValue, f.Confidence)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	summaries, err := a.repo.ListMemorySummariesByCharacter(profile.CharacterID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nSummaries (%d):\n", len(summaries))
+	for _, s := range summaries {
+		fmt.Printf("  v%d turns %d-%d: %s\n", s.Version, s.BatchStartTurn, s.BatchEndTurn, s.SummaryText)
+	}
+	return nil
+}
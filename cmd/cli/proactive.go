@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/proactive"
+)
+
+// newScheduler builds the proactive scheduler; nil when PROACTIVE_EVERY_MINUTES is 0
+func (a *app) newScheduler(orch *orchestrator.Orchestrator) *proactive.Scheduler {
+	if a.cfg.ProactiveEveryMinutes <= 0 {
+		return nil
+	}
+	return proactive.NewScheduler(a.repo, orch)
+}
+
+// proactiveInterval is the tick of long-running schedulers (serve, bot)
+func (a *app) proactiveInterval() time.Duration {
+	return time.Duration(a.cfg.ProactiveEveryMinutes) * time.Minute
+}
+
+// markProactiveSeen marks the messages the character left in the chat UI's session as delivered, since the
+// UI shows them as part of the history
+func (a *app) markProactiveSeen(profile *models.CharacterProfile, session *models.SessionState) {
+	pending, err := a.repo.ListUndeliveredProactive(profile.CharacterID, session.SessionID)
+	if err != nil || len(pending) == 0 {
+		return
+	}
+	ids := make([]uint, len(pending))
+	for i, e := range pending {
+		ids[i] = e.ID
+	}
+	fmt.Printf("%s left you %d new message(s).\n", profile.Name, len(pending))
+	_ = a.repo.MarkProactiveDelivered(ids...)
+}
+
+// chatProactive lets the character speak first in the chat UI's session; nil when proactive messages are off.
+// The UI runs it in the background, so a slow endpoint never holds up the start.
+func (a *app) chatProactive(orch *orchestrator.Orchestrator, profile *models.CharacterProfile, session *models.SessionState) func(ctx context.Context) (*models.ChatMessage, error) {
+	sched := a.newScheduler(orch)
+	if sched == nil || a.cfg.APIKey == "" {
+		return nil
+	}
+	sched.Session = session.SessionID
+	sched.AddDeliverer(func(ctx context.Context, p *models.CharacterProfile, msg *models.ChatMessage) bool {
+		return msg.SessionID == session.SessionID
+	})
+	return func(ctx context.Context) (*models.ChatMessage, error) {
+		sent, err := sched.RunCharacter(ctx, profile)
+		if err != nil || sent == nil {
+			return nil, err
+		}
+		return sent.Message, nil
+	}
+}
+
+// chatReminders delivers the due reminders of the chat UI's session into the open chat, which shows the messages
+func (a *app) chatReminders(orch *orchestrator.Orchestrator, profile *models.CharacterProfile, session *models.SessionState) func(ctx context.Context) (*models.ChatMessage, error) {
+	sched := proactive.NewScheduler(a.repo, orch)
+	sched.Session = session.SessionID
+	sched.AddDeliverer(func(ctx context.Context, p *models.CharacterProfile, msg *models.ChatMessage) bool {
+		return msg.SessionID == session.SessionID
+	})
+	return func(ctx context.Context) (*models.ChatMessage, error) {
+		sent, err := sched.Remind(ctx, profile)
+		if err != nil || sent == nil {
+			return nil, err
+		}
+		return sent.Message, nil
+	}
+}
+
+// runProactive checks every character once, e.g. from cron
+func runProactive(a *app, args []string) error {
+	fs := flag.NewFlagSet("proactive", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only show which characters would reach out")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	_, orch := a.newOrchestrator()
+	sched := proactive.NewScheduler(a.repo, orch)
+	sched.DryRun = *dryRun
+
+	var sent []proactive.Sent
+	if a.character != "" {
+		profile, err := a.selectedCharacter(false)
+		if err != nil {
+			return err
+		}
+		out, err := sched.RunCharacter(context.Background(), profile)
+		if err != nil {
+			return err
+		}
+		if out != nil {
+			sent = append(sent, *out)
+		}
+	} else {
+		var err error
+		if sent, err = sched.RunOnce(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	if len(sent) == 0 {
+		fmt.Println("Nobody has anything to say right now.")
+	}
+	for _, s := range sent {
+		if s.Message == nil {
+			fmt.Printf("%s would send a %s message\n", s.Character.Name, s.Kind)
+			continue
+		}
+		fmt.Printf("%s (%s): %s\n", s.Character.Name, s.Kind, s.Message.Content.String())
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"ai-companion-cli-go/internal/prompts"
+	"ai-companion-cli-go/internal/relationship"
+)
+
+// runPrompts lists, previews or exports the prompt templates and shows the intimacy stage table
+func runPrompts(a *app, args []string) error {
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	store := prompts.NewStore(a.cfg.PromptDir, a.cfg.PromptLocale)
+
+	switch sub {
+	case "list":
+		return listPrompts(a, store, args)
+	case "preview", "render":
+		return previewPrompt(a, args)
+	case "export":
+		return exportPrompts(a, args)
+	case "stages":
+		return showStages(a, store, args)
+	default:
+		return fmt.Errorf("usage: prompts list | preview [--name system|initiate] [--locale L] [--level N] | stages [--type T] [--locale L] | export [dir]")
+	}
+}
+
+// listPrompts shows which file every template resolves to for the selected character, and whether it renders
+func listPrompts(a *app, store *prompts.Store, args []string) error {
+	fs := flag.NewFlagSet("prompts list", flag.ContinueOnError)
+	locale := fs.String("locale", "", "locale to resolve (default: the character's, then PROMPT_LOCALE)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+	if *locale == "" {
+		*locale = store.LocaleFor(profile)
+	}
+	_, orch := a.newOrchestrator()
+	level := orch.Progression(profile).StartLevel
+
+	fmt.Printf("Character %s (%s), locale %s; embedded locales: %s\n\n", profile.Name, profile.CharacterID, *locale, strings.Join(prompts.Locales(), ", "))
+	for _, name := range prompts.Names {
+		src, err := store.Resolve(name, *locale, profile.CharacterID)
+		if err != nil {
+			fmt.Printf("%-9s [FAIL] %v\n", name, err)
+			continue
+		}
+		status := "ok"
+		if _, err := orch.RenderPrompt(name, profile, level, *locale, "preview"); err != nil {
+			status = err.Error()
+		}
+		fmt.Printf("%-9s %-40s %s\n", name, src.Path, status)
+	}
+	return nil
+}
+
+// previewPrompt renders one template for the selected character exactly as it would be sent
+func previewPrompt(a *app, args []string) error {
+	fs := flag.NewFlagSet("prompts preview", flag.ContinueOnError)
+	name := fs.String("name", prompts.System, "template to render: "+strings.Join(prompts.Names, ", "))
+	locale := fs.String("locale", "", "render in this locale instead of the character's")
+	level := fs.Int("level", 0, "intimacy level to render with (default: the current one)")
+	reason := fs.String("reason", "It has been a while since you last talked.", "reason passed to the initiate template")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+	_, orch := a.newOrchestrator()
+	if *level == 0 {
+		*level = orch.Progression(profile).StartLevel
+		if rel, err := a.repo.GetRelationshipState(profile.CharacterID); err == nil && rel != nil && rel.IntimacyLevel > 0 {
+			*level = rel.IntimacyLevel
+		}
+	}
+
+	text, err := orch.RenderPrompt(*name, profile, *level, *locale, *reason)
+	if err != nil {
+		return err
+	}
+	fmt.Print(text)
+	return nil
+}
+
+// exportPrompts copies the embedded defaults into a directory to start editing from
+func exportPrompts(a *app, args []string) error {
+	dir := a.cfg.PromptDir
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if dir == "" {
+		return fmt.Errorf("usage: prompts export <dir> (or set PROMPT_DIR)")
+	}
+
+	written, err := prompts.Export(dir)
+	if err != nil {
+		return err
+	}
+	for _, p := range written {
+		fmt.Println("wrote", p)
+	}
+	if len(written) == 0 {
+		fmt.Println("All templates already exist in", dir)
+	}
+	if dir != a.cfg.PromptDir {
+		fmt.Printf("Set PROMPT_DIR=%s to use them.\n", dir)
+	}
+	return nil
+}
+
+// showStages prints the intimacy stage table of a relationship type, marking the character's current level
+func showStages(a *app, store *prompts.Store, args []string) error {
+	fs := flag.NewFlagSet("prompts stages", flag.ContinueOnError)
+	relType := fs.String("type", "", "relationship type (default: the selected character's)")
+	locale := fs.String("locale", "", "locale (default: the selected character's)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	current := 0
+	if *relType == "" || *locale == "" {
+		profile, err := a.selectedCharacter(false)
+		if err != nil {
+			return err
+		}
+		if *relType == "" {
+			*relType = profile.RelationshipType
+			if rel, err := a.repo.GetRelationshipState(profile.CharacterID); err == nil && rel != nil {
+				current = rel.IntimacyLevel
+			}
+		}
+		if *locale == "" {
+			*locale = store.LocaleFor(profile)
+		}
+	}
+
+	p := a.stages.Progression(*relType)
+	fmt.Printf("Relationship type %s, locale %s, starts at level %d, ceiling %d (types: %s)\n", relationship.NormalizeType(*relType), *locale, p.StartLevel, p.Ceiling, strings.Join(a.stages.TypesFor(*locale), ", "))
+	for level := 1; level <= relationship.MaxLevel; level++ {
+		st := a.stages.Stage(*locale, *relType, level)
+		marker := " "
+		if level == current {
+			marker = "*"
+		} else if level > p.Ceiling {
+			marker = "x" // out of reach for this type
+		}
+		fmt.Printf("\n%s Lv%-2d %s — %s\n", marker, level, st.Name, st.Description)
+		printStageList("behaviors", st.Behaviors)
+		printStageList("pet names", st.PetNames)
+		printStageList("topics", st.Topics)
+		printStageList("boundaries", st.Boundaries)
+		if st.ReplyLength != "" || st.Emoji != "" {
+			fmt.Printf("       %-11s %s; emoji: %s\n", "length", st.ReplyLength, st.Emoji)
+		}
+	}
+	return nil
+}
+
+func printStageList(label string, items []string) {
+	if len(items) > 0 {
+		fmt.Printf("       %-11s %s\n", label, strings.Join(items, "; "))
+	}
+}
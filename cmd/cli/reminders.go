@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const remindersUsage = "usage: reminders [--all] | cancel <id>"
+
+// runReminders lists or cancels the reminders the selected character promised
+func runReminders(a *app, args []string) error {
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 && args[0] == "cancel" {
+		if len(args) != 2 {
+			return fmt.Errorf(remindersUsage)
+		}
+		id, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid reminder ID %q", args[1])
+		}
+		r, err := a.repo.GetReminder(uint(id))
+		if err != nil {
+			return err
+		}
+		if r == nil || r.CharacterID != profile.CharacterID {
+			return fmt.Errorf("reminder %d not found for %s", id, profile.Name)
+		}
+		if err := a.repo.DeleteReminder(r.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Cancelled reminder %d: %s\n", r.ID, r.Text.String())
+		return nil
+	}
+
+	fs := flag.NewFlagSet("reminders", flag.ContinueOnError)
+	all := fs.Bool("all", false, "include reminders that were already delivered")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf(remindersUsage)
+	}
+	reminders, err := a.repo.ListReminders(profile.CharacterID, *all)
+	if err != nil {
+		return err
+	}
+	if len(reminders) == 0 {
+		fmt.Printf("%s has no reminders for you; ask in the chat, e.g. \"remind me to call mom at 18:00\".\n", profile.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDUE\tSTATE\tTEXT")
+	for _, r := range reminders {
+		state := "pending"
+		if r.Done {
+			state = "delivered"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.ID, r.DueAt.Local().Format("2006-01-02 15:04"), state, r.Text.String())
+	}
+	return w.Flush()
+}
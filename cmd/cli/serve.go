@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"ai-companion-cli-go/internal/server"
+)
+
+// runServe starts the HTTP server exposing companions as OpenAI-compatible models
+func runServe(a *app, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "listen address")
+	keysFile := fs.String("keys", os.Getenv("SERVER_KEYS_FILE"), "JSON file with API keys and their allowed characters")
+	noAuth := fs.Bool("no-auth", false, "serve without API keys (only for trusted local networks)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var keys *server.KeyStore
+	switch {
+	case *keysFile != "":
+		var err error
+		if keys, err = server.LoadKeyStore(*keysFile); err != nil {
+			return fmt.Errorf("load API keys: %w", err)
+		}
+	case !*noAuth:
+		return errors.New("no API keys configured: pass --keys <file> (or SERVER_KEYS_FILE), or --no-auth to disable authentication")
+	}
+
+	_, orch := a.newOrchestrator()
+	srv := server.NewServer(a.repo, orch, keys)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if sched := a.newScheduler(orch); sched != nil {
+		sched.Lock = srv.CharacterLock
+		sched.AddDeliverer(srv.PushProactive)
+		go sched.Start(ctx, a.proactiveInterval())
+	}
+
+	if keys != nil {
+		log.Printf("Serving on http://%s/v1 with %d API key(s)", *addr, keys.Len())
+	} else {
+		log.Printf("Serving on http://%s/v1 WITHOUT authentication", *addr)
+	}
+	if err := srv.ListenAndServe(ctx, *addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"ai-companion-cli-go/internal/orchestrator"
+)
+
+// runTraces lists or shows the per-turn debug traces of the selected character
+func runTraces(a *app, args []string) error {
+	if len(args) > 0 && args[0] == "show" {
+		return showTrace(a, args[1:])
+	}
+	if len(args) > 0 && args[0] == "list" {
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("traces", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "number of traces to list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+	traces, err := a.repo.ListTurnTraces(profile.CharacterID, *limit)
+	if err != nil {
+		return err
+	}
+	if len(traces) == 0 {
+		fmt.Printf("No traces for %s yet (TRACE_KEEP=%d).\n", profile.Name, a.cfg.TraceKeep)
+		return nil
+	}
+
+	fmt.Printf("%-6s %-19s %-9s %-5s %-20s %7s %7s %8s  %s\n", "ID", "TIME", "KIND", "TURN", "MODEL", "TOKENS", "TTFT", "LATENCY", "ERROR")
+	for _, t := range traces {
+		model := t.Model
+		if t.FallbackFrom != "" {
+			model += "*"
+		}
+		fmt.Printf("%-6d %-19s %-9s %-5d %-20s %7d %5dms %6dms  %s\n",
+			t.ID, t.CreatedAt.Local().Format("2006-01-02 15:04:05"), t.Kind, t.TurnIndex, model,
+			t.TotalTokens, t.FirstTokenMs, t.LatencyMs, t.Error)
+	}
+	return nil
+}
+
+// showTrace prints one trace in full: traces show <id> | last
+func showTrace(a *app, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: traces show <id|last>")
+	}
+
+	if args[0] == "last" {
+		profile, err := a.selectedCharacter(false)
+		if err != nil {
+			return err
+		}
+		traces, err := a.repo.ListTurnTraces(profile.CharacterID, 1)
+		if err != nil {
+			return err
+		}
+		if len(traces) == 0 {
+			return fmt.Errorf("no traces for %s yet", profile.Name)
+		}
+		fmt.Print(orchestrator.FormatTrace(&traces[0]))
+		return nil
+	}
+
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid trace id %q", args[0])
+	}
+	trace, err := a.repo.GetTurnTrace(uint(id))
+	if err != nil {
+		return err
+	}
+	if trace == nil {
+		return fmt.Errorf("trace %d not found", id)
+	}
+	fmt.Print(orchestrator.FormatTrace(trace))
+	return nil
+}
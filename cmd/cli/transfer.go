@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"ai-companion-cli-go/internal/bundle"
+)
+
+// runExport handles `export <character_id> <file.json|file.zip>`
+func runExport(a *app, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: export <character_id> <file.json|file.zip>")
+	}
+
+	b, err := bundle.Export(a.repo, args[0])
+	if err != nil {
+		return err
+	}
+	if err := bundle.WriteFile(b, args[1]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %s (%d messages, %d facts, %d summaries) to %s\n",
+		b.Character.Name, len(b.Messages), len(b.Facts), len(b.Summaries), args[1])
+	return nil
+}
+
+// runImport handles `import [--on-conflict fail|replace|duplicate] <file>`
+func runImport(a *app, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	onConflict := fs.String("on-conflict", "fail", "what to do if the character already exists: fail, replace or duplicate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: import [--on-conflict fail|replace|duplicate] <file>")
+	}
+
+	mode, err := bundle.ParseConflictMode(*onConflict)
+	if err != nil {
+		return err
+	}
+
+	b, err := bundle.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	characterID, err := bundle.Import(a.repo, b, mode)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %s as %s (%d messages)\n", b.Character.Name, characterID, len(b.Messages))
+	return nil
+}
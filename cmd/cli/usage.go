@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"ai-companion-cli-go/internal/usage"
+)
+
+// runUsage prints token usage and cost per day or month, and the budget status
+func runUsage(a *app, args []string) error {
+	if len(args) > 0 && args[0] == "prices" {
+		return showPrices(a)
+	}
+
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	monthly := fs.Bool("monthly", false, "group by month instead of day")
+	days := fs.Int("days", 7, "days to cover (ignored with --monthly, which covers the last 12 months)")
+	by := fs.String("by", usage.ByModel, "break down by model, character or feature")
+	asJSON := fs.Bool("json", false, "print the report rows as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *by {
+	case usage.ByModel, usage.ByCharacter, usage.ByFeature:
+	default:
+		return fmt.Errorf("--by must be model, character or feature, got %q", *by)
+	}
+
+	now := time.Now()
+	y, m, d := now.Date()
+	period := usage.PeriodDay
+	since := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).AddDate(0, 0, -(*days - 1))
+	if *monthly {
+		period = usage.PeriodMonth
+		since = time.Date(y, m, 1, 0, 0, 0, 0, now.Location()).AddDate(0, -11, 0)
+	}
+
+	rows, err := usage.BuildReport(a.repo, since, now.Add(time.Minute), period, *by)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No usage recorded in this period.")
+	} else {
+		fmt.Printf("%-10s %-28s %6s %10s %10s %10s\n", "PERIOD", "BY "+*by, "CALLS", "PROMPT", "COMPLETION", "COST USD")
+		var total float64
+		for _, r := range rows {
+			fmt.Printf("%-10s %-28s %6d %10d %10d %10.4f\n", r.Period, r.Group, r.Calls, r.PromptTokens, r.CompletionTokens, r.CostUSD)
+			total += r.CostUSD
+		}
+		fmt.Printf("%-10s %-28s %6s %10s %10s %10.4f\n", "total", "", "", "", "", total)
+	}
+
+	today, month, err := a.usage.Spend()
+	if err != nil {
+		return err
+	}
+	b := a.usage.Budget()
+	fmt.Println()
+	fmt.Printf("today  $%.4f of %s\n", today, budgetLimit(b.DailyUSD))
+	fmt.Printf("month  $%.4f of %s\n", month, budgetLimit(b.MonthlyUSD))
+	if b.DailyUSD > 0 || b.MonthlyUSD > 0 {
+		action := b.Action
+		if action == usage.ActionDowngrade {
+			action += " to " + b.DowngradeTo
+		}
+		fmt.Printf("at cap %s\n", action)
+	}
+	return nil
+}
+
+// showPrices prints the price table used for cost accounting
+func showPrices(a *app) error {
+	prices := a.usage.Prices()
+	fmt.Printf("%-28s %12s %12s\n", "MODEL", "INPUT/MTOK", "OUTPUT/MTOK")
+	for _, name := range prices.Models() {
+		p := prices[name]
+		fmt.Printf("%-28s %12.4f %12.4f\n", name, p.InputPerMTok, p.OutputPerMTok)
+	}
+	if a.cfg.PriceTableFile != "" {
+		fmt.Printf("\noverrides from %s\n", a.cfg.PriceTableFile)
+	}
+	return nil
+}
+
+func budgetLimit(limit float64) string {
+	if limit <= 0 {
+		return "no cap"
+	}
+	return fmt.Sprintf("$%.2f", limit)
+}
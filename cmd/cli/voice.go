@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"ai-companion-cli-go/internal/audio"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/vault"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const voiceUsage = "usage: voice transcribe <file.wav> | record | say [--no-play] [message-id] | play <id|last> | list [--limit N] | set [voice]"
+
+// voiceTimeout bounds one transcription or synthesis
+const voiceTimeout = 2 * time.Minute
+
+// runVoice transcribes recordings and reads replies of the selected character aloud
+func runVoice(a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(voiceUsage)
+	}
+	sub, args := args[0], args[1:]
+	profile, err := a.selectedCharacter(false)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), voiceTimeout)
+	defer cancel()
+
+	switch sub {
+	case "transcribe", "record":
+		var data []byte
+		name := "voice.wav"
+		if sub == "transcribe" {
+			if len(args) != 1 {
+				return fmt.Errorf("usage: voice transcribe <file.wav>")
+			}
+			if data, err = os.ReadFile(args[0]); err != nil {
+				return err
+			}
+			name = filepath.Base(args[0])
+		} else if data, err = recordUntilEnter(a); err != nil {
+			return err
+		}
+		if _, err := audio.Duration(data); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		_, orch := a.newOrchestrator()
+		text, _, err := orch.Transcribe(ctx, profile, orch.EnsureSession(profile.CharacterID), name, data)
+		if err != nil {
+			return err
+		}
+		fmt.Println(text)
+		return nil
+	case "say":
+		fs := flag.NewFlagSet("voice say", flag.ContinueOnError)
+		noPlay := fs.Bool("no-play", false, "only store the audio")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		_, orch := a.newOrchestrator()
+		msg, err := replyByRef(a, orch, profile, fs.Args())
+		if err != nil {
+			return err
+		}
+		m, err := orch.Speak(ctx, profile, msg)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Saved %s #%d for message %d (%s, %s)\n", m.Kind, m.ID, msg.ID, m.Model, media.Placeholder(m))
+		if *noPlay {
+			return nil
+		}
+		return playMedia(a, m)
+	case "play":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: voice play <id|last>")
+		}
+		m, err := audioByRef(a, profile, args[0])
+		if err != nil {
+			return err
+		}
+		return playMedia(a, m)
+	case "list":
+		return listAudio(a, profile, args)
+	case "set":
+		if len(args) == 0 {
+			voice := profile.Voice
+			if voice == "" {
+				voice = a.cfg.SpeechVoice + " (SPEECH_VOICE)"
+			}
+			fmt.Printf("%s speaks with voice %s\n", profile.Name, voice)
+			return nil
+		}
+		profile.Voice = args[0]
+		if err := a.repo.SaveCharacter(profile); err != nil {
+			return err
+		}
+		fmt.Printf("%s now speaks with voice %s\n", profile.Name, profile.Voice)
+		return nil
+	default:
+		return fmt.Errorf(voiceUsage)
+	}
+}
+
+// recordUntilEnter records from the microphone until the user presses Enter
+func recordUntilEnter(a *app) ([]byte, error) {
+	rec, err := audio.Record(a.cfg.RecordCommand)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(os.Stderr, "● Recording… press Enter to stop.")
+	_, _ = vault.Stdin.ReadString('\n')
+	return rec.Stop()
+}
+
+// replyByRef loads the character's message with the ID in args, or its newest one
+func replyByRef(a *app, orch *orchestrator.Orchestrator, profile *models.CharacterProfile, args []string) (*models.ChatMessage, error) {
+	if len(args) == 0 {
+		msg, err := a.repo.GetLastMessageByRole(orch.EnsureSession(profile.CharacterID).SessionID, openai.ChatMessageRoleAssistant)
+		if err == nil && msg == nil {
+			err = fmt.Errorf("%s has not said anything yet", profile.Name)
+		}
+		return msg, err
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message ID %q", args[0])
+	}
+	msg, err := a.repo.GetMessage(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil || msg.CharacterID != profile.CharacterID || msg.Role != openai.ChatMessageRoleAssistant {
+		return nil, fmt.Errorf("message %d is not a reply of %s", id, profile.Name)
+	}
+	return msg, nil
+}
+
+// audioByRef loads the recording or spoken reply with the given ID, or the newest one for "last"
+func audioByRef(a *app, profile *models.CharacterProfile, ref string) (*models.Media, error) {
+	if ref == "last" {
+		m, err := a.repo.GetLastAudio(profile.CharacterID)
+		if err == nil && m == nil {
+			err = fmt.Errorf("no recordings or spoken replies of %s yet", profile.Name)
+		}
+		return m, err
+	}
+	id, err := strconv.ParseUint(ref, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audio ID %q", ref)
+	}
+	m, err := a.repo.GetMedia(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || m.CharacterID != profile.CharacterID || !media.IsAudio(m) {
+		return nil, fmt.Errorf("audio %d not found for %s", id, profile.Name)
+	}
+	return m, nil
+}
+
+// playMedia plays a recording or spoken reply with PLAY_COMMAND
+func playMedia(a *app, m *models.Media) error {
+	data, err := media.Bytes(m)
+	if err != nil {
+		return err
+	}
+	return audio.Play(context.Background(), a.cfg.PlayCommand, data, media.Extension(m))
+}
+
+func listAudio(a *app, profile *models.CharacterProfile, args []string) error {
+	fs := flag.NewFlagSet("voice list", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "number of recordings to list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	items, err := a.repo.ListMediaPage(profile.CharacterID, 0, *limit, media.KindSpeech, media.KindVoice)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Printf("No recordings or spoken replies of %s yet; try `voice say`.\n", profile.Name)
+		return nil
+	}
+	fmt.Printf("%-6s %-19s %-7s %-8s %-16s %s\n", "ID", "TIME", "KIND", "MESSAGE", "MODEL", "FILE")
+	for _, m := range items {
+		fmt.Printf("%-6d %-19s %-7s %-8d %-16s %s\n", m.ID, m.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			m.Kind, m.MessageID, m.Model, media.Placeholder(&m))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Package anchor loads a character's canonical persona document (CharacterProfile.AnchorRef) and checks
+// replies against the hard facts it pins down
+package anchor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+// Well-known fact keys; the rule checker understands age, job and family (see Contradictions), the LLM judge any key
+const (
+	FactAge       = "age"
+	FactJob       = "job"
+	FactFamily    = "family"
+	FactEducation = "education"
+	FactName      = "name"
+)
+
+// Anchor is the canonical persona of a character: what must never drift, however long the conversation.
+// It is a JSON file referenced by AnchorRef:
+//
+//	{"name": "Mira", "facts": [{"key": "age", "value": "22"}, {"key": "job", "value": "librarian"}],
+//	 "voice": ["Ugh, *long* day~"], "canon": "...", "appearance": "...", "art_style": "..."}
+type Anchor struct {
+	Name       string   `json:"name,omitempty"`
+	Facts      []Fact   `json:"facts,omitempty"`
+	Voice      []string `json:"voice,omitempty"` // sample lines in the character's voice
+	Canon      string   `json:"canon,omitempty"` // free-form truths on top of the profile's backstory
+	Appearance string   `json:"appearance,omitempty"`
+	ArtStyle   string   `json:"art_style,omitempty"`
+
+	Path string `json:"-"` // resolved file the anchor was loaded from
+}
+
+// Fact is one hard fact about the character
+type Fact struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Resolve turns an AnchorRef into a file path; relative refs are looked up in dir
+func Resolve(ref, dir string) string {
+	if ref == "" || filepath.IsAbs(ref) || dir == "" {
+		return ref
+	}
+	return filepath.Join(dir, ref)
+}
+
+// Load reads the anchor a profile references; nil without an AnchorRef
+func Load(profile *models.CharacterProfile, dir string) (*Anchor, error) {
+	path := Resolve(strings.TrimSpace(profile.AnchorRef), dir)
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("anchor %s: %w", profile.AnchorRef, err)
+	}
+	var a Anchor
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("parse anchor %s: %w", path, err)
+	}
+	for _, f := range a.Facts {
+		if strings.TrimSpace(f.Key) == "" || strings.TrimSpace(f.Value) == "" {
+			return nil, fmt.Errorf("anchor %s: every fact needs a key and a value", path)
+		}
+	}
+	a.Path = path
+	return &a, nil
+}
+
+// FromProfile drafts an anchor from the profile fields, as a starting point for `anchor init`
+func FromProfile(profile *models.CharacterProfile) *Anchor {
+	a := &Anchor{
+		Name:       profile.Name,
+		Facts:      profileFacts(profile),
+		Appearance: profile.ReferenceImagePrompt,
+		ArtStyle:   profile.ArtStyle,
+	}
+	if profile.Catchphrase != "" {
+		a.Voice = append(a.Voice, profile.Catchphrase)
+	}
+	return a
+}
+
+// Save writes the anchor as indented JSON
+func (a *Anchor) Save(path string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Facts returns the facts replies are checked against: the anchor's, completed by the profile fields
+// it does not cover (age, job, family background, education)
+func Facts(profile *models.CharacterProfile, a *Anchor) []Fact {
+	var out []Fact
+	seen := map[string]bool{}
+	if a != nil {
+		for _, f := range a.Facts {
+			out = append(out, f)
+			seen[strings.ToLower(f.Key)] = true
+		}
+	}
+	for _, f := range profileFacts(profile) {
+		if !seen[f.Key] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Appearance describes how the character looks, from the anchor or the profile's reference image prompt
+func Appearance(profile *models.CharacterProfile, a *Anchor) string {
+	if a != nil && a.Appearance != "" {
+		return a.Appearance
+	}
+	return profile.ReferenceImagePrompt
+}
+
+func profileFacts(profile *models.CharacterProfile) []Fact {
+	var out []Fact
+	if profile.Age > 0 {
+		out = append(out, Fact{Key: FactAge, Value: strconv.Itoa(profile.Age)})
+	}
+	if job := profileJob(profile); job != "" {
+		out = append(out, Fact{Key: FactJob, Value: job})
+	}
+	if profile.FamilyBackground != "" {
+		out = append(out, Fact{Key: FactFamily, Value: profile.FamilyBackground})
+	}
+	if profile.EducationDetail != "" {
+		out = append(out, Fact{Key: FactEducation, Value: profile.EducationDetail})
+	}
+	return out
+}
+
+// profileJob reads the job, which profiles keep as "job" or "occupation" in ProfileJSON for lack of a column
+func profileJob(profile *models.CharacterProfile) string {
+	for _, key := range []string{"job", "occupation"} {
+		if s, ok := profile.ProfileJSON[key].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,209 @@
+package anchor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Check modes (ANCHOR_CHECK)
+const (
+	CheckOff   = "off"
+	CheckRules = "rules" // local pattern checks only
+	CheckLLM   = "llm"   // rules plus a model judging every reply against all facts
+)
+
+// Finding is a statement in a reply that contradicts an anchored fact
+type Finding struct {
+	FactKey  string `json:"fact_key"`
+	Expected string `json:"expected"`
+	Found    string `json:"found"` // the contradicting part of the reply
+	Source   string `json:"source"`
+}
+
+// ValidCheck reports whether mode is one of the check modes
+func ValidCheck(mode string) bool {
+	return mode == CheckOff || mode == CheckRules || mode == CheckLLM
+}
+
+var (
+	// "I'm 5 minutes away" is not an age, so a bare "I'm N" needs "years old"
+	ageEN   = regexp.MustCompile(`(?i)\bI(?:'m| am)\s+(\d{1,3})\s*(?:years?[\s-]+old|yo)\b`)
+	ageENTo = regexp.MustCompile(`(?i)\b(?:I(?:'ve| have)? just turned|I turned|my age is)\s+(\d{1,3})\b`)
+	ageZH   = regexp.MustCompile(`我(?:今年|已经|才|都)?([0-9一二两三四五六七八九十]{1,4})岁`)
+
+	// "I'm a bit tired" is not a job, so only explicit phrasings count
+	jobEN = regexp.MustCompile(`(?i)\b(?:I work as|I(?:'m| am) working as|I(?:'m| am) employed as|my job is|my profession is)\s+(?:an?\s+|the\s+)?([a-z][a-z -]{1,40}?)(?:[.,!?;:]|\s+(?:at|in|for|and|but|so|now)\b|$)`)
+	jobZH = regexp.MustCompile(`我的(?:工作|职业)是(?:一名|一个|个)?(\p{Han}{1,8})`)
+
+	onlyChildEN = regexp.MustCompile(`(?i)\bI(?:'m| am)\s+an only child\b|\bI (?:don't|do not) have any (?:brothers|sisters|siblings)\b`)
+	onlyChildZH = regexp.MustCompile(`我是独生(?:子|女)|我没有(?:兄弟姐妹|兄弟|姐妹)`)
+	siblingEN   = regexp.MustCompile(`(?i)\b(?:my (?:(?:older|younger|big|little|twin) )?(?:brother|sister)|I have (?:an?|one|two|three|\d) (?:(?:older|younger|big|little|twin) )?(?:brothers?|sisters?|siblings?))\b`)
+	siblingZH   = regexp.MustCompile(`我(?:的)?(?:哥哥|姐姐|弟弟|妹妹)|我有(?:一个|两个|个)?(?:哥哥|姐姐|弟弟|妹妹)`)
+
+	// how a family fact says whether the character has siblings
+	factOnlyChild = regexp.MustCompile(`(?i)only child|no (?:brothers|sisters|siblings)|独生|没有兄弟姐妹`)
+	factSiblings  = regexp.MustCompile(`(?i)brother|sister|sibling|twin|哥|姐|弟|妹`)
+)
+
+// Contradictions finds statements that contradict the facts and can be recognised without a model:
+//   - age: "I'm 25 years old", "I turned 25", "我今年二十五岁" against an anchored age
+//   - job: "I work as a barista", "my job is …", "我的工作是…" naming something other than the anchored job
+//   - family: claiming to be an only child, or mentioning a brother or sister, against a family fact
+//     that says otherwise; the rest of a family background is left to the llm check
+func Contradictions(reply string, facts []Fact) []Finding {
+	var out []Finding
+	for _, f := range facts {
+		switch strings.ToLower(f.Key) {
+		case FactAge:
+			out = append(out, ageContradictions(reply, f)...)
+		case FactJob:
+			out = append(out, jobContradictions(reply, f)...)
+		case FactFamily:
+			out = append(out, familyContradictions(reply, f)...)
+		}
+	}
+	return out
+}
+
+func ageContradictions(reply string, f Fact) []Finding {
+	want, err := strconv.Atoi(strings.TrimSpace(f.Value))
+	if err != nil {
+		return nil
+	}
+	var out []Finding
+	for _, m := range statedAges(reply) {
+		if m.age != want {
+			out = append(out, Finding{FactKey: FactAge, Expected: f.Value, Found: m.text, Source: CheckRules})
+		}
+	}
+	return out
+}
+
+func jobContradictions(reply string, f Fact) []Finding {
+	var out []Finding
+	for _, re := range []*regexp.Regexp{jobEN, jobZH} {
+		// The statement ends with the job, without the word or punctuation that closed it
+		for _, m := range re.FindAllStringSubmatchIndex(reply, -1) {
+			if !sameJob(reply[m[2]:m[3]], f.Value) {
+				out = append(out, Finding{FactKey: FactJob, Expected: f.Value, Found: reply[m[0]:m[3]], Source: CheckRules})
+			}
+		}
+	}
+	return out
+}
+
+// sameJob is lenient: "senior librarian" matches "librarian", and "护士呀" matches "护士"
+func sameJob(stated, want string) bool {
+	stated, want = strings.ToLower(strings.TrimSpace(stated)), strings.ToLower(strings.TrimSpace(want))
+	if stated == "" || strings.Contains(want, stated) || strings.Contains(stated, want) {
+		return true
+	}
+	for _, word := range strings.Fields(stated) {
+		if len(word) >= 4 && strings.Contains(want, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func familyContradictions(reply string, f Fact) []Finding {
+	var claims []*regexp.Regexp
+	switch {
+	case factOnlyChild.MatchString(f.Value):
+		claims = []*regexp.Regexp{siblingEN, siblingZH}
+	case factSiblings.MatchString(f.Value):
+		claims = []*regexp.Regexp{onlyChildEN, onlyChildZH}
+	default:
+		return nil
+	}
+	var out []Finding
+	for _, re := range claims {
+		for _, found := range re.FindAllString(reply, -1) {
+			out = append(out, Finding{FactKey: FactFamily, Expected: f.Value, Found: found, Source: CheckRules})
+		}
+	}
+	return out
+}
+
+type statedAge struct {
+	age  int
+	text string
+}
+
+func statedAges(reply string) []statedAge {
+	var out []statedAge
+	for _, re := range []*regexp.Regexp{ageEN, ageENTo} {
+		for _, m := range re.FindAllStringSubmatch(reply, -1) {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				out = append(out, statedAge{n, m[0]})
+			}
+		}
+	}
+	for _, m := range ageZH.FindAllStringSubmatch(reply, -1) {
+		if n, ok := parseChineseNumber(m[1]); ok {
+			out = append(out, statedAge{n, m[0]})
+		}
+	}
+	return out
+}
+
+// parseChineseNumber reads 0-999 written with Arabic digits or 一..十 (e.g. 二十二, 十八)
+func parseChineseNumber(s string) (int, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	digits := map[rune]int{'一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	total, current := 0, 0
+	for _, r := range s {
+		switch {
+		case r == '十':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+		default:
+			d, ok := digits[r]
+			if !ok {
+				return 0, false
+			}
+			current = d
+		}
+	}
+	return total + current, true
+}
+
+// JudgePrompt is the system prompt asking a model to list contradictions as JSON
+func JudgePrompt(name string, facts []Fact) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You check whether a roleplay reply by %s contradicts the character's canonical facts.\n\nFacts:\n", name)
+	for _, f := range facts {
+		fmt.Fprintf(&b, "- %s: %s\n", f.Key, f.Value)
+	}
+	b.WriteString("\nOnly report statements the character makes about itself that clearly contradict a fact. " +
+		"Omissions, jokes and hypotheticals are fine. Answer with JSON only: " +
+		`{"findings": [{"fact_key": "...", "expected": "...", "found": "<quote from the reply>"}]}` +
+		" and an empty list when the reply is consistent.")
+	return b.String()
+}
+
+// ParseJudgement reads the model's answer to JudgePrompt, tolerating code fences around the JSON
+func ParseJudgement(answer string) ([]Finding, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON in consistency judgement")
+	}
+	var out struct {
+		Findings []Finding `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &out); err != nil {
+		return nil, fmt.Errorf("parse consistency judgement: %w", err)
+	}
+	for i := range out.Findings {
+		out.Findings[i].Source = CheckLLM
+	}
+	return out.Findings, nil
+}
@@ -0,0 +1,77 @@
+package anchor
+
+import (
+	"testing"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+func TestContradictions(t *testing.T) {
+	tests := []struct {
+		name  string
+		fact  Fact
+		reply string
+		found string // the flagged text; empty when the reply is consistent
+	}{
+		{"age", Fact{FactAge, "22"}, "Well, I'm 25 years old, you know.", "I'm 25 years old"},
+		{"same age", Fact{FactAge, "22"}, "I just turned 22!", ""},
+		{"not an age", Fact{FactAge, "22"}, "I'm 5 minutes away", ""},
+		{"age in Chinese", Fact{FactAge, "22"}, "我今年二十五岁了", "我今年二十五岁"},
+
+		{"job", Fact{FactJob, "librarian"}, "Honestly, I work as a barista at the corner café.", "I work as a barista"},
+		{"same job", Fact{FactJob, "librarian"}, "I work as a senior librarian, remember?", ""},
+		{"not a job", Fact{FactJob, "librarian"}, "I'm a bit tired today.", ""},
+		{"job in Chinese", Fact{FactJob, "图书管理员"}, "我的工作是护士呀", "我的工作是护士呀"},
+		{"same job in Chinese", Fact{FactJob, "护士"}, "我的工作是护士呀", ""},
+
+		{"sibling of an only child", Fact{FactFamily, "An only child raised by her grandmother"}, "My older brother says hi!", "My older brother"},
+		{"only child with a sister", Fact{FactFamily, "Has a younger sister, Yui"}, "I'm an only child, so it was quiet.", "I'm an only child"},
+		{"only child in Chinese", Fact{FactFamily, "家里有一个哥哥"}, "我是独生女啦", "我是独生女"},
+		{"sibling in Chinese", Fact{FactFamily, "独生女，父母在京都开茶馆"}, "我哥哥昨天来了", "我哥哥"},
+		{"consistent family", Fact{FactFamily, "Has a younger sister, Yui"}, "My younger sister is visiting.", ""},
+		{"family without siblings info", Fact{FactFamily, "Parents run a tea shop"}, "My brother helps out there.", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Contradictions(tt.reply, []Fact{tt.fact})
+			if tt.found == "" {
+				if len(got) != 0 {
+					t.Fatalf("flagged %+v in a consistent reply", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].Found != tt.found || got[0].FactKey != tt.fact.Key || got[0].Source != CheckRules {
+				t.Fatalf("findings = %+v, want %q", got, tt.found)
+			}
+		})
+	}
+}
+
+func TestFactsIncludeProfileJob(t *testing.T) {
+	profile := &models.CharacterProfile{Age: 22, ProfileJSON: models.MapJSON{"occupation": "librarian"}}
+	facts := Facts(profile, nil)
+	if len(facts) != 2 || facts[1] != (Fact{FactJob, "librarian"}) {
+		t.Fatalf("facts = %+v, want the age and the job", facts)
+	}
+
+	// The anchor's job wins over the profile's
+	facts = Facts(profile, &Anchor{Facts: []Fact{{"Job", "nurse"}}})
+	for _, f := range facts {
+		if f.Key == FactJob {
+			t.Fatalf("facts = %+v, want only the anchored job", facts)
+		}
+	}
+}
+
+func TestValidCheck(t *testing.T) {
+	for _, mode := range []string{CheckOff, CheckRules, CheckLLM} {
+		if !ValidCheck(mode) {
+			t.Errorf("%q rejected", mode)
+		}
+	}
+	for _, mode := range []string{"", "rule", "LLM", "on"} {
+		if ValidCheck(mode) {
+			t.Errorf("%q accepted", mode)
+		}
+	}
+}
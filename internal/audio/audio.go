@@ -0,0 +1,187 @@
+// Package audio records the user's voice and plays the character's replies through external programs
+// (arecord, sox, ffmpeg, afplay, ...), so the binary needs no sound libraries of its own
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Auto picks the first installed recorder or player (RECORD_COMMAND, PLAY_COMMAND)
+const Auto = "auto"
+
+// fileArg is replaced by the path of the recording or of the audio to play
+const fileArg = "{file}"
+
+// recorders and players tried by Auto, in order
+var (
+	recorders = []string{
+		"arecord -q -f S16_LE -r 16000 -c 1 -t wav {file}",
+		"rec -q -c 1 -r 16000 -b 16 {file}",
+		"ffmpeg -loglevel quiet -y -f avfoundation -i :0 -ac 1 -ar 16000 {file}",
+	}
+	players = []string{
+		"afplay {file}",
+		"ffplay -nodisp -autoexit -loglevel quiet {file}",
+		"mpv --really-quiet --no-video {file}",
+		"play -q {file}",
+		"paplay {file}",
+		"aplay -q {file}",
+	}
+)
+
+// ErrNoProgram is returned when Auto finds neither a recorder nor a player
+var ErrNoProgram = errors.New("no audio program found")
+
+// resolve turns a command setting into its argv with the file filled in
+func resolve(setting string, candidates []string, file string) ([]string, error) {
+	if setting == "" || setting == Auto {
+		for _, c := range candidates {
+			argv := strings.Fields(c)
+			// ffmpeg only records through avfoundation on macOS
+			if argv[0] == "ffmpeg" && runtime.GOOS != "darwin" {
+				continue
+			}
+			if _, err := exec.LookPath(argv[0]); err == nil {
+				setting = c
+				break
+			}
+		}
+		if setting == "" || setting == Auto {
+			return nil, ErrNoProgram
+		}
+	}
+	argv := strings.Fields(setting)
+	found := false
+	for i, a := range argv {
+		if strings.Contains(a, fileArg) {
+			argv[i] = strings.ReplaceAll(a, fileArg, file)
+			found = true
+		}
+	}
+	if !found {
+		argv = append(argv, file)
+	}
+	return argv, nil
+}
+
+// Recording is a recorder program writing a WAV file until it is stopped
+type Recording struct {
+	cmd  *exec.Cmd
+	path string
+	done chan error
+}
+
+// Record starts recording from the microphone with the given command (Auto detects one)
+func Record(setting string) (*Recording, error) {
+	f, err := os.CreateTemp("", "companion-voice-*.wav")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	argv, err := resolve(setting, recorders, f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("cannot record: %w (install arecord or sox, or set RECORD_COMMAND)", err)
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	if err := cmd.Start(); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	r := &Recording{cmd: cmd, path: f.Name(), done: make(chan error, 1)}
+	go func() { r.done <- cmd.Wait() }()
+	return r, nil
+}
+
+// Stop ends the recording and returns the WAV file
+func (r *Recording) Stop() ([]byte, error) {
+	defer os.Remove(r.path)
+	// Recorders finish the WAV header when interrupted, as they would on Ctrl+C
+	_ = r.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-r.done:
+	case <-time.After(3 * time.Second):
+		_ = r.cmd.Process.Kill()
+		<-r.done
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Duration(data); err != nil {
+		return nil, fmt.Errorf("recording failed: %w", err)
+	}
+	return data, nil
+}
+
+// Cancel ends the recording and throws it away
+func (r *Recording) Cancel() {
+	_ = r.cmd.Process.Kill()
+	<-r.done
+	os.Remove(r.path)
+}
+
+// Play plays audio with the given command (Auto detects one) and waits until it ends or ctx is done.
+// ext names the format for players that go by the file name, e.g. ".mp3".
+func Play(ctx context.Context, setting string, data []byte, ext string) error {
+	f, err := os.CreateTemp("", "companion-speech-*"+ext)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	argv, err := resolve(setting, players, f.Name())
+	if err != nil {
+		return fmt.Errorf("cannot play audio: %w (install ffmpeg or mpv, or set PLAY_COMMAND)", err)
+	}
+	// The player must not write into the terminal UI
+	return exec.CommandContext(ctx, argv[0], argv[1:]...).Run()
+}
+
+// Duration checks that data is a PCM WAV file and returns its length
+func Duration(data []byte) (time.Duration, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, errors.New("not a WAV file")
+	}
+	var byteRate uint32
+	for pos := 12; pos+8 <= len(data); {
+		id, size := string(data[pos:pos+4]), binary.LittleEndian.Uint32(data[pos+4:pos+8])
+		body := pos + 8
+		switch id {
+		case "fmt ":
+			if body+12 > len(data) {
+				return 0, errors.New("truncated WAV header")
+			}
+			byteRate = binary.LittleEndian.Uint32(data[body+8 : body+12])
+		case "data":
+			if byteRate == 0 {
+				return 0, errors.New("WAV file has no format chunk")
+			}
+			// Recorders stopped early may leave the size unset; count what is there
+			n := min(int(size), len(data)-body)
+			if size == 0 || size == 0xffffffff {
+				n = len(data) - body
+			}
+			if n <= 0 {
+				return 0, errors.New("recording is empty")
+			}
+			return time.Duration(float64(n) / float64(byteRate) * float64(time.Second)), nil
+		}
+		pos = body + int(size) + int(size&1)
+	}
+	return 0, errors.New("WAV file has no audio data")
+}
@@ -0,0 +1,219 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// filePrefix and fileExt frame snapshot names: companion-20060102-150405-<reason>.db
+const (
+	filePrefix = "companion-"
+	fileExt    = ".db"
+	timeLayout = "20060102-150405"
+)
+
+// Snapshot describes one backup file on disk
+type Snapshot struct {
+	Name      string
+	Path      string
+	Reason    string
+	CreatedAt time.Time
+	Size      int64
+}
+
+// Manager takes rotating snapshots of the database and restores them
+type Manager struct {
+	db         *storage.DB
+	dir        string
+	keep       int
+	everyTurns int
+
+	mu sync.Mutex
+}
+
+// NewManager creates a backup manager; dir defaults to "backups" next to the database
+func NewManager(db *storage.DB, dir string, keep, everyTurns int) *Manager {
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(db.GetDBPath()), "backups")
+	}
+	return &Manager{db: db, dir: dir, keep: keep, everyTurns: everyTurns}
+}
+
+// Dir returns the directory snapshots are written to
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Create writes a verified snapshot and prunes old ones beyond the retention limit
+func (m *Manager) Create(reason string) (*Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.create(reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.prune(s.Path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// create writes a verified snapshot without pruning
+func (m *Manager) create(reason string) (*Snapshot, error) {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s%s-%s%s", filePrefix, now.Format(timeLayout), sanitizeReason(reason), fileExt)
+	path := filepath.Join(m.dir, name)
+
+	if err := m.db.BackupTo(path); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	if err := storage.IntegrityCheck(path); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Name: name, Path: path, Reason: reason, CreatedAt: now, Size: info.Size()}, nil
+}
+
+// List returns snapshots newest first
+func (m *Manager) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(m.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, e := range entries {
+		s, ok := parseName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		s.Path = filepath.Join(m.dir, e.Name())
+		s.Size = info.Size()
+		snapshots = append(snapshots, s)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name > snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+// Restore replaces the live database with a snapshot, keeping a "pre-restore" snapshot of the current state.
+// Old snapshots are only pruned after the restore, and never the one restored from.
+func (m *Manager) Restore(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := filepath.Join(m.dir, filepath.Base(name))
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup %s not found in %s", name, m.dir)
+	}
+	if err := storage.IntegrityCheck(path); err != nil {
+		return fmt.Errorf("backup %s is damaged: %w", name, err)
+	}
+
+	pre, err := m.create("pre-restore")
+	if err != nil {
+		return fmt.Errorf("could not snapshot current database before restoring: %w", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup %s disappeared before restoring: %w", name, err)
+	}
+	if err := m.db.RestoreFrom(path); err != nil {
+		return err
+	}
+	return m.prune(path, pre.Path)
+}
+
+// AfterTurn takes a snapshot every N completed turns; it is meant to be registered as an orchestrator turn hook
+func (m *Manager) AfterTurn(session *models.SessionState) {
+	if m.everyTurns <= 0 || session.TurnIndex == 0 || session.TurnIndex%m.everyTurns != 0 {
+		return
+	}
+	go func() {
+		if _, err := m.Create("auto"); err != nil {
+			log.Printf("automatic backup failed: %v", err)
+		}
+	}()
+}
+
+// prune deletes the oldest snapshots so that at most keep remain, never deleting the protected paths
+func (m *Manager) prune(protected ...string) error {
+	if m.keep <= 0 {
+		return nil
+	}
+	snapshots, err := m.List()
+	if err != nil {
+		return err
+	}
+	kept := 0
+	for _, s := range snapshots {
+		if slices.Contains(protected, s.Path) {
+			continue
+		}
+		if kept < m.keep-len(protected) {
+			kept++
+			continue
+		}
+		if err := os.Remove(s.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseName(name string) (Snapshot, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileExt) {
+		return Snapshot{}, false
+	}
+	rest := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileExt)
+	if len(rest) < len(timeLayout)+2 {
+		return Snapshot{}, false
+	}
+	created, err := time.ParseInLocation(timeLayout, rest[:len(timeLayout)], time.Local)
+	if err != nil {
+		return Snapshot{}, false
+	}
+	return Snapshot{Name: name, Reason: rest[len(timeLayout)+1:], CreatedAt: created}, true
+}
+
+func sanitizeReason(reason string) string {
+	reason = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(reason))
+	if reason == "" {
+		return "manual"
+	}
+	return reason
+}
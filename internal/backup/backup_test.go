@@ -0,0 +1,109 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/storage"
+)
+
+func newTestDB(t *testing.T) (*storage.DB, *storage.Repository) {
+	t.Helper()
+	db := storage.NewDB(filepath.Join(t.TempDir(), "companion.db"))
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		if conn, err := db.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	return db, storage.NewRepository(db)
+}
+
+func characterNames(t *testing.T, repo *storage.Repository) []string {
+	t.Helper()
+	chars, err := repo.ListCharacters()
+	if err != nil {
+		t.Fatalf("list characters: %v", err)
+	}
+	var names []string
+	for _, c := range chars {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestRestoreOldestSnapshotAtRetentionLimit(t *testing.T) {
+	db, repo := newTestDB(t)
+	m := NewManager(db, filepath.Join(t.TempDir(), "backups"), 3, 0)
+	if err := os.MkdirAll(m.Dir(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "a", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+	// Three snapshots fill the retention limit; the oldest holds only Aoi
+	names := []string{
+		"companion-20200101-000000-manual.db",
+		"companion-20200102-000000-manual.db",
+		"companion-20200103-000000-manual.db",
+	}
+	for i, name := range names {
+		if i == 1 {
+			if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "b", Name: "Ren"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := db.BackupTo(filepath.Join(m.Dir(), name)); err != nil {
+			t.Fatalf("backup %s: %v", name, err)
+		}
+	}
+
+	if err := m.Restore(names[0]); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	if got := characterNames(t, repo); len(got) != 1 || got[0] != "Aoi" {
+		t.Fatalf("characters after restore = %v, want [Aoi]", got)
+	}
+	snapshots, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 3 {
+		t.Fatalf("snapshots after restore = %d, want 3", len(snapshots))
+	}
+	var restored, pre bool
+	for _, s := range snapshots {
+		restored = restored || s.Name == names[0]
+		pre = pre || s.Reason == "pre-restore"
+	}
+	if !restored || !pre {
+		t.Fatalf("snapshots %v should keep the restored one and the pre-restore one", snapshots)
+	}
+}
+
+func TestRestoreMissingSnapshotKeepsDatabase(t *testing.T) {
+	db, repo := newTestDB(t)
+	m := NewManager(db, filepath.Join(t.TempDir(), "backups"), 3, 0)
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "a", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Restore("companion-20200101-000000-manual.db"); err == nil {
+		t.Fatal("restoring a missing snapshot should fail")
+	}
+	if err := storage.IntegrityCheck(filepath.Join(m.Dir(), "missing.db")); err == nil {
+		t.Fatal("integrity check of a missing file should fail")
+	}
+	if _, err := os.Stat(filepath.Join(m.Dir(), "missing.db")); !os.IsNotExist(err) {
+		t.Fatalf("integrity check created the missing file: %v", err)
+	}
+	if got := characterNames(t, repo); len(got) != 1 {
+		t.Fatalf("characters = %v, want the live one", got)
+	}
+}
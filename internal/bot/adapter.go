@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// Message is one text message received from an external chat platform
+type Message struct {
+	ChatID   string // conversation (private chat, group, thread) the reply goes to
+	UserID   string
+	UserName string
+	Text     string
+}
+
+// Adapter connects the bridge to one chat platform
+type Adapter interface {
+	// Platform is the stable name stored in chat bindings, e.g. "telegram"
+	Platform() string
+	// Receive delivers incoming messages to handle until ctx is cancelled
+	Receive(ctx context.Context, handle func(Message)) error
+	// Send delivers one message; text is at most MaxMessageLen runes
+	Send(ctx context.Context, chatID, text string) error
+	// Typing shows a short-lived "typing…" indicator in the chat
+	Typing(ctx context.Context, chatID string) error
+	// MaxMessageLen is the platform's per-message limit in runes
+	MaxMessageLen() int
+}
+
+// SplitMessage breaks text into chunks of at most max runes, preferring paragraph,
+// line and word boundaries so long replies stay readable
+func SplitMessage(text string, max int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	var chunks []string
+	for utf8.RuneCountInString(text) > max {
+		cut := byteOffset(text, max)
+		head := text[:cut]
+		for _, sep := range []string{"\n\n", "\n", "。", ". ", " "} {
+			// only split on a boundary in the back half, otherwise chunks get too short
+			if i := strings.LastIndex(head, sep); i > len(head)/2 {
+				cut = i + len(sep)
+				break
+			}
+		}
+		chunks = append(chunks, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// byteOffset returns the byte index just after the first n runes of s
+func byteOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
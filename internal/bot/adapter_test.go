@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want []string
+	}{
+		{"empty", "  \n ", 10, nil},
+		{"fits", "hello there", 20, []string{"hello there"}},
+		{"paragraph", "first part here\n\nsecond part", 20, []string{"first part here", "second part"}},
+		{"line", "first line here\nsecond", 20, []string{"first line here", "second"}},
+		{"sentence", "今天天气很好。你呢", 8, []string{"今天天气很好。", "你呢"}},
+		{"word", "one two three four", 10, []string{"one two", "three four"}},
+		{"no boundary", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitMessage(tt.text, tt.max)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Fatalf("SplitMessage(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitMessageLimit(t *testing.T) {
+	text := strings.Repeat("长句子没有标点 ", 2000)
+	chunks := SplitMessage(text, 4096)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want the text split", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 4096 || n == 0 {
+			t.Fatalf("chunk %d has %d runes", i, n)
+		}
+		if !utf8.ValidString(c) {
+			t.Fatalf("chunk %d splits a rune", i)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-companion-cli-go/internal/metrics"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// typingInterval refreshes the typing indicator before platforms expire it (Telegram: 5s)
+const typingInterval = 4 * time.Second
+
+var queueDepth = metrics.NewGaugeVec("companion_job_queue_depth",
+	"Background work waiting to be processed, by queue.", "queue")
+
+// Bridge routes messages from an Adapter to characters through the orchestrator.
+// Every external chat is bound to one character and gets its own session.
+type Bridge struct {
+	repo    *storage.Repository
+	orch    *orchestrator.Orchestrator
+	adapter Adapter
+
+	defaultCharacter string          // used for chats without a binding
+	allowedUsers     map[string]bool // nil lets everyone talk to the bot
+
+	mu     sync.Mutex
+	queues map[string]chan Message // one worker per chat keeps replies in order
+	locks  map[string]*sync.Mutex  // one turn at a time per character
+}
+
+// NewBridge creates a bridge; defaultCharacter is the character new chats start with
+func NewBridge(repo *storage.Repository, orch *orchestrator.Orchestrator, adapter Adapter, defaultCharacter string) *Bridge {
+	return &Bridge{
+		repo:             repo,
+		orch:             orch,
+		adapter:          adapter,
+		defaultCharacter: defaultCharacter,
+		queues:           make(map[string]chan Message),
+		locks:            make(map[string]*sync.Mutex),
+	}
+}
+
+// AllowUsers restricts the bot to the given external user IDs
+func (b *Bridge) AllowUsers(ids []string) {
+	b.allowedUsers = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		b.allowedUsers[strings.TrimSpace(id)] = true
+	}
+}
+
+// Run receives messages until ctx is cancelled
+func (b *Bridge) Run(ctx context.Context) error {
+	return b.adapter.Receive(ctx, func(msg Message) {
+		if b.allowedUsers != nil && !b.allowedUsers[msg.UserID] {
+			log.Printf("bot: ignoring %s user %s (%s): not in the allow list", b.adapter.Platform(), msg.UserID, msg.UserName)
+			return
+		}
+		b.enqueue(ctx, msg)
+	})
+}
+
+func (b *Bridge) enqueue(ctx context.Context, msg Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	queue, ok := b.queues[msg.ChatID]
+	if !ok {
+		queue = make(chan Message, 32)
+		b.queues[msg.ChatID] = queue
+		go func() {
+			for m := range queue {
+				queueDepth.Dec(b.queueName())
+				b.handle(ctx, m)
+			}
+		}()
+	}
+
+	select {
+	case queue <- msg:
+		queueDepth.Inc(b.queueName())
+	default:
+		log.Printf("bot: chat %s is flooding, dropping message", msg.ChatID)
+	}
+}
+
+// queueName labels this bridge's messages in the queue depth metric
+func (b *Bridge) queueName() string {
+	return "bot_" + b.adapter.Platform()
+}
+
+// CharacterLock returns the mutex guarding turns on one character, for the proactive scheduler to share
+func (b *Bridge) CharacterLock(characterID string) *sync.Mutex {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	mu, ok := b.locks[characterID]
+	if !ok {
+		mu = &sync.Mutex{}
+		b.locks[characterID] = mu
+	}
+	return mu
+}
+
+func (b *Bridge) handle(ctx context.Context, msg Message) {
+	text := strings.TrimSpace(msg.Text)
+	if text == "" {
+		return
+	}
+
+	var reply string
+	var err error
+	if strings.HasPrefix(text, "/") {
+		reply, err = b.command(msg, text)
+	} else {
+		reply, err = b.chat(ctx, msg, text)
+	}
+	if err != nil {
+		log.Printf("bot: %s chat %s: %v", b.adapter.Platform(), msg.ChatID, err)
+		reply = "（出了点问题，请稍后再试）"
+	}
+	b.send(ctx, msg.ChatID, reply)
+}
+
+// Push sends a message the character wrote on its own to the chat whose session it was written for; it
+// reports whether the chat got it. Other chats with the character never see it: it was written from that
+// chat's history.
+func (b *Bridge) Push(ctx context.Context, profile *models.CharacterProfile, msg *models.ChatMessage) bool {
+	bindings, err := b.repo.ListChatBindingsByCharacter(b.adapter.Platform(), profile.CharacterID)
+	if err != nil {
+		log.Printf("bot: push for %s: %v", profile.CharacterID, err)
+		return false
+	}
+	for _, binding := range bindings {
+		if binding.SessionID != msg.SessionID {
+			continue
+		}
+		if b.allowedUsers != nil && !b.allowedUsers[binding.ExternalUserID] {
+			return false
+		}
+		return b.send(ctx, binding.ExternalChatID, msg.Content.String())
+	}
+	return false
+}
+
+// send splits long replies to fit the platform limit and reports whether everything went out
+func (b *Bridge) send(ctx context.Context, chatID, text string) bool {
+	for _, chunk := range SplitMessage(text, b.adapter.MaxMessageLen()) {
+		if err := b.adapter.Send(ctx, chatID, chunk); err != nil {
+			log.Printf("bot: send to %s: %v", chatID, err)
+			return false
+		}
+	}
+	return true
+}
+
+// chat runs one turn, keeping the typing indicator alive while the model answers
+func (b *Bridge) chat(ctx context.Context, msg Message, text string) (string, error) {
+	binding, err := b.binding(msg)
+	if err != nil {
+		return "", err
+	}
+	profile, err := b.repo.GetCharacter(binding.CharacterID)
+	if err != nil {
+		return "", err
+	}
+	if profile == nil {
+		return "这个角色已经不存在了，用 /characters 看看还有谁，再用 /use 切换。", nil
+	}
+
+	mu := b.CharacterLock(profile.CharacterID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	typingCtx, stopTyping := context.WithCancel(ctx)
+	defer stopTyping()
+	go func() {
+		ticker := time.NewTicker(typingInterval)
+		defer ticker.Stop()
+		for {
+			_ = b.adapter.Typing(typingCtx, msg.ChatID)
+			select {
+			case <-typingCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	session := b.orch.EnsureSessionID(profile.CharacterID, binding.SessionID)
+	result, err := b.orch.Reply(ctx, text, profile, session, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.Reply, nil
+}
+
+// binding returns the chat's binding, creating one on the default character for new chats
+func (b *Bridge) binding(msg Message) (*models.ChatBinding, error) {
+	platform := b.adapter.Platform()
+	binding, err := b.repo.GetChatBinding(platform, msg.ChatID)
+	if err != nil || binding != nil {
+		return binding, err
+	}
+
+	characterID := b.defaultCharacter
+	if characterID == "" {
+		chars, err := b.repo.ListCharacters()
+		if err != nil {
+			return nil, err
+		}
+		if len(chars) == 0 {
+			return nil, fmt.Errorf("no characters yet; create one with `characters create`")
+		}
+		characterID = chars[0].CharacterID
+	}
+	return b.bind(msg, characterID)
+}
+
+func (b *Bridge) bind(msg Message, characterID string) (*models.ChatBinding, error) {
+	binding := &models.ChatBinding{
+		Platform:       b.adapter.Platform(),
+		ExternalChatID: msg.ChatID,
+		ExternalUserID: msg.UserID,
+		CharacterID:    characterID,
+		SessionID:      fmt.Sprintf("sess_%s_%s_%s", characterID, b.adapter.Platform(), msg.ChatID),
+	}
+	if err := b.repo.SaveChatBinding(binding); err != nil {
+		return nil, err
+	}
+	return binding, nil
+}
+
+// command handles /start, /help, /characters, /use and /status
+func (b *Bridge) command(msg Message, text string) (string, error) {
+	name, arg, _ := strings.Cut(text, " ")
+	name, _, _ = strings.Cut(name, "@") // group chats address commands as /cmd@botname
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/start", "/help":
+		binding, err := b.binding(msg)
+		if err != nil {
+			return "", err
+		}
+		profile, err := b.repo.GetCharacter(binding.CharacterID)
+		if err != nil {
+			return "", err
+		}
+		if profile == nil {
+			return "这个角色已经不存在了，用 /characters 看看还有谁，再用 /use 切换。", nil
+		}
+		help := fmt.Sprintf("你正在和 %s 聊天。\n\n/characters 查看所有角色\n/use <ID或名字> 切换角色\n/status 查看你们的关系", profile.Name)
+		// A fresh chat opens with the character's greeting
+		session := b.orch.EnsureSessionID(profile.CharacterID, binding.SessionID)
+		if greeting, err := b.orch.Greet(profile, session); err != nil {
+			log.Printf("bot: greeting: %v", err)
+		} else if greeting != nil {
+			help += "\n\n" + greeting.Content.String()
+		}
+		return help, nil
+
+	case "/characters":
+		chars, err := b.repo.ListCharacters()
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		for _, c := range chars {
+			fmt.Fprintf(&sb, "%s  %s\n", c.CharacterID, c.Name)
+		}
+		if sb.Len() == 0 {
+			return "还没有任何角色。", nil
+		}
+		return sb.String(), nil
+
+	case "/use":
+		if arg == "" {
+			return "用法：/use <角色ID或名字>", nil
+		}
+		profile, err := b.findCharacter(arg)
+		if err != nil {
+			return "", err
+		}
+		if profile == nil {
+			return fmt.Sprintf("找不到角色 %q。", arg), nil
+		}
+		if _, err := b.bind(msg, profile.CharacterID); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("已切换到 %s。", profile.Name), nil
+
+	case "/status":
+		binding, err := b.binding(msg)
+		if err != nil {
+			return "", err
+		}
+		rel, err := b.repo.GetRelationshipState(binding.CharacterID)
+		if err != nil {
+			return "", err
+		}
+		if rel == nil {
+			return "你们还没有开始聊天。", nil
+		}
+		return fmt.Sprintf("亲密度等级 %d（%.0f%%）", rel.IntimacyLevel, rel.IntimacyScore), nil
+
+	default:
+		return "未知命令，发送 /help 查看帮助。", nil
+	}
+}
+
+// findCharacter resolves an ID or case-insensitive name; nil when nothing matches
+func (b *Bridge) findCharacter(ref string) (*models.CharacterProfile, error) {
+	profile, err := b.repo.GetCharacter(ref)
+	if err != nil || profile != nil {
+		return profile, err
+	}
+	chars, err := b.repo.ListCharacters()
+	if err != nil {
+		return nil, err
+	}
+	for i := range chars {
+		if strings.EqualFold(chars[i].Name, ref) {
+			return &chars[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// recordingAdapter is an Adapter that keeps what was sent per chat
+type recordingAdapter struct {
+	mu   sync.Mutex
+	sent map[string][]string
+}
+
+func (a *recordingAdapter) Platform() string   { return "fake" }
+func (a *recordingAdapter) MaxMessageLen() int { return 10 }
+
+func (a *recordingAdapter) Receive(ctx context.Context, handle func(Message)) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (a *recordingAdapter) Send(ctx context.Context, chatID, text string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sent[chatID] = append(a.sent[chatID], text)
+	return nil
+}
+
+func (a *recordingAdapter) Typing(ctx context.Context, chatID string) error { return nil }
+
+func newTestBridge(t *testing.T) (*Bridge, *recordingAdapter, *storage.Repository) {
+	t.Helper()
+	db := storage.NewDB(filepath.Join(t.TempDir(), "companion.db"))
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		if conn, err := db.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	repo := storage.NewRepository(db)
+	adapter := &recordingAdapter{sent: map[string][]string{}}
+	return NewBridge(repo, nil, adapter, "c"), adapter, repo
+}
+
+func TestPushOnlyReachesTheMessagesChat(t *testing.T) {
+	b, adapter, _ := newTestBridge(t)
+	var sessions []string
+	for _, chat := range []string{"1", "2"} {
+		binding, err := b.bind(Message{ChatID: chat, UserID: "u" + chat}, "c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions = append(sessions, binding.SessionID)
+	}
+
+	profile := &models.CharacterProfile{CharacterID: "c"}
+	msg := &models.ChatMessage{SessionID: sessions[0], CharacterID: "c", Content: "take your medicine now"}
+	if !b.Push(context.Background(), profile, msg) {
+		t.Fatal("push was not delivered")
+	}
+	if got := adapter.sent["1"]; len(got) != 3 || got[0] != "take your" {
+		t.Fatalf("chat 1 got %q, want the message split into 3 chunks", got)
+	}
+	if got := adapter.sent["2"]; len(got) != 0 {
+		t.Fatalf("chat 2 got %q, want nothing", got)
+	}
+
+	msg.SessionID = "sess_c"
+	if b.Push(context.Background(), profile, msg) {
+		t.Fatal("a message of a session without a chat was reported delivered")
+	}
+}
+
+func TestPushRespectsAllowList(t *testing.T) {
+	b, adapter, _ := newTestBridge(t)
+	binding, err := b.bind(Message{ChatID: "1", UserID: "u1"}, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.AllowUsers([]string{"someone-else"})
+
+	msg := &models.ChatMessage{SessionID: binding.SessionID, CharacterID: "c", Content: "hello"}
+	if b.Push(context.Background(), &models.CharacterProfile{CharacterID: "c"}, msg) {
+		t.Fatal("push reached a user outside the allow list")
+	}
+	if len(adapter.sent) != 0 {
+		t.Fatalf("sent %v, want nothing", adapter.sent)
+	}
+}
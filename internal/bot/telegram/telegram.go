@@ -0,0 +1,242 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-companion-cli-go/internal/bot"
+)
+
+// DefaultAPIURL is the public Bot API endpoint; point APIURL at a fake server for local testing
+const DefaultAPIURL = "https://api.telegram.org"
+
+const (
+	pollTimeout   = 30 // seconds a getUpdates call is held open
+	maxMessageLen = 4096
+	maxRetries    = 3
+
+	// Bot API limits: about one message per second per chat and 30 per second overall
+	perChatInterval = time.Second
+	globalInterval  = time.Second / 30
+)
+
+var _ bot.Adapter = (*Adapter)(nil)
+
+// Adapter talks to the Telegram Bot API using long polling
+type Adapter struct {
+	token  string
+	apiURL string
+	http   *http.Client
+
+	mu         sync.Mutex
+	nextGlobal time.Time
+	nextChat   map[string]time.Time
+}
+
+// New creates a Telegram adapter; apiURL may be empty for the public API
+func New(token, apiURL string) *Adapter {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Adapter{
+		token:    token,
+		apiURL:   strings.TrimRight(apiURL, "/"),
+		http:     &http.Client{Timeout: (pollTimeout + 10) * time.Second},
+		nextChat: make(map[string]time.Time),
+	}
+}
+
+// Platform implements bot.Adapter
+func (a *Adapter) Platform() string { return "telegram" }
+
+// MaxMessageLen implements bot.Adapter
+func (a *Adapter) MaxMessageLen() int { return maxMessageLen }
+
+// apiResponse is the envelope of every Bot API reply
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+type update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *message `json:"message"`
+}
+
+type message struct {
+	MessageThreadID int64  `json:"message_thread_id"`
+	Text            string `json:"text"`
+	From            *struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"from"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// call invokes a Bot API method, waiting out 429 responses as instructed by retry_after
+func (a *Adapter) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/bot%s/%s", a.apiURL, a.token, method), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := a.http.Do(req)
+		if err != nil {
+			// the request URL embeds the bot token, keep it out of logs
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return fmt.Errorf("telegram %s: %w", method, err)
+		}
+		var res apiResponse
+		err = json.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("telegram %s: %s: %w", method, resp.Status, err)
+		}
+
+		if res.OK {
+			if out != nil {
+				return json.Unmarshal(res.Result, out)
+			}
+			return nil
+		}
+		if res.ErrorCode == http.StatusTooManyRequests && attempt < maxRetries {
+			wait := time.Duration(res.Parameters.RetryAfter) * time.Second
+			if wait <= 0 {
+				wait = time.Second
+			}
+			log.Printf("telegram: rate limited on %s, retrying in %s", method, wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		return fmt.Errorf("telegram %s: %d %s", method, res.ErrorCode, res.Description)
+	}
+}
+
+// Receive implements bot.Adapter with getUpdates long polling
+func (a *Adapter) Receive(ctx context.Context, handle func(bot.Message)) error {
+	var offset int64
+	for {
+		var updates []update
+		err := a.call(ctx, "getUpdates", map[string]interface{}{
+			"offset":          offset,
+			"timeout":         pollTimeout,
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("telegram: getUpdates: %v", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(3 * time.Second):
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			m := u.Message
+			if m == nil || m.Text == "" || m.From == nil {
+				continue
+			}
+			handle(bot.Message{
+				ChatID:   chatKey(m.Chat.ID, m.MessageThreadID),
+				UserID:   strconv.FormatInt(m.From.ID, 10),
+				UserName: m.From.Username,
+				Text:     m.Text,
+			})
+		}
+	}
+}
+
+// Send implements bot.Adapter
+func (a *Adapter) Send(ctx context.Context, chatID, text string) error {
+	if err := a.wait(ctx, chatID); err != nil {
+		return err
+	}
+	params := chatParams(chatID)
+	params["text"] = text
+	return a.call(ctx, "sendMessage", params, nil)
+}
+
+// Typing implements bot.Adapter
+func (a *Adapter) Typing(ctx context.Context, chatID string) error {
+	params := chatParams(chatID)
+	params["action"] = "typing"
+	return a.call(ctx, "sendChatAction", params, nil)
+}
+
+// wait paces outgoing messages below the Bot API limits
+func (a *Adapter) wait(ctx context.Context, chatID string) error {
+	a.mu.Lock()
+	now := time.Now()
+	at := now
+	if a.nextGlobal.After(at) {
+		at = a.nextGlobal
+	}
+	if next := a.nextChat[chatID]; next.After(at) {
+		at = next
+	}
+	a.nextGlobal = at.Add(globalInterval)
+	a.nextChat[chatID] = at.Add(perChatInterval)
+	a.mu.Unlock()
+
+	if delay := at.Sub(now); delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return nil
+}
+
+// chatKey identifies a chat, or a topic thread inside a forum group, as "<chat>[:<thread>]"
+func chatKey(chatID, threadID int64) string {
+	if threadID != 0 {
+		return fmt.Sprintf("%d:%d", chatID, threadID)
+	}
+	return strconv.FormatInt(chatID, 10)
+}
+
+func chatParams(key string) map[string]interface{} {
+	chat, thread, _ := strings.Cut(key, ":")
+	params := map[string]interface{}{"chat_id": chat}
+	if thread != "" {
+		params["message_thread_id"] = thread
+	}
+	return params
+}
@@ -0,0 +1,209 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-companion-cli-go/internal/bot"
+)
+
+// fakeBotAPI is a local stand-in for the Telegram Bot API that records every call
+type fakeBotAPI struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	calls   []fakeCall
+	limited int                 // sendMessage calls still answered with 429
+	updates [][]json.RawMessage // getUpdates results, served in order
+}
+
+type fakeCall struct {
+	Method string
+	Params map[string]interface{}
+	At     time.Time
+}
+
+func newFakeBotAPI(t *testing.T) (*fakeBotAPI, *Adapter) {
+	f := &fakeBotAPI{t: t}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, New("123:secret", srv.URL)
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+	if !ok || token != "123:secret" {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": 404, "description": "Not Found"})
+		return
+	}
+	var params map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		f.t.Errorf("%s: bad body: %v", method, err)
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, fakeCall{Method: method, Params: params, At: time.Now()})
+	var result interface{} = true
+	switch method {
+	case "sendMessage":
+		if f.limited > 0 {
+			f.limited--
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok": false, "error_code": 429, "description": "Too Many Requests: retry after 1",
+				"parameters": map[string]int{"retry_after": 1},
+			})
+			return
+		}
+		result = map[string]interface{}{"message_id": len(f.calls)}
+	case "getUpdates":
+		result = []json.RawMessage{}
+		if len(f.updates) > 0 {
+			result, f.updates = f.updates[0], f.updates[1:]
+		}
+	}
+	f.mu.Unlock()
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func (f *fakeBotAPI) callsOf(method string) []fakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []fakeCall
+	for _, c := range f.calls {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func TestSendToTopicThread(t *testing.T) {
+	f, a := newFakeBotAPI(t)
+	if err := a.Send(context.Background(), "-100200:7", "hello"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	calls := f.callsOf("sendMessage")
+	if len(calls) != 1 {
+		t.Fatalf("sendMessage calls = %d, want 1", len(calls))
+	}
+	p := calls[0].Params
+	if p["chat_id"] != "-100200" || p["message_thread_id"] != "7" || p["text"] != "hello" {
+		t.Fatalf("sendMessage params = %v", p)
+	}
+}
+
+func TestTyping(t *testing.T) {
+	f, a := newFakeBotAPI(t)
+	if err := a.Typing(context.Background(), "42"); err != nil {
+		t.Fatalf("typing: %v", err)
+	}
+	calls := f.callsOf("sendChatAction")
+	if len(calls) != 1 || calls[0].Params["chat_id"] != "42" || calls[0].Params["action"] != "typing" {
+		t.Fatalf("sendChatAction calls = %v", calls)
+	}
+	if _, ok := calls[0].Params["message_thread_id"]; ok {
+		t.Fatalf("plain chat got a thread: %v", calls[0].Params)
+	}
+}
+
+func TestSendWaitsOutRetryAfter(t *testing.T) {
+	f, a := newFakeBotAPI(t)
+	f.limited = 1
+
+	start := time.Now()
+	if err := a.Send(context.Background(), "42", "hello"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	calls := f.callsOf("sendMessage")
+	if len(calls) != 2 {
+		t.Fatalf("sendMessage calls = %d, want a retry after the 429", len(calls))
+	}
+	if gap := calls[1].At.Sub(calls[0].At); gap < time.Second {
+		t.Fatalf("retried after %s, want at least retry_after (1s)", gap)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("send took far longer than retry_after")
+	}
+}
+
+func TestSendGivesUpAfterRetries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits out several retry_after periods")
+	}
+	f, a := newFakeBotAPI(t)
+	f.limited = maxRetries + 1
+	err := a.Send(context.Background(), "42", "hello")
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("send error = %v, want the 429", err)
+	}
+	if n := len(f.callsOf("sendMessage")); n != maxRetries+1 {
+		t.Fatalf("sendMessage calls = %d, want %d", n, maxRetries+1)
+	}
+}
+
+func TestSendRetryStopsWithContext(t *testing.T) {
+	f, a := newFakeBotAPI(t)
+	f.limited = 1
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := a.Send(ctx, "42", "hello"); err != context.DeadlineExceeded {
+		t.Fatalf("send error = %v, want the context's", err)
+	}
+}
+
+func TestErrorsHideToken(t *testing.T) {
+	a := New("123:secret", "http://127.0.0.1:1")
+	err := a.Send(context.Background(), "42", "hello")
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Fatalf("send error = %v, want one without the token", err)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	f, a := newFakeBotAPI(t)
+	f.updates = [][]json.RawMessage{{
+		json.RawMessage(`{"update_id":10,"message":{"text":"hi","from":{"id":5,"username":"aki"},"chat":{"id":42}}}`),
+		json.RawMessage(`{"update_id":11,"message":{"text":"","from":{"id":5},"chat":{"id":42}}}`),
+		json.RawMessage(`{"update_id":12,"message":{"message_thread_id":3,"text":"in a topic","from":{"id":6},"chat":{"id":-100}}}`),
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []bot.Message
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Receive(ctx, func(m bot.Message) { got = append(got, m) })
+	}()
+	// The second poll starts once the first batch is handled
+	for deadline := time.Now().Add(5 * time.Second); len(f.callsOf("getUpdates")) < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("receive did not poll again")
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+
+	want := []bot.Message{
+		{ChatID: "42", UserID: "5", UserName: "aki", Text: "hi"},
+		{ChatID: "-100:3", UserID: "6", Text: "in a topic"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("received %v, want %v", got, want)
+	}
+	// The next poll acknowledges everything seen so far
+	polls := f.callsOf("getUpdates")
+	if len(polls) < 2 || polls[1].Params["offset"] != float64(13) {
+		t.Fatalf("getUpdates calls = %v, want offset 13 after the first batch", polls)
+	}
+}
@@ -0,0 +1,169 @@
+package bundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// FormatVersion is bumped whenever the bundle layout changes incompatibly
+const FormatVersion = 1
+
+// zipEntryName is the JSON document stored inside zipped bundles
+const zipEntryName = "bundle.json"
+
+// Bundle is a portable snapshot of one character and its full relationship history
+type Bundle struct {
+	FormatVersion int                             `json:"format_version"`
+	ExportedAt    time.Time                       `json:"exported_at"`
+	Character     models.CharacterProfile         `json:"character"`
+	Sessions      []models.SessionState           `json:"sessions"`
+	Messages      []models.ChatMessage            `json:"messages"`
+	Relationship  *models.RelationshipState       `json:"relationship,omitempty"`
+	Transitions   []models.RelationshipTransition `json:"transitions,omitempty"`
+	Emotion       *models.CharacterEmotionState   `json:"emotion,omitempty"`
+	Facts         []models.MemoryFact             `json:"facts"`
+	Summaries     []models.MemorySummary          `json:"summaries"`
+	Lorebook      []models.LorebookEntry          `json:"lorebook,omitempty"`
+	Media         []models.Media                  `json:"media,omitempty"`
+	Reminders     []models.Reminder               `json:"reminders,omitempty"`
+}
+
+// Export collects everything stored for a character into a bundle
+func Export(repo *storage.Repository, characterID string) (*Bundle, error) {
+	profile, err := repo.GetCharacter(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, fmt.Errorf("character %s not found", characterID)
+	}
+
+	b := &Bundle{
+		FormatVersion: FormatVersion,
+		ExportedAt:    time.Now(),
+		Character:     *profile,
+	}
+
+	if b.Sessions, err = repo.ListSessionsByCharacter(characterID); err != nil {
+		return nil, err
+	}
+	if b.Messages, err = repo.ListMessagesByCharacter(characterID); err != nil {
+		return nil, err
+	}
+	if b.Relationship, err = repo.GetRelationshipState(characterID); err != nil {
+		return nil, err
+	}
+	if b.Transitions, err = repo.ListRelationshipTransitions(characterID); err != nil {
+		return nil, err
+	}
+	if b.Emotion, err = repo.GetEmotionState(characterID); err != nil {
+		return nil, err
+	}
+	if b.Facts, err = repo.ListMemoryFactsByCharacter(characterID); err != nil {
+		return nil, err
+	}
+	if b.Summaries, err = repo.ListMemorySummariesByCharacter(characterID); err != nil {
+		return nil, err
+	}
+	if b.Lorebook, err = repo.ListLorebookEntries(characterID); err != nil {
+		return nil, err
+	}
+	if b.Media, err = repo.ListMediaByCharacter(characterID); err != nil {
+		return nil, err
+	}
+	if b.Reminders, err = repo.ListReminders(characterID, true); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// WriteFile writes the bundle as JSON, or as a zip archive when path ends in .zip
+func WriteFile(b *Bundle, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, b, isZip(path)); err != nil {
+		f.Close()
+		return err
+	}
+	// The data is only known to be on disk once the file is closed
+	return f.Close()
+}
+
+func write(w io.Writer, b *Bundle, zipped bool) error {
+	if !zipped {
+		return writeJSON(w, b)
+	}
+	zw := zip.NewWriter(w)
+	entry, err := zw.Create(zipEntryName)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(entry, b); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadFile loads a bundle written by WriteFile
+func ReadFile(path string) (*Bundle, error) {
+	if !isZip(path) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return readJSON(f)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	for _, entry := range zr.File {
+		if entry.Name != zipEntryName {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return readJSON(rc)
+	}
+	return nil, fmt.Errorf("%s: archive has no %s", path, zipEntryName)
+}
+
+func writeJSON(w io.Writer, b *Bundle) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+func readJSON(r io.Reader) (*Bundle, error) {
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, err
+	}
+	if b.FormatVersion == 0 || b.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle format version %d", b.FormatVersion)
+	}
+	return &b, nil
+}
+
+func isZip(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".zip")
+}
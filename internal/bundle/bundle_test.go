@@ -0,0 +1,29 @@
+package bundle
+
+import (
+	"path/filepath"
+	"testing"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+func TestWriteFileRoundTrip(t *testing.T) {
+	b := &Bundle{
+		FormatVersion: 1,
+		Character:     models.CharacterProfile{CharacterID: "c", Name: "Aoi"},
+		Messages:      []models.ChatMessage{{SessionID: "sess_c", CharacterID: "c", Role: "user", Content: "hello"}},
+	}
+	for _, name := range []string{"aoi.json", "aoi.zip"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := WriteFile(b, path); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		got, err := ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if got.Character.Name != "Aoi" || len(got.Messages) != 1 || got.Messages[0].Content != "hello" {
+			t.Fatalf("%s read back as %+v", name, got)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package bundle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// ConflictMode decides what happens when the imported character already exists
+type ConflictMode string
+
+const (
+	// ConflictFail aborts the import and leaves the database untouched
+	ConflictFail ConflictMode = "fail"
+	// ConflictReplace deletes the existing character and its history first
+	ConflictReplace ConflictMode = "replace"
+	// ConflictDuplicate imports the bundle as a new character with fresh IDs
+	ConflictDuplicate ConflictMode = "duplicate"
+)
+
+// ParseConflictMode validates a user supplied conflict mode
+func ParseConflictMode(s string) (ConflictMode, error) {
+	switch m := ConflictMode(strings.ToLower(s)); m {
+	case ConflictFail, ConflictReplace, ConflictDuplicate:
+		return m, nil
+	case "":
+		return ConflictFail, nil
+	default:
+		return "", fmt.Errorf("unknown conflict mode %q (want fail, replace or duplicate)", s)
+	}
+}
+
+// Import writes a bundle into the database in a single transaction and returns the resulting character ID
+func Import(repo *storage.Repository, b *Bundle, mode ConflictMode) (string, error) {
+	existing, err := repo.GetCharacter(b.Character.CharacterID)
+	if err != nil {
+		return "", err
+	}
+
+	remap := mode == ConflictDuplicate
+	if existing != nil && mode == ConflictFail {
+		return "", fmt.Errorf("character %s (%s) already exists", existing.CharacterID, existing.Name)
+	}
+
+	oldID := b.Character.CharacterID
+	newID := oldID
+	if remap {
+		newID = orchestrator.GenerateCharacterID()
+	}
+
+	err = repo.Transaction(func(tx *storage.Repository) error {
+		if existing != nil && mode == ConflictReplace {
+			if err := tx.DeleteCharacter(oldID); err != nil {
+				return err
+			}
+		}
+
+		profile := b.Character
+		profile.CharacterID = newID
+		if err := tx.CreateCharacter(&profile); err != nil {
+			return err
+		}
+
+		sessionIDs := make(map[string]string, len(b.Sessions))
+		for _, s := range b.Sessions {
+			newSessionID := s.SessionID
+			if remap {
+				newSessionID = remapSessionID(s.SessionID, oldID, newID)
+			}
+			sessionIDs[s.SessionID] = newSessionID
+			s.SessionID = newSessionID
+			s.CharacterID = newID
+			if err := tx.SaveSessionState(&s); err != nil {
+				return err
+			}
+		}
+
+		// Message IDs are auto-incremented, so they always get renumbered
+		messageIDs := make(map[string]string, len(b.Messages))
+		for _, m := range b.Messages {
+			oldMessageID := strconv.FormatUint(uint64(m.ID), 10)
+			m.ID = 0
+			m.CharacterID = newID
+			if mapped, ok := sessionIDs[m.SessionID]; ok {
+				m.SessionID = mapped
+			} else if remap {
+				mapped = remapSessionID(m.SessionID, oldID, newID)
+				sessionIDs[m.SessionID] = mapped
+				m.SessionID = mapped
+			}
+			if err := tx.AppendMessage(&m); err != nil {
+				return err
+			}
+			messageIDs[oldMessageID] = strconv.FormatUint(uint64(m.ID), 10)
+		}
+
+		if b.Relationship != nil {
+			rel := *b.Relationship
+			rel.CharacterID = newID
+			if err := tx.SaveRelationshipState(&rel); err != nil {
+				return err
+			}
+		}
+
+		for _, t := range b.Transitions {
+			t.ID = 0
+			t.CharacterID = newID
+			if err := tx.AppendRelationshipTransition(&t); err != nil {
+				return err
+			}
+		}
+
+		if b.Emotion != nil {
+			emo := *b.Emotion
+			emo.CharacterID = newID
+			if err := tx.SaveEmotionState(&emo); err != nil {
+				return err
+			}
+		}
+
+		for _, f := range b.Facts {
+			if remap {
+				f.FactID = orchestrator.GenerateFactID()
+			}
+			f.CharacterID = newID
+			if mapped, ok := sessionIDs[f.SessionID]; ok {
+				f.SessionID = mapped
+			}
+			if mapped, ok := messageIDs[f.SourceMessageID]; ok {
+				f.SourceMessageID = mapped
+			}
+			if err := tx.SaveMemoryFact(&f); err != nil {
+				return err
+			}
+		}
+
+		for _, s := range b.Summaries {
+			s.ID = 0
+			s.CharacterID = newID
+			if err := tx.AppendMemorySummary(&s); err != nil {
+				return err
+			}
+		}
+
+		for _, e := range b.Lorebook {
+			e.ID = 0
+			e.CharacterID = newID
+			if err := tx.SaveLorebookEntry(&e); err != nil {
+				return err
+			}
+		}
+
+		for _, m := range b.Media {
+			m.ID = 0
+			m.CharacterID = newID
+			if mapped, ok := sessionIDs[m.SessionID]; ok {
+				m.SessionID = mapped
+			}
+			if m.MessageID != 0 {
+				mapped, _ := strconv.ParseUint(messageIDs[strconv.FormatUint(uint64(m.MessageID), 10)], 10, 64)
+				m.MessageID = uint(mapped)
+			}
+			if err := tx.AppendMedia(&m); err != nil {
+				return err
+			}
+		}
+
+		for _, r := range b.Reminders {
+			r.ID = 0
+			r.CharacterID = newID
+			if mapped, ok := sessionIDs[r.SessionID]; ok {
+				r.SessionID = mapped
+			}
+			if r.MessageID != 0 {
+				mapped, _ := strconv.ParseUint(messageIDs[strconv.FormatUint(uint64(r.MessageID), 10)], 10, 64)
+				r.MessageID = uint(mapped)
+			}
+			if err := tx.SaveReminder(&r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return newID, nil
+}
+
+// remapSessionID keeps the default "sess_<character>" naming intact and gives other sessions a fresh ID
+func remapSessionID(sessionID, oldCharacterID, newCharacterID string) string {
+	if sessionID == "sess_"+oldCharacterID {
+		return "sess_" + newCharacterID
+	}
+	return "sess_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+}
@@ -0,0 +1,301 @@
+package charcard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+)
+
+// SpecV2 and SpecVersionV2 identify a Character Card V2 document
+const (
+	SpecV2        = "chara_card_v2"
+	SpecVersionV2 = "2.0"
+)
+
+// extensionKey namespaces our own profile fields inside data.extensions so they survive a round trip
+const extensionKey = "ai_companion"
+
+// Card is the Character Card V2 envelope used by SillyTavern, TavernAI and friends
+type Card struct {
+	Spec        string   `json:"spec"`
+	SpecVersion string   `json:"spec_version"`
+	Data        CardData `json:"data"`
+}
+
+// CardData holds the character definition of a V2 card
+type CardData struct {
+	Name                    string                 `json:"name"`
+	Description             string                 `json:"description"`
+	Personality             string                 `json:"personality"`
+	Scenario                string                 `json:"scenario"`
+	FirstMes                string                 `json:"first_mes"`
+	MesExample              string                 `json:"mes_example"`
+	CreatorNotes            string                 `json:"creator_notes"`
+	SystemPrompt            string                 `json:"system_prompt"`
+	PostHistoryInstructions string                 `json:"post_history_instructions"`
+	AlternateGreetings      []string               `json:"alternate_greetings"`
+	CharacterBook           *CharacterBook         `json:"character_book,omitempty"`
+	Tags                    []string               `json:"tags"`
+	Creator                 string                 `json:"creator"`
+	CharacterVersion        string                 `json:"character_version"`
+	Extensions              map[string]interface{} `json:"extensions"`
+}
+
+// CharacterBook is the embedded lorebook of a V2 card
+type CharacterBook struct {
+	Name              string                 `json:"name,omitempty"`
+	Description       string                 `json:"description,omitempty"`
+	ScanDepth         *int                   `json:"scan_depth,omitempty"`
+	TokenBudget       *int                   `json:"token_budget,omitempty"`
+	RecursiveScanning *bool                  `json:"recursive_scanning,omitempty"`
+	Extensions        map[string]interface{} `json:"extensions"`
+	Entries           []CharacterBookEntry   `json:"entries"`
+}
+
+// CharacterBookEntry is a single keyword-triggered lorebook entry
+type CharacterBookEntry struct {
+	Keys           []string               `json:"keys"`
+	Content        string                 `json:"content"`
+	Extensions     map[string]interface{} `json:"extensions"`
+	Enabled        bool                   `json:"enabled"`
+	InsertionOrder int                    `json:"insertion_order"`
+	CaseSensitive  *bool                  `json:"case_sensitive,omitempty"`
+	Name           string                 `json:"name,omitempty"`
+	Priority       *int                   `json:"priority,omitempty"`
+	ID             *int                   `json:"id,omitempty"`
+	Comment        string                 `json:"comment,omitempty"`
+	Selective      *bool                  `json:"selective,omitempty"`
+	SecondaryKeys  []string               `json:"secondary_keys,omitempty"`
+	Constant       *bool                  `json:"constant,omitempty"`
+	Position       string                 `json:"position,omitempty"` // before_char, after_char
+}
+
+// companionExtension carries profile fields that have no card equivalent
+type companionExtension struct {
+	Age                  int      `json:"age,omitempty"`
+	Gender               string   `json:"gender,omitempty"`
+	RelationshipType     string   `json:"relationship_type,omitempty"`
+	SpeechStyle          string   `json:"speech_style,omitempty"`
+	Catchphrase          string   `json:"catchphrase,omitempty"`
+	PersonalityTags      []string `json:"personality_tags,omitempty"`
+	MBTI                 string   `json:"mbti,omitempty"`
+	ArtStyle             string   `json:"art_style,omitempty"`
+	FamilyBackground     string   `json:"family_background,omitempty"`
+	EducationDetail      string   `json:"education_detail,omitempty"`
+	DatingHistory        string   `json:"dating_history,omitempty"`
+	ReferenceImagePrompt string   `json:"reference_image_prompt,omitempty"`
+}
+
+// Parse decodes a V2 card, falling back to the flat V1 layout used by older TavernAI cards
+func Parse(raw []byte) (*Card, error) {
+	var card Card
+	if err := json.Unmarshal(raw, &card); err != nil {
+		return nil, err
+	}
+	if card.Spec == SpecV2 {
+		if card.Data.Name == "" {
+			return nil, errors.New("character card has no name")
+		}
+		return &card, nil
+	}
+	if card.Spec != "" {
+		return nil, fmt.Errorf("unsupported character card spec %q", card.Spec)
+	}
+
+	var v1 CardData
+	if err := json.Unmarshal(raw, &v1); err != nil {
+		return nil, err
+	}
+	if v1.Name == "" {
+		return nil, errors.New("not a character card: missing name")
+	}
+	return &Card{Spec: SpecV2, SpecVersion: SpecVersionV2, Data: v1}, nil
+}
+
+// ToProfile maps a card onto a new CharacterProfile; card-only fields are kept in ProfileJSON
+func (c *Card) ToProfile() *models.CharacterProfile {
+	d := c.Data
+	profile := &models.CharacterProfile{
+		CharacterID:        orchestrator.GenerateCharacterID(),
+		Name:               d.Name,
+		CharacterBackstory: d.Description,
+		PersonalityTags:    splitPersonality(d.Personality),
+		ProfileJSON:        models.MapJSON{},
+	}
+
+	setIfNotEmpty(profile.ProfileJSON, "personality", d.Personality)
+	setIfNotEmpty(profile.ProfileJSON, "scenario", d.Scenario)
+	setIfNotEmpty(profile.ProfileJSON, "first_mes", d.FirstMes)
+	setIfNotEmpty(profile.ProfileJSON, "mes_example", d.MesExample)
+	setIfNotEmpty(profile.ProfileJSON, "creator_notes", d.CreatorNotes)
+	setIfNotEmpty(profile.ProfileJSON, "system_prompt", d.SystemPrompt)
+	setIfNotEmpty(profile.ProfileJSON, "post_history_instructions", d.PostHistoryInstructions)
+	setIfNotEmpty(profile.ProfileJSON, "creator", d.Creator)
+	setIfNotEmpty(profile.ProfileJSON, "character_version", d.CharacterVersion)
+	if len(d.AlternateGreetings) > 0 {
+		profile.ProfileJSON["alternate_greetings"] = d.AlternateGreetings
+	}
+	if len(d.Tags) > 0 {
+		profile.ProfileJSON["tags"] = d.Tags
+	}
+	if d.CharacterBook != nil {
+		// The entries become lorebook rows (see LorebookEntries); only the book's settings stay on the profile
+		book := *d.CharacterBook
+		book.Entries = nil
+		profile.ProfileJSON["character_book"] = toGeneric(book)
+	}
+
+	if raw, ok := d.Extensions[extensionKey]; ok {
+		var ext companionExtension
+		if err := fromGeneric(raw, &ext); err == nil {
+			profile.Age = ext.Age
+			profile.Gender = ext.Gender
+			profile.RelationshipType = ext.RelationshipType
+			profile.SpeechStyle = ext.SpeechStyle
+			profile.Catchphrase = ext.Catchphrase
+			profile.MBTI = ext.MBTI
+			profile.ArtStyle = ext.ArtStyle
+			profile.FamilyBackground = ext.FamilyBackground
+			profile.EducationDetail = ext.EducationDetail
+			profile.DatingHistory = ext.DatingHistory
+			profile.ReferenceImagePrompt = ext.ReferenceImagePrompt
+			if len(ext.PersonalityTags) > 0 {
+				profile.PersonalityTags = ext.PersonalityTags
+			}
+		}
+	}
+
+	return profile
+}
+
+// FromProfile builds a V2 card from one of our profiles
+func FromProfile(profile *models.CharacterProfile) *Card {
+	pj := profile.ProfileJSON
+
+	personality := stringField(pj, "personality")
+	if personality == "" {
+		parts := append([]string{}, profile.PersonalityTags...)
+		if profile.MBTI != "" {
+			parts = append(parts, profile.MBTI)
+		}
+		personality = strings.Join(parts, ", ")
+	}
+
+	mesExample := stringField(pj, "mes_example")
+	if mesExample == "" && profile.Catchphrase != "" {
+		mesExample = fmt.Sprintf("<START>\n{{char}}: %s", profile.Catchphrase)
+	}
+
+	d := CardData{
+		Name:                    profile.Name,
+		Description:             profile.CharacterBackstory,
+		Personality:             personality,
+		Scenario:                stringField(pj, "scenario"),
+		FirstMes:                stringField(pj, "first_mes"),
+		MesExample:              mesExample,
+		CreatorNotes:            stringField(pj, "creator_notes"),
+		SystemPrompt:            stringField(pj, "system_prompt"),
+		PostHistoryInstructions: stringField(pj, "post_history_instructions"),
+		AlternateGreetings:      stringsField(pj, "alternate_greetings"),
+		Tags:                    stringsField(pj, "tags"),
+		Creator:                 stringField(pj, "creator"),
+		CharacterVersion:        stringField(pj, "character_version"),
+		Extensions: map[string]interface{}{
+			extensionKey: toGeneric(companionExtension{
+				Age:                  profile.Age,
+				Gender:               profile.Gender,
+				RelationshipType:     profile.RelationshipType,
+				SpeechStyle:          profile.SpeechStyle,
+				Catchphrase:          profile.Catchphrase,
+				PersonalityTags:      profile.PersonalityTags,
+				MBTI:                 profile.MBTI,
+				ArtStyle:             profile.ArtStyle,
+				FamilyBackground:     profile.FamilyBackground,
+				EducationDetail:      profile.EducationDetail,
+				DatingHistory:        profile.DatingHistory,
+				ReferenceImagePrompt: profile.ReferenceImagePrompt,
+			}),
+		},
+	}
+	if d.AlternateGreetings == nil {
+		d.AlternateGreetings = []string{}
+	}
+	if d.Tags == nil {
+		d.Tags = []string{}
+	}
+
+	if raw, ok := pj["character_book"]; ok {
+		var book CharacterBook
+		if err := fromGeneric(raw, &book); err == nil {
+			if book.Entries == nil {
+				book.Entries = []CharacterBookEntry{}
+			}
+			d.CharacterBook = &book
+		}
+	}
+
+	return &Card{Spec: SpecV2, SpecVersion: SpecVersionV2, Data: d}
+}
+
+// splitPersonality turns "kind, shy, curious" style summaries into tags, leaving prose untouched
+func splitPersonality(personality string) []string {
+	fields := strings.FieldsFunc(personality, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == ';' || r == '；'
+	})
+	var tags []string
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if len([]rune(f)) > 24 {
+			return nil
+		}
+		tags = append(tags, f)
+	}
+	return tags
+}
+
+func setIfNotEmpty(m models.MapJSON, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+func stringField(m models.MapJSON, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func stringsField(m models.MapJSON, key string) []string {
+	var out []string
+	_ = fromGeneric(m[key], &out)
+	return out
+}
+
+// toGeneric converts a typed value into the map/slice form stored in MapJSON
+func toGeneric(v interface{}) interface{} {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out interface{}
+	_ = json.Unmarshal(raw, &out)
+	return out
+}
+
+// fromGeneric converts a MapJSON value back into a typed struct
+func fromGeneric(v interface{}, out interface{}) error {
+	if v == nil {
+		return errors.New("missing value")
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
@@ -0,0 +1,45 @@
+package charcard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadFile loads a card from a .json file or a .png with an embedded "chara" chunk
+func ReadFile(path string) (*Card, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isPNG(path) {
+		if data, err = ExtractFromPNG(data); err != nil {
+			return nil, err
+		}
+	}
+	return Parse(data)
+}
+
+// WriteFile writes a card as JSON, or embeds it into a PNG when path ends in .png.
+// basePNG is the avatar to embed into; a placeholder is used when it is empty.
+func WriteFile(card *Card, path string, basePNG []byte) error {
+	data, err := json.MarshalIndent(card, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if isPNG(path) {
+		if len(basePNG) == 0 {
+			basePNG = PlaceholderPNG()
+		}
+		if data, err = EmbedInPNG(basePNG, data); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func isPNG(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".png")
+}
@@ -0,0 +1,162 @@
+package charcard
+
+import (
+	"regexp"
+	"strings"
+
+	"ai-companion-cli-go/internal/lorebook"
+	"ai-companion-cli-go/internal/models"
+)
+
+// entryExtension carries lorebook fields that have no card equivalent
+type entryExtension struct {
+	MaxTokens int `json:"max_tokens,omitempty"`
+}
+
+// LorebookEntries converts the card's character_book into lorebook entries (without a character ID)
+func (c *Card) LorebookEntries() []models.LorebookEntry {
+	return entriesFromBook(c.Data.CharacterBook)
+}
+
+// StoredLorebook reads the character_book a card import kept in ProfileJSON before lorebooks were stored as entries
+func StoredLorebook(profile *models.CharacterProfile) []models.LorebookEntry {
+	var book CharacterBook
+	if err := fromGeneric(profile.ProfileJSON["character_book"], &book); err != nil {
+		return nil
+	}
+	return entriesFromBook(&book)
+}
+
+// AttachLorebook puts a character's lorebook entries into the card's character_book
+func AttachLorebook(c *Card, entries []models.LorebookEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	if c.Data.CharacterBook == nil {
+		c.Data.CharacterBook = &CharacterBook{Extensions: map[string]interface{}{}}
+	}
+	book := c.Data.CharacterBook
+	book.Entries = make([]CharacterBookEntry, 0, len(entries))
+	for i, e := range entries {
+		id, priority := i+1, e.Priority
+		caseSensitive, selective, constant := e.CaseSensitive, len(e.SecondaryKeys) > 0, e.Constant
+		entry := CharacterBookEntry{
+			Keys:           cardKeys(e.Keys, e.Regex),
+			Content:        e.Content,
+			Extensions:     map[string]interface{}{"exclude_recursion": e.NoRecursion},
+			Enabled:        e.Enabled,
+			InsertionOrder: e.InsertionOrder,
+			CaseSensitive:  &caseSensitive,
+			Name:           e.Name,
+			Priority:       &priority,
+			ID:             &id,
+			Selective:      &selective,
+			SecondaryKeys:  cardKeys(e.SecondaryKeys, e.Regex),
+			Constant:       &constant,
+			Position:       e.Position,
+		}
+		if e.MaxTokens > 0 {
+			entry.Extensions[extensionKey] = toGeneric(entryExtension{MaxTokens: e.MaxTokens})
+		}
+		book.Entries = append(book.Entries, entry)
+	}
+}
+
+// entriesFromBook maps card entries onto lorebook entries. Keys written as /pattern/flags (SillyTavern's
+// regex syntax) make the entry a regex entry; plain keys next to them are escaped.
+func entriesFromBook(book *CharacterBook) []models.LorebookEntry {
+	if book == nil {
+		return nil
+	}
+	out := make([]models.LorebookEntry, 0, len(book.Entries))
+	for _, ce := range book.Entries {
+		e := models.LorebookEntry{
+			Name:           ce.Name,
+			Content:        ce.Content,
+			Position:       ce.Position,
+			InsertionOrder: ce.InsertionOrder,
+			Enabled:        ce.Enabled,
+			CaseSensitive:  ce.CaseSensitive != nil && *ce.CaseSensitive,
+			Constant:       ce.Constant != nil && *ce.Constant,
+		}
+		if e.Name == "" {
+			e.Name = ce.Comment
+		}
+		if ce.Priority != nil {
+			e.Priority = *ce.Priority
+		}
+		if e.Position != lorebook.PositionBeforeChar && e.Position != lorebook.PositionAfterChar {
+			e.Position = ""
+		}
+		if v, ok := ce.Extensions["exclude_recursion"].(bool); ok {
+			e.NoRecursion = v
+		}
+		var ext entryExtension
+		if err := fromGeneric(ce.Extensions[extensionKey], &ext); err == nil {
+			e.MaxTokens = ext.MaxTokens
+		}
+
+		secondary := ce.SecondaryKeys
+		if ce.Selective == nil || !*ce.Selective {
+			secondary = nil
+		}
+		e.Regex = hasRegexKey(ce.Keys) || hasRegexKey(secondary)
+		e.Keys = modelKeys(ce.Keys, e.Regex)
+		e.SecondaryKeys = modelKeys(secondary, e.Regex)
+		out = append(out, e)
+	}
+	return out
+}
+
+// regexKey matches SillyTavern's /pattern/flags key syntax
+var regexKey = regexp.MustCompile(`^/(.+)/([a-z]*)$`)
+
+func hasRegexKey(keys []string) bool {
+	for _, k := range keys {
+		if regexKey.MatchString(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// modelKeys strips the /.../ of regex keys and escapes plain keys when the entry uses regexes
+func modelKeys(keys []string, regex bool) models.StringSlice {
+	var out models.StringSlice
+	for _, k := range keys {
+		if strings.TrimSpace(k) == "" {
+			continue
+		}
+		if !regex {
+			out = append(out, k)
+			continue
+		}
+		m := regexKey.FindStringSubmatch(k)
+		if m == nil {
+			out = append(out, regexp.QuoteMeta(k))
+			continue
+		}
+		if strings.Contains(m[2], "i") {
+			out = append(out, "(?i)"+m[1])
+		} else {
+			out = append(out, m[1])
+		}
+	}
+	return out
+}
+
+// cardKeys writes regex keys back in /pattern/flags form
+func cardKeys(keys []string, regex bool) []string {
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if regex {
+			if rest, ok := strings.CutPrefix(k, "(?i)"); ok {
+				k = "/" + rest + "/i"
+			} else {
+				k = "/" + k + "/"
+			}
+		}
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,116 @@
+package charcard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// cardKeyword is the tEXt keyword under which card JSON is embedded (base64 encoded)
+const cardKeyword = "chara"
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// ExtractFromPNG returns the card JSON embedded in a PNG's "chara" tEXt chunk
+func ExtractFromPNG(data []byte) ([]byte, error) {
+	chunks, err := readChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		if c.typ != "tEXt" {
+			continue
+		}
+		keyword, text, ok := bytes.Cut(c.data, []byte{0})
+		if !ok || string(keyword) != cardKeyword {
+			continue
+		}
+		return base64.StdEncoding.DecodeString(string(text))
+	}
+	return nil, errors.New("PNG has no embedded character card")
+}
+
+// EmbedInPNG writes card JSON into the PNG as a "chara" tEXt chunk, replacing any existing one
+func EmbedInPNG(img []byte, cardJSON []byte) ([]byte, error) {
+	chunks, err := readChunks(img)
+	if err != nil {
+		return nil, err
+	}
+
+	text := append([]byte(cardKeyword+"\x00"), base64.StdEncoding.EncodeToString(cardJSON)...)
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	for _, c := range chunks {
+		if c.typ == "tEXt" && bytes.HasPrefix(c.data, []byte(cardKeyword+"\x00")) {
+			continue
+		}
+		if c.typ == "IEND" {
+			writeChunk(&out, pngChunk{typ: "tEXt", data: text})
+		}
+		writeChunk(&out, c)
+	}
+	return out.Bytes(), nil
+}
+
+// PlaceholderPNG renders a small solid avatar for characters that have no image yet
+func PlaceholderPNG() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 600))
+	fill := color.RGBA{R: 0x7D, G: 0x56, B: 0xF4, A: 0xFF}
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 400; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func readChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG file")
+	}
+	var chunks []pngChunk
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		// Compare before adding: a huge length would overflow an int on 32-bit platforms
+		length := binary.BigEndian.Uint32(data[pos : pos+4])
+		if rest := len(data) - pos - 12; rest < 0 || uint64(length) > uint64(rest) {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		typ := string(data[pos+4 : pos+8])
+		end := pos + 8 + int(length) + 4
+		chunks = append(chunks, pngChunk{typ: typ, data: data[pos+8 : pos+8+int(length)]})
+		pos = end
+		if typ == "IEND" {
+			return chunks, nil
+		}
+	}
+	return nil, errors.New("PNG is missing IEND chunk")
+}
+
+func writeChunk(buf *bytes.Buffer, c pngChunk) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(c.data)))
+	copy(header[4:], c.typ)
+	buf.Write(header[:])
+	buf.Write(c.data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(c.data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	buf.Write(sum[:])
+}
@@ -0,0 +1,38 @@
+package charcard
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestEmbedAndExtract(t *testing.T) {
+	card := []byte(`{"spec":"chara_card_v2","data":{"name":"Aoi"}}`)
+	img, err := EmbedInPNG(PlaceholderPNG(), card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Embedding again replaces the card instead of adding a second one
+	if img, err = EmbedInPNG(img, card); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ExtractFromPNG(img)
+	if err != nil || string(got) != string(card) {
+		t.Fatalf("extracted %q, %v", got, err)
+	}
+}
+
+func TestReadChunksRejectsBadLengths(t *testing.T) {
+	img := PlaceholderPNG()
+	first := len(pngSignature)
+	for _, length := range []uint32{0xFFFFFFFF, 0x80000000, uint32(len(img))} {
+		bad := append([]byte(nil), img...)
+		binary.BigEndian.PutUint32(bad[first:first+4], length)
+		if _, err := readChunks(bad); err == nil {
+			t.Errorf("chunk length %#x accepted", length)
+		}
+	}
+	// A chunk header without room for its CRC
+	if _, err := readChunks(append(append([]byte(nil), pngSignature...), 0, 0, 0, 0, 'I', 'E', 'N', 'D')); err == nil {
+		t.Error("chunk without CRC accepted")
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"ai-companion-cli-go/internal/models"
 	"github.com/joho/godotenv"
@@ -13,6 +14,56 @@ type AppConfig struct {
 	APIKey       string
 	DBPath       string
 	ModelProfile models.ModelProfile
+
+	BackupDir        string // empty means "backups" next to the database
+	BackupKeep       int    // number of snapshots retained
+	BackupEveryTurns int    // 0 disables turn-based snapshots
+
+	ProactiveEveryMinutes int // how often companions consider messaging first; 0 disables it
+
+	LogLevel  string // debug, info, warn, error
+	LogFormat string // text or json
+	LogFile   string // empty means stderr (discarded while the chat UI is open)
+	TraceKeep int    // newest turn traces retained; 0 disables tracing
+
+	PriceTableFile       string  // JSON overrides for per-model prices
+	BudgetDailyUSD       float64 // 0 means no cap
+	BudgetMonthlyUSD     float64 // 0 means no cap
+	BudgetAction         string  // block or downgrade once a cap is reached
+	BudgetDowngradeModel string  // model used after a cap when downgrading
+
+	PromptDir    string // directory overriding the embedded prompt templates
+	PromptLocale string // locale for characters without their own, e.g. en or zh-CN
+
+	StageTableFile string // JSON overrides for the intimacy stage table
+
+	LorebookScanDepth   int // recent messages matched against lorebook keys
+	LorebookTokenBudget int // tokens of lore inserted per prompt; 0 means no cap
+
+	UserName      string // how characters call the user in greetings and example dialogues
+	ExampleBudget int    // tokens of example dialogue per prompt; 0 means no cap
+
+	AnchorDir   string // directory relative AnchorRefs are resolved in; empty means the working directory
+	AnchorCheck string // off, rules or llm: how replies are checked against anchored facts
+
+	ImageModel    string // model for portraits and selfies
+	ImageSize     string // e.g. 1024x1024
+	ImageBaseURL  string // separate images endpoint; empty uses the chat endpoint
+	ImageProtocol string // auto, kitty, iterm, sixel or text: how pictures are drawn in the terminal
+
+	Vision           string // auto, on or off: whether attached pictures are sent to the chat model
+	AttachmentBudget int    // tokens of an attached text file inlined into the prompt
+
+	TranscriptionModel string // speech-to-text model for voice input
+	SpeechModel        string // text-to-speech model for spoken replies
+	SpeechVoice        string // voice of characters without their own
+	SpeechFormat       string // mp3, wav, opus, aac or flac
+	AudioBaseURL       string // separate audio endpoint; empty uses the chat endpoint
+	RecordCommand      string // microphone recorder writing a WAV to {file}; auto detects arecord or sox
+	PlayCommand        string // audio player for {file}; auto detects afplay, ffplay, mpv, ...
+	VoiceReplies       string // on or off: whether the chat reads replies aloud from the start
+
+	Tools string // all, off, or a comma-separated list of the tools characters may call while replying
 }
 
 // LoadConfig reads from .env and Env vars
@@ -36,14 +87,99 @@ func LoadConfig() *AppConfig {
 	if m := os.Getenv("PRIMARY_MODEL"); m != "" {
 		modelConfig.PrimaryModel = m
 	}
-
-	if apiKey == "" {
-		log.Println("WARNING: OPENAI_API_KEY is not set. Chat features will error out until it is configured.")
+	if m := os.Getenv("FALLBACK_MODEL"); m != "" {
+		modelConfig.FallbackModel = m
 	}
+	modelConfig.BaseURL = os.Getenv("OPENAI_BASE_URL")
 
 	return &AppConfig{
-		APIKey:       apiKey,
-		DBPath:       dbPath,
-		ModelProfile: modelConfig,
+		APIKey:           apiKey,
+		DBPath:           dbPath,
+		ModelProfile:     modelConfig,
+		BackupDir:        os.Getenv("BACKUP_DIR"),
+		BackupKeep:       envInt("BACKUP_KEEP", 10),
+		BackupEveryTurns: envInt("BACKUP_EVERY_TURNS", 50),
+
+		ProactiveEveryMinutes: envInt("PROACTIVE_EVERY_MINUTES", 15),
+
+		LogLevel:  envString("LOG_LEVEL", "warn"),
+		LogFormat: envString("LOG_FORMAT", "text"),
+		LogFile:   os.Getenv("LOG_FILE"),
+		TraceKeep: envInt("TRACE_KEEP", 500),
+
+		PriceTableFile:       os.Getenv("PRICE_TABLE_FILE"),
+		BudgetDailyUSD:       envFloat("BUDGET_DAILY_USD", 0),
+		BudgetMonthlyUSD:     envFloat("BUDGET_MONTHLY_USD", 0),
+		BudgetAction:         envString("BUDGET_ACTION", "downgrade"),
+		BudgetDowngradeModel: envString("BUDGET_DOWNGRADE_MODEL", modelConfig.FallbackModel),
+
+		PromptDir:    os.Getenv("PROMPT_DIR"),
+		PromptLocale: envString("PROMPT_LOCALE", "en"),
+
+		StageTableFile: os.Getenv("STAGE_TABLE_FILE"),
+
+		LorebookScanDepth:   envInt("LOREBOOK_SCAN_DEPTH", 4),
+		LorebookTokenBudget: envInt("LOREBOOK_TOKEN_BUDGET", 800),
+
+		UserName:      os.Getenv("USER_NAME"),
+		ExampleBudget: envInt("EXAMPLE_TOKEN_BUDGET", 600),
+
+		AnchorDir:   os.Getenv("ANCHOR_DIR"),
+		AnchorCheck: envString("ANCHOR_CHECK", "rules"),
+
+		ImageModel:    envString("IMAGE_MODEL", "dall-e-3"),
+		ImageSize:     envString("IMAGE_SIZE", "1024x1024"),
+		ImageBaseURL:  os.Getenv("IMAGE_BASE_URL"),
+		ImageProtocol: envString("IMAGE_PROTOCOL", "auto"),
+
+		Vision:           envString("VISION", "auto"),
+		AttachmentBudget: envInt("ATTACHMENT_TOKEN_BUDGET", 2000),
+
+		TranscriptionModel: envString("TRANSCRIPTION_MODEL", "whisper-1"),
+		SpeechModel:        envString("SPEECH_MODEL", "tts-1"),
+		SpeechVoice:        envString("SPEECH_VOICE", "alloy"),
+		SpeechFormat:       envString("SPEECH_FORMAT", "mp3"),
+		AudioBaseURL:       os.Getenv("AUDIO_BASE_URL"),
+		RecordCommand:      envString("RECORD_COMMAND", "auto"),
+		PlayCommand:        envString("PLAY_COMMAND", "auto"),
+		VoiceReplies:       envString("VOICE_REPLIES", "off"),
+
+		Tools: envString("TOOLS", "all"),
+	}
+}
+
+// envString reads an env var, falling back to def when unset
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt reads an integer env var, falling back to def when unset or malformed
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("WARNING: %s=%q is not a number, using %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+// envFloat reads a decimal env var, falling back to def when unset or malformed
+func envFloat(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("WARNING: %s=%q is not a number, using %g", key, v, def)
+		return def
 	}
+	return f
 }
@@ -0,0 +1,139 @@
+// Package dialogue holds a character's greetings and example dialogues, stored in ProfileJSON in the
+// Character Card layout (first_mes, alternate_greetings, mes_example)
+package dialogue
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"strings"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/tokens"
+)
+
+// ProfileJSON keys, shared with Character Card V2 import and export
+const (
+	GreetingKey           = "first_mes"
+	AlternateGreetingsKey = "alternate_greetings"
+	ExamplesKey           = "mes_example"
+)
+
+// Speakers of an example line
+const (
+	SpeakerUser = "user"
+	SpeakerChar = "char"
+)
+
+// Line is one utterance of an example exchange
+type Line struct {
+	Speaker string // user or char
+	Text    string
+}
+
+// Example is one few-shot exchange
+type Example []Line
+
+// Greetings returns the first greeting followed by the alternate ones
+func Greetings(profile *models.CharacterProfile) []string {
+	var out []string
+	if s, _ := profile.ProfileJSON[GreetingKey].(string); strings.TrimSpace(s) != "" {
+		out = append(out, s)
+	}
+	switch alts := profile.ProfileJSON[AlternateGreetingsKey].(type) {
+	case []string: // set in memory, e.g. by a card import
+		for _, s := range alts {
+			if strings.TrimSpace(s) != "" {
+				out = append(out, s)
+			}
+		}
+	case []interface{}: // read back from the database
+		for _, a := range alts {
+			if s, _ := a.(string); strings.TrimSpace(s) != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// PickGreeting chooses one of the character's greetings at random, or "" when it has none
+func PickGreeting(profile *models.CharacterProfile) string {
+	greetings := Greetings(profile)
+	if len(greetings) == 0 {
+		return ""
+	}
+	return greetings[rand.IntN(len(greetings))]
+}
+
+// Examples parses the character's example dialogues
+func Examples(profile *models.CharacterProfile) []Example {
+	s, _ := profile.ProfileJSON[ExamplesKey].(string)
+	return Parse(s, profile.Name)
+}
+
+var (
+	startMarker = regexp.MustCompile(`(?i)<start>`)
+	userPrefix  = regexp.MustCompile(`(?i)^\s*({{user}}|<user>)\s*[:：]\s*`)
+	charPrefix  = regexp.MustCompile(`(?i)^\s*({{char}}|<bot>)\s*[:：]\s*`)
+)
+
+// Parse reads mes_example text: exchanges separated by <START>, lines prefixed with {{user}}: or
+// {{char}}: (also <USER>:, <BOT>: or the character's name). Unprefixed lines continue the previous one.
+func Parse(text, charName string) []Example {
+	var out []Example
+	for _, block := range startMarker.Split(text, -1) {
+		var ex Example
+		for _, raw := range strings.Split(block, "\n") {
+			line := strings.TrimRight(raw, " \t\r")
+			speaker, rest := "", line
+			switch {
+			case userPrefix.MatchString(line):
+				speaker, rest = SpeakerUser, userPrefix.ReplaceAllString(line, "")
+			case charPrefix.MatchString(line):
+				speaker, rest = SpeakerChar, charPrefix.ReplaceAllString(line, "")
+			case charName != "" && hasNamePrefix(line, charName):
+				speaker, rest = SpeakerChar, strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line)[len(charName):], ":："))
+			}
+			switch {
+			case speaker != "":
+				ex = append(ex, Line{Speaker: speaker, Text: rest})
+			case len(ex) > 0 && strings.TrimSpace(line) != "":
+				ex[len(ex)-1].Text += "\n" + line
+			}
+		}
+		if len(ex) > 0 {
+			out = append(out, ex)
+		}
+	}
+	return out
+}
+
+func hasNamePrefix(line, name string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, name+":") || strings.HasPrefix(line, name+"：")
+}
+
+// Fit keeps whole exchanges, in order, until they would exceed budget tokens (0 keeps everything)
+func Fit(examples []Example, budget int) []Example {
+	if budget <= 0 {
+		return examples
+	}
+	used := 0
+	for i, ex := range examples {
+		for _, l := range ex {
+			used += tokens.Estimate(l.Text) + 2 // speaker label
+		}
+		if used > budget {
+			return examples[:i]
+		}
+	}
+	return examples
+}
+
+// Expand replaces the card macros {{char}}, {{user}}, <BOT> and <USER>
+func Expand(text, charName, userName string) string {
+	return strings.NewReplacer(
+		"{{char}}", charName, "{{Char}}", charName, "<BOT>", charName,
+		"{{user}}", userName, "{{User}}", userName, "<USER>", userName,
+	).Replace(text)
+}
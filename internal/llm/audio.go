@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// AudioOptions configures speech-to-text and text-to-speech
+type AudioOptions struct {
+	TranscriptionModel string // e.g. whisper-1
+	SpeechModel        string // e.g. tts-1
+	Voice              string // default voice for characters without their own, e.g. alloy
+	Format             string // speech file format: mp3, wav, opus, aac or flac
+	BaseURL            string // separate /audio endpoint; empty uses the chat endpoint
+}
+
+// Speech is a synthesized reply
+type Speech struct {
+	Data     []byte
+	MimeType string
+	Model    string
+	Voice    string
+}
+
+// maxSpeechBytes caps the audio read back from /audio/speech
+const maxSpeechBytes = 25 << 20
+
+// speechTypes are the MIME types of the speech formats
+var speechTypes = map[string]string{
+	"mp3": "audio/mpeg", "wav": "audio/wav", "opus": "audio/ogg", "aac": "audio/aac", "flac": "audio/flac", "pcm": "audio/L16",
+}
+
+// SetAudio configures the models and endpoint used by Transcribe and Speak
+func (c *Client) SetAudio(opts AudioOptions) {
+	c.audio = opts
+}
+
+// AudioOptions returns the speech settings
+func (c *Client) AudioOptions() AudioOptions {
+	return c.audio
+}
+
+// audioClient is the client for the /audio endpoints
+func (c *Client) audioClient() *openai.Client {
+	if c.audio.BaseURL != "" {
+		return newOpenAIClient(c.apiKey, c.audio.BaseURL)
+	}
+	return c.client
+}
+
+// Transcribe turns a recording into text with an OpenAI-compatible /audio/transcriptions endpoint.
+// filename only tells the endpoint the format, e.g. voice.wav.
+func (c *Client) Transcribe(ctx context.Context, filename string, data []byte) (string, error) {
+	if err := c.EnsureConfigured(); err != nil {
+		return "", err
+	}
+	if c.audio.TranscriptionModel == "" {
+		return "", fmt.Errorf("speech recognition is not configured (set TRANSCRIPTION_MODEL)")
+	}
+	if _, err := c.admit(ctx, c.audio.TranscriptionModel); err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	resp, err := c.audioClient().CreateTranscription(ctx, openai.AudioRequest{
+		Model:    c.audio.TranscriptionModel,
+		FilePath: filename,
+		Reader:   bytes.NewReader(data),
+	})
+	observeCall("transcription", StreamStats{Model: c.audio.TranscriptionModel}, time.Since(start).Seconds(), err)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Text), nil
+}
+
+// Speak reads text aloud with an OpenAI-compatible /audio/speech endpoint; an empty voice uses the default.
+// Like images, speech is subject to budgets but not accounted since it reports no tokens.
+func (c *Client) Speak(ctx context.Context, text, voice string) (*Speech, error) {
+	if err := c.EnsureConfigured(); err != nil {
+		return nil, err
+	}
+	if c.audio.SpeechModel == "" {
+		return nil, fmt.Errorf("speech synthesis is not configured (set SPEECH_MODEL)")
+	}
+	if voice == "" {
+		voice = c.audio.Voice
+	}
+	if _, err := c.admit(ctx, c.audio.SpeechModel); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	stats := StreamStats{Model: c.audio.SpeechModel}
+	resp, err := c.audioClient().CreateSpeech(ctx, openai.CreateSpeechRequest{
+		Model:          openai.SpeechModel(c.audio.SpeechModel),
+		Input:          text,
+		Voice:          openai.SpeechVoice(voice),
+		ResponseFormat: openai.SpeechResponseFormat(c.audio.Format),
+	})
+	if err != nil {
+		observeCall("speech", stats, time.Since(start).Seconds(), err)
+		return nil, err
+	}
+	defer resp.Close()
+	// Read one byte past the cap so overlong audio fails instead of being cut off
+	data, err := io.ReadAll(io.LimitReader(resp, maxSpeechBytes+1))
+	if err == nil && len(data) > maxSpeechBytes {
+		err = fmt.Errorf("longer than %d MB", maxSpeechBytes>>20)
+	}
+	observeCall("speech", stats, time.Since(start).Seconds(), err)
+	if err != nil {
+		return nil, fmt.Errorf("read speech: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("speech endpoint returned no audio")
+	}
+
+	mimeType := speechTypes[c.audio.Format]
+	if mimeType == "" {
+		mimeType = speechTypes["mp3"]
+	}
+	if detected := http.DetectContentType(data); strings.HasPrefix(detected, "audio/") {
+		mimeType = detected
+	}
+	return &Speech{Data: data, MimeType: mimeType, Model: c.audio.SpeechModel, Voice: voice}, nil
+}
@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// audioServer fakes /v1/audio/transcriptions and /v1/audio/speech; speech answers with the given bytes
+type audioServer struct {
+	*httptest.Server
+	speech []byte
+
+	mu            sync.Mutex
+	transcription map[string]string // form fields and the uploaded file's name and content
+	speechReq     openai.CreateSpeechRequest
+}
+
+func newAudioServer(t *testing.T, speech []byte) *audioServer {
+	t.Helper()
+	s := &audioServer{speech: speech}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *audioServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/v1/audio/transcriptions":
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.transcription = map[string]string{"model": r.FormValue("model")}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		s.transcription["filename"], s.transcription["data"] = header.Filename, string(data)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"text": "  hello there \n"})
+	case "/v1/audio/speech":
+		if err := json.NewDecoder(r.Body).Decode(&s.speechReq); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(s.speech)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func audioClient(s *audioServer, opts AudioOptions) *Client {
+	c := NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-test", BaseURL: s.URL + "/v1"})
+	c.SetAudio(opts)
+	return c
+}
+
+func TestTranscribe(t *testing.T) {
+	s := newAudioServer(t, nil)
+	c := audioClient(s, AudioOptions{TranscriptionModel: "whisper-1"})
+
+	text, err := c.Transcribe(context.Background(), "voice.wav", []byte("RIFF-recording"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "hello there" {
+		t.Fatalf("text = %q, want it trimmed", text)
+	}
+	got := s.transcription
+	if got["model"] != "whisper-1" || got["filename"] != "voice.wav" || got["data"] != "RIFF-recording" {
+		t.Fatalf("upload = %v", got)
+	}
+
+	if _, err := audioClient(s, AudioOptions{}).Transcribe(context.Background(), "voice.wav", nil); err == nil || !strings.Contains(err.Error(), "TRANSCRIPTION_MODEL") {
+		t.Fatalf("err = %v, want unconfigured transcription to be reported", err)
+	}
+}
+
+func TestSpeak(t *testing.T) {
+	wav := []byte("RIFF\x24\x00\x00\x00WAVEfmt \x10\x00\x00\x00")
+	tests := []struct {
+		name, format string
+		data         []byte
+		want         string
+	}{
+		{"format when undetectable", "opus", []byte("OggS\x00\x02 opus"), "audio/ogg"},
+		{"mp3 without a format", "", []byte{0x01, 0x02, 0x03}, "audio/mpeg"},
+		{"unknown format", "xyz", []byte{0x01, 0x02, 0x03}, "audio/mpeg"},
+		{"detected over format", "mp3", wav, "audio/wave"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newAudioServer(t, tt.data)
+			c := audioClient(s, AudioOptions{SpeechModel: "tts-1", Voice: "alloy", Format: tt.format})
+
+			sp, err := c.Speak(context.Background(), "hi", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sp.MimeType != tt.want || string(sp.Data) != string(tt.data) {
+				t.Fatalf("speech = %s, %d bytes; want %s", sp.MimeType, len(sp.Data), tt.want)
+			}
+			if sp.Voice != "alloy" || s.speechReq.Voice != "alloy" || s.speechReq.Input != "hi" || string(s.speechReq.ResponseFormat) != tt.format {
+				t.Fatalf("request = %+v, want the default voice and the configured format", s.speechReq)
+			}
+		})
+	}
+}
+
+func TestSpeakErrors(t *testing.T) {
+	s := newAudioServer(t, nil)
+	if _, err := audioClient(s, AudioOptions{SpeechModel: "tts-1"}).Speak(context.Background(), "hi", "nova"); err == nil || !strings.Contains(err.Error(), "no audio") {
+		t.Fatalf("err = %v, want empty audio to be rejected", err)
+	}
+
+	s = newAudioServer(t, make([]byte, maxSpeechBytes+1))
+	if _, err := audioClient(s, AudioOptions{SpeechModel: "tts-1"}).Speak(context.Background(), "hi", "nova"); err == nil || !strings.Contains(err.Error(), "longer than") {
+		t.Fatalf("err = %v, want overlong audio to be rejected", err)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
 
 	"ai-companion-cli-go/internal/models"
 	"github.com/sashabaranov/go-openai"
@@ -12,25 +16,81 @@ import (
 // Client is a wrapper around go-openai for streaming chat completions
 type Client struct {
 	client       *openai.Client
+	apiKey       string
 	modelProfile models.ModelProfile
+	meter        Meter
+	images       ImageOptions // see SetImages
+	audio        AudioOptions // see SetAudio
+	streamUsage  atomic.Int32 // whether the endpoint takes stream_options, see openStream
 }
 
 // NewClient creates a new configured wrapper
 func NewClient(apiKey string, profile models.ModelProfile) *Client {
-	var c *openai.Client
+	c := &Client{apiKey: apiKey, modelProfile: profile}
 	if apiKey != "" {
-		c = openai.NewClient(apiKey)
+		c.client = newOpenAIClient(apiKey, profile.BaseURL)
 	}
+	return c
+}
 
-	return &Client{
-		client:       c,
-		modelProfile: profile,
+// newOpenAIClient builds a go-openai client, pointing it at a compatible endpoint when baseURL is set
+func newOpenAIClient(apiKey, baseURL string) *openai.Client {
+	cfg := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		cfg.BaseURL = baseURL
 	}
+	return openai.NewClientWithConfig(cfg)
 }
 
 // SetAPIKey dynamically updates the API key in the client (useful when user inputs in TUI)
 func (c *Client) SetAPIKey(apiKey string) {
-	c.client = openai.NewClient(apiKey)
+	c.apiKey = apiKey
+	c.client = newOpenAIClient(apiKey, c.modelProfile.BaseURL)
+}
+
+// SetMeter installs usage accounting and budget enforcement for every call
+func (c *Client) SetMeter(m Meter) {
+	c.meter = m
+}
+
+// admit lets the meter veto or downgrade the model of a call
+func (c *Client) admit(ctx context.Context, model string) (string, error) {
+	if c.meter == nil {
+		return model, nil
+	}
+	admitted, err := c.meter.Admit(ctx, model)
+	if err != nil {
+		errorsTotal.Inc(model, "rejected")
+	}
+	return admitted, err
+}
+
+// record reports a finished call to the meter
+func (c *Client) record(ctx context.Context, stats StreamStats) {
+	if c.meter != nil && stats.TotalTokens > 0 {
+		c.meter.Record(ctx, stats)
+	}
+}
+
+// ModelProfile returns the model settings the client was configured with
+func (c *Client) ModelProfile() models.ModelProfile {
+	return c.modelProfile
+}
+
+// ListModels asks the endpoint which models it serves (used as a connectivity check)
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	if err := c.EnsureConfigured(); err != nil {
+		return nil, err
+	}
+	list, err := c.client.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(list.Models))
+	for _, m := range list.Models {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
 }
 
 // EnsureConfigured checks if an API key has been set
@@ -41,10 +101,36 @@ func (c *Client) EnsureConfigured() error {
 	return nil
 }
 
-// StreamChat starts a streaming inference and returns a chan of tokens
-func (c *Client) StreamChat(ctx context.Context, messages []openai.ChatCompletionMessage, preTemperature float32) (<-chan string, <-chan error) {
+// StreamStats describes a finished stream. It is filled in by the time the error channel is closed.
+type StreamStats struct {
+	Model            string // model that produced the answer
+	FallbackFrom     string // primary model that failed before the fallback took over
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	ToolCalls        []openai.ToolCall // tools the model called instead of (or after) answering, see StreamChatTools
+}
+
+// StreamChat starts a streaming inference and returns a chan of tokens.
+// If the primary model cannot be reached the configured fallback model is tried once.
+// stats may be nil.
+func (c *Client) StreamChat(ctx context.Context, messages []openai.ChatCompletionMessage, preTemperature float32, stats *StreamStats) (<-chan string, <-chan error) {
+	return c.StreamChatTools(ctx, messages, nil, preTemperature, stats)
+}
+
+// StreamChatTools is StreamChat offering the model tools; the calls it makes are collected in stats.ToolCalls
+func (c *Client) StreamChatTools(
+	ctx context.Context,
+	messages []openai.ChatCompletionMessage,
+	tools []openai.Tool,
+	preTemperature float32,
+	stats *StreamStats,
+) (<-chan string, <-chan error) {
 	tokenChan := make(chan string)
 	errChan := make(chan error, 1)
+	if stats == nil {
+		stats = &StreamStats{}
+	}
 
 	if err := c.EnsureConfigured(); err != nil {
 		errChan <- err
@@ -53,11 +139,20 @@ func (c *Client) StreamChat(ctx context.Context, messages []openai.ChatCompletio
 		return tokenChan, errChan
 	}
 
+	model, err := c.admit(ctx, c.modelProfile.PrimaryModel)
+	if err != nil {
+		errChan <- err
+		close(errChan)
+		close(tokenChan)
+		return tokenChan, errChan
+	}
+
 	req := openai.ChatCompletionRequest{
-		Model:       c.modelProfile.PrimaryModel,
+		Model:       model,
 		Messages:    messages,
 		Stream:      true,
 		Temperature: preTemperature,
+		Tools:       tools,
 	}
 
 	// Execute stream asynchronously
@@ -65,24 +160,74 @@ func (c *Client) StreamChat(ctx context.Context, messages []openai.ChatCompletio
 		defer close(tokenChan)
 		defer close(errChan)
 
-		stream, err := c.client.CreateChatCompletionStream(ctx, req)
+		start := time.Now()
+		log := slog.With("component", "llm", "model", req.Model)
+		log.Debug("chat stream request", "messages", len(messages), "tools", len(tools), "temperature", preTemperature)
+
+		activeStreams.Inc()
+		defer activeStreams.Dec()
+		var callErr error
+		defer func() { observeCall("stream", *stats, time.Since(start).Seconds(), callErr) }()
+
+		// A model the budget downgraded to has no fallback: the fallback would spend past the budget
+		// and a refused request would be refused by the fallback as well
+		downgraded := req.Model != c.modelProfile.PrimaryModel
+		stream, err := c.openStream(ctx, req)
+		if err != nil && ctx.Err() == nil && !downgraded && !IsRefusal(err) && c.canFallback(req.Model) {
+			log.Warn("primary model failed, trying fallback", "fallback", c.modelProfile.FallbackModel, "err", err)
+			errorsTotal.Inc(req.Model, errorClass(err))
+			fallbacksTotal.Inc(req.Model, c.modelProfile.FallbackModel)
+			stats.FallbackFrom = req.Model
+			req.Model = c.modelProfile.FallbackModel
+			log = slog.With("component", "llm", "model", req.Model)
+			stream, err = c.openStream(ctx, req)
+		}
+		stats.Model = req.Model
 		if err != nil {
+			log.Error("chat stream failed", "err", err)
+			callErr = err
 			errChan <- err
 			return
 		}
 		defer stream.Close()
 
+		// usage is billed even when the stream breaks off midway
+		defer func() { c.record(ctx, *stats) }()
+
+		firstToken := true
 		for {
 			response, err := stream.Recv()
 			if errors.Is(err, io.EOF) {
+				log.Info("chat stream finished",
+					"duration_ms", time.Since(start).Milliseconds(),
+					"prompt_tokens", stats.PromptTokens,
+					"completion_tokens", stats.CompletionTokens,
+					"tool_calls", len(stats.ToolCalls))
 				return
 			}
 			if err != nil {
+				log.Error("chat stream interrupted", "err", err, "duration_ms", time.Since(start).Milliseconds())
+				callErr = err
 				errChan <- err
 				return
 			}
+			if response.Usage != nil {
+				stats.PromptTokens = response.Usage.PromptTokens
+				stats.CompletionTokens = response.Usage.CompletionTokens
+				stats.TotalTokens = response.Usage.TotalTokens
+			}
 			if len(response.Choices) > 0 {
-				tokenChan <- response.Choices[0].Delta.Content
+				delta := response.Choices[0].Delta
+				if firstToken && delta.Content != "" {
+					firstToken = false
+					firstTokenSeconds.ObserveSince(start, req.Model)
+				}
+				for _, call := range delta.ToolCalls {
+					stats.ToolCalls = mergeToolCall(stats.ToolCalls, call)
+				}
+				if delta.Content != "" || len(delta.ToolCalls) == 0 {
+					tokenChan <- delta.Content
+				}
 			}
 		}
 	}()
@@ -90,14 +235,75 @@ func (c *Client) StreamChat(ctx context.Context, messages []openai.ChatCompletio
 	return tokenChan, errChan
 }
 
+// Whether the endpoint takes stream_options
+const (
+	streamUsageUnknown int32 = iota
+	streamUsageOn
+	streamUsageOff
+)
+
+// openStream starts a stream asking for the usage in its last chunk. Some compatible endpoints refuse
+// stream_options outright, so until one such stream has gone through a refusal is retried once without
+// them; when the retry passes they are left off from then on and the usage goes unrecorded.
+func (c *Client) openStream(ctx context.Context, req openai.ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
+	state := c.streamUsage.Load()
+	if state == streamUsageOff {
+		return c.client.CreateChatCompletionStream(ctx, req)
+	}
+	withUsage := req
+	withUsage.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := c.client.CreateChatCompletionStream(ctx, withUsage)
+	if err == nil {
+		c.streamUsage.Store(streamUsageOn)
+		return stream, nil
+	}
+	if state == streamUsageOn || !IsRefusal(err) || ctx.Err() != nil {
+		return nil, err
+	}
+	stream, err = c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	slog.Warn("endpoint refused stream_options, streaming without usage", "component", "llm", "model", req.Model)
+	c.streamUsage.Store(streamUsageOff)
+	return stream, nil
+}
+
+// canFallback reports whether a distinct fallback model is configured
+func (c *Client) canFallback(model string) bool {
+	fb := c.modelProfile.FallbackModel
+	return fb != "" && fb != model
+}
+
+// IsRefusal reports whether the endpoint refused a request as such (400, 415 or 422), e.g. because the model
+// takes no pictures or tools. Rate limits, auth, server and network errors are not refusals: the same
+// request may well succeed later.
+func IsRefusal(err error) bool {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	status := 0
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	return status == http.StatusBadRequest || status == http.StatusUnsupportedMediaType || status == http.StatusUnprocessableEntity
+}
+
 // GenerateSync does a synchronous (non-streaming) request for background tasks
 func (c *Client) GenerateSync(ctx context.Context, systemPrompt string, userPrompt string) (string, error) {
 	if err := c.EnsureConfigured(); err != nil {
 		return "", err
 	}
 
+	model, err := c.admit(ctx, c.modelProfile.PrimaryModel)
+	if err != nil {
+		return "", err
+	}
+
 	req := openai.ChatCompletionRequest{
-		Model: c.modelProfile.PrimaryModel,
+		Model: model,
 		Messages: []openai.ChatCompletionMessage{
 			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
 			{Role: openai.ChatMessageRoleUser, Content: userPrompt},
@@ -105,10 +311,20 @@ func (c *Client) GenerateSync(ctx context.Context, systemPrompt string, userProm
 		Temperature: 0.7,
 	}
 
+	start := time.Now()
 	resp, err := c.client.CreateChatCompletion(ctx, req)
 	if err != nil {
+		observeCall("sync", StreamStats{Model: req.Model}, time.Since(start).Seconds(), err)
 		return "", err
 	}
+	stats := StreamStats{
+		Model:            req.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		TotalTokens:      resp.Usage.TotalTokens,
+	}
+	observeCall("sync", stats, time.Since(start).Seconds(), nil)
+	c.record(ctx, stats)
 
 	if len(resp.Choices) > 0 {
 		return resp.Choices[0].Message.Content, nil
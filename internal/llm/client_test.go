@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"ai-companion-cli-go/internal/llm/llmtest"
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// downgradeMeter admits every call on a cheaper model
+type downgradeMeter struct{ to string }
+
+func (m downgradeMeter) Admit(ctx context.Context, model string) (string, error) { return m.to, nil }
+func (m downgradeMeter) Record(ctx context.Context, stats StreamStats)           {}
+
+func stream(t *testing.T, c *Client) (*StreamStats, error) {
+	t.Helper()
+	stats := &StreamStats{}
+	tokens, errs := c.StreamChatTools(context.Background(), []openai.ChatCompletionMessage{{Role: "user", Content: "hi"}}, nil, 0.7, stats)
+	for range tokens {
+	}
+	return stats, <-errs
+}
+
+func TestFallbackAfterPrimaryFails(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	fake.SetReply(func(req openai.ChatCompletionRequest) llmtest.Response {
+		if req.Model == "gpt-primary" {
+			return llmtest.Response{Status: http.StatusServiceUnavailable}
+		}
+		return llmtest.Response{Content: "hi"}
+	})
+	c := NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-primary", FallbackModel: "gpt-fallback", BaseURL: fake.URL})
+
+	stats, err := stream(t, c)
+	if err != nil || stats.Model != "gpt-fallback" || stats.FallbackFrom != "gpt-primary" {
+		t.Fatalf("err = %v, stats = %+v; want the fallback to answer", err, stats)
+	}
+}
+
+func TestNoFallbackAfterBudgetDowngrade(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	fake.SetReply(func(req openai.ChatCompletionRequest) llmtest.Response {
+		return llmtest.Response{Status: http.StatusServiceUnavailable}
+	})
+	c := NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-primary", FallbackModel: "gpt-fallback", BaseURL: fake.URL})
+	c.SetMeter(downgradeMeter{to: "gpt-cheap"})
+
+	if _, err := stream(t, c); err == nil {
+		t.Fatal("a failed downgraded call succeeded")
+	}
+	reqs := fake.Requests()
+	if len(reqs) != 1 || reqs[0].Model != "gpt-cheap" {
+		t.Fatalf("sent %d requests, first to %s; want only the downgraded model", len(reqs), reqs[0].Model)
+	}
+}
+
+func TestStreamOptionsRefused(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	fake.SetReply(func(req openai.ChatCompletionRequest) llmtest.Response {
+		if req.StreamOptions != nil {
+			return llmtest.Response{Status: http.StatusBadRequest}
+		}
+		return llmtest.Response{Content: "hi"}
+	})
+	c := NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-test", FallbackModel: "gpt-fallback", BaseURL: fake.URL})
+
+	for range 2 {
+		if stats, err := stream(t, c); err != nil || stats.Model != "gpt-test" {
+			t.Fatalf("err = %v, stats = %+v; want the primary to answer without stream_options", err, stats)
+		}
+	}
+	reqs := fake.Requests()
+	if len(reqs) != 3 || reqs[0].StreamOptions == nil || reqs[1].StreamOptions != nil || reqs[2].StreamOptions != nil {
+		t.Fatalf("sent %d requests; want one with stream_options, then none", len(reqs))
+	}
+}
+
+func TestNoFallbackAfterRefusal(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	fake.SetReply(func(req openai.ChatCompletionRequest) llmtest.Response {
+		return llmtest.Response{Status: http.StatusUnprocessableEntity}
+	})
+	c := NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-primary", FallbackModel: "gpt-fallback", BaseURL: fake.URL})
+
+	if _, err := stream(t, c); !IsRefusal(err) {
+		t.Fatalf("err = %v, want the refusal", err)
+	}
+	for _, req := range fake.Requests() {
+		if req.Model != "gpt-primary" {
+			t.Fatalf("a refused request went to %s", req.Model)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ImageOptions configures image generation
+type ImageOptions struct {
+	Model   string // e.g. dall-e-3, gpt-image-1 or whatever a compatible server serves
+	Size    string // e.g. 1024x1024; empty leaves it to the endpoint
+	BaseURL string // separate /images/generations endpoint; empty uses the chat endpoint
+}
+
+// Image is a generated picture
+type Image struct {
+	Data          []byte
+	MimeType      string
+	Model         string
+	RevisedPrompt string // the prompt the endpoint actually used, when it rewrites prompts
+}
+
+// maxImageBytes caps downloads of images returned by URL
+const maxImageBytes = 20 << 20
+
+// ImageTimeout bounds one image generation; they take much longer than a chat reply
+const ImageTimeout = 3 * time.Minute
+
+// SetImages configures the model and endpoint used by GenerateImage
+func (c *Client) SetImages(opts ImageOptions) {
+	c.images = opts
+}
+
+// ImageOptions returns the image generation settings
+func (c *Client) ImageOptions() ImageOptions {
+	return c.images
+}
+
+// GenerateImage calls an OpenAI-compatible /images/generations endpoint and returns the first image.
+// Budgets can block image calls, but their cost is not accounted since they report no tokens.
+func (c *Client) GenerateImage(ctx context.Context, prompt string) (*Image, error) {
+	if err := c.EnsureConfigured(); err != nil {
+		return nil, err
+	}
+	if c.images.Model == "" {
+		return nil, fmt.Errorf("image generation is not configured (set IMAGE_MODEL)")
+	}
+	if _, err := c.admit(ctx, c.images.Model); err != nil {
+		return nil, err
+	}
+
+	client := c.client
+	if c.images.BaseURL != "" {
+		client = newOpenAIClient(c.apiKey, c.images.BaseURL)
+	}
+	req := openai.ImageRequest{
+		Prompt: prompt,
+		Model:  c.images.Model,
+		N:      1,
+		Size:   c.images.Size,
+	}
+	// gpt-image models always answer in base64 and reject the parameter
+	if !strings.HasPrefix(c.images.Model, "gpt-image") {
+		req.ResponseFormat = openai.CreateImageResponseFormatB64JSON
+	}
+
+	start := time.Now()
+	stats := StreamStats{Model: req.Model}
+	resp, err := client.CreateImage(ctx, req)
+	if err == nil && len(resp.Data) == 0 {
+		err = fmt.Errorf("image endpoint returned no image")
+	}
+	if err != nil {
+		observeCall("image", stats, time.Since(start).Seconds(), err)
+		return nil, err
+	}
+
+	img := &Image{Model: req.Model, RevisedPrompt: resp.Data[0].RevisedPrompt}
+	if b64 := resp.Data[0].B64JSON; b64 != "" {
+		img.Data, err = base64.StdEncoding.DecodeString(b64)
+	} else {
+		img.Data, err = download(ctx, resp.Data[0].URL)
+	}
+	observeCall("image", stats, time.Since(start).Seconds(), err)
+	if err != nil {
+		return nil, fmt.Errorf("read generated image: %w", err)
+	}
+	img.MimeType = http.DetectContentType(img.Data)
+	if !strings.HasPrefix(img.MimeType, "image/") {
+		return nil, fmt.Errorf("image endpoint returned %s, not an image", img.MimeType)
+	}
+	return img, nil
+}
+
+// download fetches an image some endpoints return by (short-lived) URL
+func download(ctx context.Context, url string) ([]byte, error) {
+	if url == "" {
+		return nil, fmt.Errorf("image endpoint returned neither data nor a URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	// Read one byte past the cap so an oversized image fails instead of being cut off
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image at %s is larger than %d MB", url, maxImageBytes>>20)
+	}
+	return data, nil
+}
@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var pngData = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+
+// imageServer fakes /v1/images/generations; answer gets the decoded request and the server's own URL
+func imageServer(t *testing.T, answer func(req openai.ImageRequest, base string) openai.ImageResponse) *httptest.Server {
+	t.Helper()
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/images/generations":
+			var req openai.ImageRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(answer(req, ts.URL))
+		case "/files/cat.png":
+			w.Write(pngData)
+		case "/files/huge.png":
+			w.Write(pngData)
+			w.Write(make([]byte, maxImageBytes))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func imageClient(ts *httptest.Server, model string) *Client {
+	c := NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-test", BaseURL: ts.URL + "/v1"})
+	c.SetImages(ImageOptions{Model: model, Size: "1024x1024"})
+	return c
+}
+
+func TestGenerateImageBase64(t *testing.T) {
+	var got openai.ImageRequest
+	ts := imageServer(t, func(req openai.ImageRequest, _ string) openai.ImageResponse {
+		got = req
+		return openai.ImageResponse{Data: []openai.ImageResponseDataInner{{
+			B64JSON: base64.StdEncoding.EncodeToString(pngData), RevisedPrompt: "a cat, watercolor",
+		}}}
+	})
+
+	img, err := imageClient(ts, "dall-e-3").GenerateImage(context.Background(), "a cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(img.Data, pngData) || img.MimeType != "image/png" || img.RevisedPrompt != "a cat, watercolor" || img.Model != "dall-e-3" {
+		t.Fatalf("image = %s %s %q, %d bytes", img.Model, img.MimeType, img.RevisedPrompt, len(img.Data))
+	}
+	if got.Prompt != "a cat" || got.Size != "1024x1024" || got.ResponseFormat != openai.CreateImageResponseFormatB64JSON {
+		t.Fatalf("request = %+v", got)
+	}
+}
+
+func TestGenerateImageURL(t *testing.T) {
+	var got openai.ImageRequest
+	ts := imageServer(t, func(req openai.ImageRequest, base string) openai.ImageResponse {
+		got = req
+		return openai.ImageResponse{Data: []openai.ImageResponseDataInner{{URL: base + "/files/cat.png"}}}
+	})
+
+	img, err := imageClient(ts, "gpt-image-1").GenerateImage(context.Background(), "a cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(img.Data, pngData) || img.MimeType != "image/png" {
+		t.Fatalf("image = %s, %d bytes", img.MimeType, len(img.Data))
+	}
+	if got.ResponseFormat != "" {
+		t.Fatalf("response_format = %q sent to a gpt-image model", got.ResponseFormat)
+	}
+}
+
+func TestGenerateImageErrors(t *testing.T) {
+	tests := []struct {
+		name, file, want string
+	}{
+		{"oversized download", "huge.png", "larger than"},
+		{"missing download", "gone.png", "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := imageServer(t, func(req openai.ImageRequest, base string) openai.ImageResponse {
+				return openai.ImageResponse{Data: []openai.ImageResponseDataInner{{URL: base + "/files/" + tt.file}}}
+			})
+			_, err := imageClient(ts, "dall-e-3").GenerateImage(context.Background(), "a cat")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	ts := imageServer(t, func(openai.ImageRequest, string) openai.ImageResponse {
+		return openai.ImageResponse{Data: []openai.ImageResponseDataInner{{B64JSON: base64.StdEncoding.EncodeToString([]byte("<html>nope</html>"))}}}
+	})
+	if _, err := imageClient(ts, "dall-e-3").GenerateImage(context.Background(), "a cat"); err == nil || !strings.Contains(err.Error(), "not an image") {
+		t.Fatalf("err = %v, want a non-image to be rejected", err)
+	}
+	if _, err := imageClient(ts, "").GenerateImage(context.Background(), "a cat"); err == nil || !strings.Contains(err.Error(), "IMAGE_MODEL") {
+		t.Fatalf("err = %v, want unconfigured image generation to be reported", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package llmtest provides a local fake of the OpenAI chat completions API for tests
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Response is what the fake answers one chat completion request with
+type Response struct {
+	Status    int    // HTTP error status; 0 answers normally
+	ErrorCode string // error code sent with Status, e.g. "unsupported_content"
+	Content   string
+	ToolCalls []openai.ToolCall
+}
+
+// Server is a fake OpenAI-compatible endpoint; point a model profile's BaseURL at URL
+type Server struct {
+	*httptest.Server
+	URL string // base URL including /v1
+
+	mu       sync.Mutex
+	requests []openai.ChatCompletionRequest
+	reply    func(req openai.ChatCompletionRequest) Response
+}
+
+// NewServer starts a fake that answers "ok" until SetReply changes it; it is closed with the test
+func NewServer(t testing.TB) *Server {
+	s := &Server{reply: func(openai.ChatCompletionRequest) Response { return Response{Content: "ok"} }}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.Server.URL + "/v1"
+	t.Cleanup(s.Close)
+	return s
+}
+
+// SetReply chooses how the following requests are answered
+func (s *Server) SetReply(reply func(req openai.ChatCompletionRequest) Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = reply
+}
+
+// Requests returns the chat completion requests received so far
+func (s *Server) Requests() []openai.ChatCompletionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/chat/completions" {
+		http.NotFound(w, r)
+		return
+	}
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	reply := s.reply
+	s.mu.Unlock()
+
+	res := reply(req)
+	if res.Status != 0 {
+		writeError(w, res.Status, res.ErrorCode, fmt.Sprintf("fake error %d", res.Status))
+		return
+	}
+	usage := openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+
+	if !req.Stream {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Model: req.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: res.Content, ToolCalls: res.ToolCalls},
+				FinishReason: openai.FinishReasonStop,
+			}},
+			Usage: usage,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	send := func(chunk openai.ChatCompletionStreamResponse) {
+		chunk.Model = req.Model
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	for _, word := range strings.SplitAfter(res.Content, " ") {
+		if word != "" {
+			send(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{
+				Delta: openai.ChatCompletionStreamChoiceDelta{Content: word},
+			}}})
+		}
+	}
+	for i, call := range res.ToolCalls {
+		index := i
+		call.Index = &index
+		send(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{
+			Delta: openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{call}},
+		}}})
+	}
+	send(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{}, Usage: &usage})
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": "invalid_request_error", "code": code},
+	})
+}
@@ -0,0 +1,41 @@
+package llm
+
+import "context"
+
+// Features a model call can be attributed to
+const (
+	FeatureChat          = "chat"
+	FeatureProactive     = "proactive"
+	FeatureConsistency   = "consistency"
+	FeatureImage         = "image"
+	FeatureTranscription = "transcription"
+	FeatureSpeech        = "speech"
+)
+
+// CallInfo attributes a model call to the character, session and feature it serves
+type CallInfo struct {
+	CharacterID string
+	SessionID   string
+	Feature     string
+}
+
+type callInfoKey struct{}
+
+// WithCall attaches attribution to a context passed to StreamChat or GenerateSync
+func WithCall(ctx context.Context, info CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+// CallFrom returns the attribution attached with WithCall
+func CallFrom(ctx context.Context) CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(CallInfo)
+	return info
+}
+
+// Meter accounts for model usage. The client asks it before every call and reports back afterwards.
+type Meter interface {
+	// Admit returns the model to use (possibly a cheaper one) or an error when a budget blocks the call
+	Admit(ctx context.Context, model string) (string, error)
+	// Record stores the usage of a finished call
+	Record(ctx context.Context, stats StreamStats)
+}
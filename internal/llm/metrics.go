@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"ai-companion-cli-go/internal/metrics"
+	"github.com/sashabaranov/go-openai"
+)
+
+var (
+	requestsTotal = metrics.NewCounterVec("companion_llm_requests_total",
+		"Model calls by model, call kind (stream, sync) and outcome (ok, error).", "model", "kind", "outcome")
+	requestDuration = metrics.NewHistogramVec("companion_llm_request_duration_seconds",
+		"Time from sending a model call until the answer was complete.", metrics.DefBuckets, "model", "kind")
+	firstTokenSeconds = metrics.NewHistogramVec("companion_llm_time_to_first_token_seconds",
+		"Time from sending a streaming call until the first token arrived.", metrics.DefBuckets, "model")
+	errorsTotal = metrics.NewCounterVec("companion_llm_errors_total",
+		"Failed model calls by model and error class.", "model", "class")
+	fallbacksTotal = metrics.NewCounterVec("companion_llm_fallbacks_total",
+		"Calls retried on the fallback model after the primary failed.", "from", "to")
+	activeStreams = metrics.NewGaugeVec("companion_llm_active_streams",
+		"Streaming calls currently in flight.")
+	tokensTotal = metrics.NewCounterVec("companion_llm_tokens_total",
+		"Tokens reported by the endpoint, by model and type (prompt, completion).", "model", "type")
+)
+
+// observeCall records the outcome of one finished model call
+func observeCall(kind string, stats StreamStats, seconds float64, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+		errorsTotal.Inc(stats.Model, errorClass(err))
+	}
+	requestsTotal.Inc(stats.Model, kind, outcome)
+	requestDuration.Observe(seconds, stats.Model, kind)
+	if stats.TotalTokens > 0 {
+		tokensTotal.Add(float64(stats.PromptTokens), stats.Model, "prompt")
+		tokensTotal.Add(float64(stats.CompletionTokens), stats.Model, "completion")
+	}
+}
+
+// errorClass buckets an error into a small, fixed set of label values
+func errorClass(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "rate_limit"
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return "auth"
+	case status >= 500:
+		return "server"
+	case status >= 400:
+		return "client"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+	return "other"
+}
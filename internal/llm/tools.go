@@ -0,0 +1,31 @@
+package llm
+
+import "github.com/sashabaranov/go-openai"
+
+// mergeToolCall adds a streamed tool call fragment to the calls assembled so far. The first fragment of
+// a call carries its ID and name, the following ones only pieces of the JSON arguments.
+func mergeToolCall(calls []openai.ToolCall, delta openai.ToolCall) []openai.ToolCall {
+	i := len(calls) - 1
+	switch {
+	case delta.Index != nil:
+		i = *delta.Index
+	case delta.ID != "" || i < 0:
+		i = len(calls)
+	}
+	for len(calls) <= i {
+		calls = append(calls, openai.ToolCall{Type: openai.ToolTypeFunction})
+	}
+
+	call := &calls[i]
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	if delta.Function.Name != "" {
+		call.Function.Name = delta.Function.Name
+	}
+	call.Function.Arguments += delta.Function.Arguments
+	return calls
+}
@@ -0,0 +1,42 @@
+package llm
+
+import "strings"
+
+// Vision settings (VISION)
+const (
+	VisionAuto = "auto" // guess from the model name
+	VisionOn   = "on"
+	VisionOff  = "off"
+)
+
+// visionModels are name fragments of chat models known to accept image input
+var visionModels = []string{
+	"gpt-4o", "gpt-4.1", "gpt-4-turbo", "gpt-4-vision", "gpt-5", "o1", "o3", "o4",
+	"claude-3", "claude-sonnet", "claude-opus", "claude-haiku", "gemini", "pixtral", "llava",
+	"vision", "-vl", "qwen2.5-vl", "glm-4v", "minicpm-v", "llama-4", "grok-2-vision",
+}
+
+// SupportsVision guesses from its name whether a model accepts images in chat messages
+func SupportsVision(model string) bool {
+	model = strings.ToLower(model)
+	if strings.HasPrefix(model, "o1-mini") || strings.HasPrefix(model, "o3-mini") {
+		return false
+	}
+	for _, fragment := range visionModels {
+		if strings.Contains(model, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// VisionEnabled resolves a VISION setting for a model
+func VisionEnabled(setting, model string) bool {
+	switch setting {
+	case VisionOn:
+		return true
+	case VisionOff:
+		return false
+	}
+	return SupportsVision(model)
+}
@@ -0,0 +1,217 @@
+// Package lorebook decides which world-info entries of a character enter the prompt for the current conversation
+package lorebook
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/tokens"
+)
+
+// Insertion positions of an entry relative to the character definition in the system prompt
+const (
+	PositionBeforeChar = "before_char"
+	PositionAfterChar  = "after_char"
+)
+
+// maxRecursion bounds how many times activated content may activate further entries
+const maxRecursion = 3
+
+// Settings control how much of the conversation is scanned and how much lore may be inserted
+type Settings struct {
+	ScanDepth   int  // number of recent messages matched against keys
+	TokenBudget int  // total tokens of inserted lore, 0 = unlimited
+	Recursive   bool // activated content is scanned for further keys
+}
+
+// For applies the per-character overrides a Character Card book carries (scan_depth, token_budget,
+// recursive_scanning, kept in ProfileJSON["character_book"])
+func (s Settings) For(profile *models.CharacterProfile) Settings {
+	book, _ := profile.ProfileJSON["character_book"].(map[string]interface{})
+	if v, ok := book["scan_depth"].(float64); ok && v > 0 {
+		s.ScanDepth = int(v)
+	}
+	if v, ok := book["token_budget"].(float64); ok && v > 0 {
+		s.TokenBudget = int(v)
+	}
+	if v, ok := book["recursive_scanning"].(bool); ok {
+		s.Recursive = v
+	}
+	return s
+}
+
+// Result is the lore to insert, already ordered, cut and split by position
+type Result struct {
+	Before    []string
+	After     []string
+	Activated []models.LorebookEntry // entries that made it into the prompt
+	Skipped   []models.LorebookEntry // entries that matched but did not fit the budget
+	Tokens    int
+}
+
+// Validate checks an entry before it is stored
+func Validate(e *models.LorebookEntry) error {
+	if strings.TrimSpace(e.Content) == "" {
+		return fmt.Errorf("lorebook entry needs content")
+	}
+	if len(e.Keys) == 0 && !e.Constant {
+		return fmt.Errorf("lorebook entry needs at least one key (or --constant)")
+	}
+	switch e.Position {
+	case "", PositionBeforeChar, PositionAfterChar:
+	default:
+		return fmt.Errorf("unknown position %q (use %s or %s)", e.Position, PositionBeforeChar, PositionAfterChar)
+	}
+	if e.Regex {
+		for _, k := range append(append([]string{}, e.Keys...), e.SecondaryKeys...) {
+			if _, err := compile(k, e.CaseSensitive); err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Scan activates the entries whose keys appear in the last ScanDepth messages (oldest first), follows
+// recursive activations and fits the result into the token budget, highest priority first
+func Scan(entries []models.LorebookEntry, messages []string, s Settings) Result {
+	if s.ScanDepth > 0 && len(messages) > s.ScanDepth {
+		messages = messages[len(messages)-s.ScanDepth:]
+	}
+	matchers := make([]*matcher, len(entries))
+	for i := range entries {
+		matchers[i] = newMatcher(&entries[i])
+	}
+
+	active := make([]bool, len(entries))
+	var hits []int
+	text := strings.Join(messages, "\n")
+	for round := 0; text != "" || round == 0; round++ {
+		var next []string
+		for i, e := range entries {
+			if active[i] || !e.Enabled || matchers[i] == nil {
+				continue
+			}
+			if (round == 0 && e.Constant) || matchers[i].match(text) {
+				active[i] = true
+				hits = append(hits, i)
+				if !e.NoRecursion {
+					next = append(next, e.Content)
+				}
+			}
+		}
+		if !s.Recursive || round >= maxRecursion {
+			break
+		}
+		text = strings.Join(next, "\n")
+	}
+
+	sort.SliceStable(hits, func(a, b int) bool {
+		ea, eb := entries[hits[a]], entries[hits[b]]
+		if ea.Priority != eb.Priority {
+			return ea.Priority > eb.Priority
+		}
+		return ea.InsertionOrder < eb.InsertionOrder
+	})
+
+	type insert struct {
+		entry   models.LorebookEntry
+		content string
+	}
+	var res Result
+	var inserts []insert
+	for _, i := range hits {
+		e := entries[i]
+		content := strings.TrimSpace(e.Content)
+		if e.MaxTokens > 0 {
+			content = tokens.Truncate(content, e.MaxTokens)
+		}
+		cost := tokens.Estimate(content)
+		if s.TokenBudget > 0 && res.Tokens+cost > s.TokenBudget {
+			res.Skipped = append(res.Skipped, e)
+			continue
+		}
+		res.Tokens += cost
+		inserts = append(inserts, insert{e, content})
+	}
+
+	sort.SliceStable(inserts, func(a, b int) bool {
+		return inserts[a].entry.InsertionOrder < inserts[b].entry.InsertionOrder
+	})
+	for _, in := range inserts {
+		res.Activated = append(res.Activated, in.entry)
+		if in.entry.Position == PositionAfterChar {
+			res.After = append(res.After, in.content)
+		} else {
+			res.Before = append(res.Before, in.content)
+		}
+	}
+	return res
+}
+
+// matcher matches the keys of one entry
+type matcher struct {
+	keys, secondary []*regexp.Regexp
+}
+
+// reportedKeys holds the broken keys already logged, so an entry is reported once rather than on every turn
+var reportedKeys sync.Map
+
+// newMatcher compiles an entry's keys; plain keys match as substrings. Entries with a broken regex never
+// match: imports keep them (Validate only guards the CLI), so the first scan logs why.
+func newMatcher(e *models.LorebookEntry) *matcher {
+	m := &matcher{}
+	for _, list := range []struct {
+		keys []string
+		out  *[]*regexp.Regexp
+	}{{e.Keys, &m.keys}, {e.SecondaryKeys, &m.secondary}} {
+		for _, k := range list.keys {
+			if strings.TrimSpace(k) == "" {
+				continue
+			}
+			pattern := k
+			if !e.Regex {
+				pattern = regexp.QuoteMeta(strings.TrimSpace(k))
+			}
+			re, err := compile(pattern, e.CaseSensitive)
+			if err != nil {
+				if _, seen := reportedKeys.LoadOrStore(fmt.Sprintf("%d %s", e.ID, k), true); !seen {
+					slog.Warn("lorebook entry disabled by a bad key", "component", "lorebook",
+						"character_id", e.CharacterID, "entry_id", e.ID, "name", e.Name, "key", k, "err", err)
+				}
+				return nil
+			}
+			*list.out = append(*list.out, re)
+		}
+	}
+	return m
+}
+
+func compile(pattern string, caseSensitive bool) (*regexp.Regexp, error) {
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// match reports whether any key and, when there are secondary keys, any secondary key occurs in text
+func (m *matcher) match(text string) bool {
+	if text == "" || !anyMatch(m.keys, text) {
+		return false
+	}
+	return len(m.secondary) == 0 || anyMatch(m.secondary, text)
+}
+
+func anyMatch(res []*regexp.Regexp, text string) bool {
+	for _, re := range res {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package lorebook
+
+import (
+	"slices"
+	"testing"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/tokens"
+)
+
+// entry is an enabled entry named after its content, activated by keys
+func entry(content string, keys ...string) models.LorebookEntry {
+	return models.LorebookEntry{Name: content, Content: content, Keys: keys, Enabled: true}
+}
+
+// names lists the entries by name
+func names(entries []models.LorebookEntry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Name)
+	}
+	return out
+}
+
+func TestScan(t *testing.T) {
+	with := func(e models.LorebookEntry, edit func(*models.LorebookEntry)) models.LorebookEntry {
+		edit(&e)
+		return e
+	}
+	// chain activates each other in turn: "link 0" is found in the message, its content names "link 1", ...
+	chain := []models.LorebookEntry{
+		entry("then link 1", "link 0"),
+		entry("then link 2", "link 1"),
+		entry("then link 3", "link 2"),
+		entry("then link 4", "link 3"),
+		entry("the end", "link 4"),
+	}
+	big, small := "龙龙龙龙龙龙龙龙龙龙", "猫猫猫猫猫"
+
+	tests := []struct {
+		name      string
+		entries   []models.LorebookEntry
+		messages  []string
+		settings  Settings
+		activated []string // by name, in insertion order
+		skipped   []string
+	}{
+		{
+			name:      "plain key ignores case",
+			entries:   []models.LorebookEntry{entry("the castle", "Castle"), entry("the sea", "sea")},
+			messages:  []string{"Let's visit the CASTLE"},
+			activated: []string{"the castle"},
+		},
+		{
+			name: "secondary key required",
+			entries: []models.LorebookEntry{
+				with(entry("winter festival", "festival"), func(e *models.LorebookEntry) { e.SecondaryKeys = []string{"winter", "snow"} }),
+				with(entry("summer festival", "festival"), func(e *models.LorebookEntry) { e.SecondaryKeys = []string{"summer"} }),
+			},
+			messages:  []string{"the festival in the snow"},
+			activated: []string{"winter festival"},
+		},
+		{
+			name: "regex key",
+			entries: []models.LorebookEntry{
+				with(entry("dragons", `\bdragons?\b`), func(e *models.LorebookEntry) { e.Regex = true }),
+				entry("not a regex", `\bcats?\b`),
+			},
+			messages:  []string{"a Dragon and a cat"},
+			activated: []string{"dragons"},
+		},
+		{
+			name: "case sensitive key",
+			entries: []models.LorebookEntry{
+				with(entry("the guild", "Guild"), func(e *models.LorebookEntry) { e.CaseSensitive = true }),
+				with(entry("the moon", "Moon"), func(e *models.LorebookEntry) { e.CaseSensitive = true }),
+			},
+			messages:  []string{"the guild under the Moon"},
+			activated: []string{"the moon"},
+		},
+		{
+			name: "constant entry without a match",
+			entries: []models.LorebookEntry{
+				with(entry("always", "never mentioned"), func(e *models.LorebookEntry) { e.Constant = true }),
+				with(entry("disabled", "hello"), func(e *models.LorebookEntry) { e.Constant, e.Enabled = true, false }),
+			},
+			messages:  []string{"hello"},
+			activated: []string{"always"},
+		},
+		{
+			name:      "scan depth",
+			entries:   []models.LorebookEntry{entry("old news", "yesterday"), entry("new news", "today")},
+			messages:  []string{"yesterday", "something else", "today"},
+			settings:  Settings{ScanDepth: 2},
+			activated: []string{"new news"},
+		},
+		{
+			name:      "no recursion without the setting",
+			entries:   chain,
+			messages:  []string{"link 0"},
+			activated: []string{"then link 1"},
+		},
+		{
+			name:      "recursion stops at maxRecursion",
+			entries:   chain,
+			messages:  []string{"link 0"},
+			settings:  Settings{Recursive: true},
+			activated: []string{"then link 1", "then link 2", "then link 3", "then link 4"},
+		},
+		{
+			name: "no_recursion content activates nothing",
+			entries: []models.LorebookEntry{
+				chain[0],
+				with(chain[1], func(e *models.LorebookEntry) { e.NoRecursion = true }),
+				chain[2],
+			},
+			messages:  []string{"link 0"},
+			settings:  Settings{Recursive: true},
+			activated: []string{"then link 1", "then link 2"},
+		},
+		{
+			name: "budget cuts the lowest priority",
+			entries: []models.LorebookEntry{
+				with(entry(big, "x"), func(e *models.LorebookEntry) { e.Name, e.Priority = "big low", 1 }),
+				with(entry(small, "x"), func(e *models.LorebookEntry) { e.Name, e.Priority, e.InsertionOrder = "small high", 5, 1 }),
+				with(entry(big, "x"), func(e *models.LorebookEntry) { e.Name, e.Priority, e.InsertionOrder = "big high", 5, 2 }),
+			},
+			messages:  []string{"x"},
+			settings:  Settings{TokenBudget: tokens.Estimate(big) + tokens.Estimate(small)},
+			activated: []string{"small high", "big high"},
+			skipped:   []string{"big low"},
+		},
+		{
+			name: "budget keeps smaller entries that still fit",
+			entries: []models.LorebookEntry{
+				with(entry(big, "x"), func(e *models.LorebookEntry) { e.Name, e.Priority = "big", 5 }),
+				with(entry(big, "x"), func(e *models.LorebookEntry) { e.Name, e.Priority = "big too", 4 }),
+				with(entry(small, "x"), func(e *models.LorebookEntry) { e.Name, e.Priority = "small", 1 }),
+			},
+			messages:  []string{"x"},
+			settings:  Settings{TokenBudget: tokens.Estimate(big) + tokens.Estimate(small)},
+			activated: []string{"big", "small"},
+			skipped:   []string{"big too"},
+		},
+		{
+			name: "broken regex disables only its entry",
+			entries: []models.LorebookEntry{
+				with(entry("broken", "(unclosed", "fine"), func(e *models.LorebookEntry) { e.Regex = true }),
+				entry("fine", "fine"),
+			},
+			messages:  []string{"fine (unclosed"},
+			activated: []string{"fine"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Scan(tt.entries, tt.messages, tt.settings)
+			if got := names(res.Activated); !slices.Equal(got, tt.activated) {
+				t.Errorf("activated = %q, want %q", got, tt.activated)
+			}
+			if got := names(res.Skipped); !slices.Equal(got, tt.skipped) {
+				t.Errorf("skipped = %q, want %q", got, tt.skipped)
+			}
+			if tt.settings.TokenBudget > 0 && res.Tokens > tt.settings.TokenBudget {
+				t.Errorf("%d tokens over a budget of %d", res.Tokens, tt.settings.TokenBudget)
+			}
+		})
+	}
+}
+
+func TestScanPlacement(t *testing.T) {
+	entries := []models.LorebookEntry{
+		{Content: "after, second", Keys: []string{"x"}, Enabled: true, Position: PositionAfterChar, InsertionOrder: 2},
+		{Content: "before, second", Keys: []string{"x"}, Enabled: true, InsertionOrder: 3},
+		{Content: "after, first", Keys: []string{"x"}, Enabled: true, Position: PositionAfterChar, InsertionOrder: 1},
+		{Content: "before, first", Keys: []string{"x"}, Enabled: true, Position: PositionBeforeChar, InsertionOrder: 0},
+	}
+	res := Scan(entries, []string{"x"}, Settings{})
+	if want := []string{"before, first", "before, second"}; !slices.Equal(res.Before, want) {
+		t.Errorf("before = %q, want %q", res.Before, want)
+	}
+	if want := []string{"after, first", "after, second"}; !slices.Equal(res.After, want) {
+		t.Errorf("after = %q, want %q", res.After, want)
+	}
+}
+
+func TestValidateRejectsBadRegex(t *testing.T) {
+	e := entry("broken", "(unclosed")
+	if err := Validate(&e); err != nil {
+		t.Fatalf("a plain key was validated as a regex: %v", err)
+	}
+	e.Regex = true
+	if err := Validate(&e); err == nil {
+		t.Fatal("a broken regex key passed validation")
+	}
+}
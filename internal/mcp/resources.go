@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"encoding/json"
+	"strings"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+const resourcePrefix = "companion://characters/"
+
+// resource describes one readable resource in resources/list
+type resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+func resourceTemplates() []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"uriTemplate": resourcePrefix + "{character_id}",
+			"name":        "Character profile",
+			"mimeType":    "application/json",
+		},
+		{
+			"uriTemplate": resourcePrefix + "{character_id}/summaries",
+			"name":        "Conversation summaries",
+			"mimeType":    "application/json",
+		},
+	}
+}
+
+func (s *Server) listResources() (interface{}, error) {
+	chars, err := s.repo.ListCharacters()
+	if err != nil {
+		return nil, err
+	}
+	resources := []resource{}
+	for _, c := range chars {
+		resources = append(resources,
+			resource{
+				URI:         resourcePrefix + c.CharacterID,
+				Name:        c.Name,
+				Description: "Profile of " + c.Name,
+				MimeType:    "application/json",
+			},
+			resource{
+				URI:         resourcePrefix + c.CharacterID + "/summaries",
+				Name:        c.Name + " summaries",
+				Description: "Conversation summaries remembered by " + c.Name,
+				MimeType:    "application/json",
+			},
+		)
+	}
+	return map[string]interface{}{"resources": resources}, nil
+}
+
+func (s *Server) readResource(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, invalidParams("resources/read: %v", err)
+	}
+	if !strings.HasPrefix(p.URI, resourcePrefix) {
+		return nil, invalidParams("unknown resource %q", p.URI)
+	}
+
+	characterID, view, _ := strings.Cut(strings.TrimPrefix(p.URI, resourcePrefix), "/")
+	profile, err := s.repo.GetCharacter(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, invalidParams("unknown resource %q", p.URI)
+	}
+
+	var content interface{}
+	switch view {
+	case "":
+		content = profile
+	case "summaries":
+		summaries, err := s.repo.ListMemorySummariesByCharacter(characterID)
+		if err != nil {
+			return nil, err
+		}
+		if summaries == nil {
+			summaries = []models.MemorySummary{}
+		}
+		content = summaries
+	default:
+		return nil, invalidParams("unknown resource %q", p.URI)
+	}
+
+	text, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"uri": p.URI, "mimeType": "application/json", "text": string(text)},
+		},
+	}, nil
+}
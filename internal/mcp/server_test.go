@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// reply is a response as a client decodes it
+type reply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+// serve feeds lines to a server and returns its responses in order. None of the
+// requests used here reach the repository or the orchestrator.
+func serve(t *testing.T, lines ...string) []reply {
+	t.Helper()
+	var out bytes.Buffer
+	in := strings.NewReader(strings.Join(lines, "\n") + "\n")
+	if err := NewServer(nil, nil).Serve(context.Background(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+	var replies []reply
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r reply
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("decode %q: %v", out.String(), err)
+		}
+		replies = append(replies, r)
+	}
+	return replies
+}
+
+func TestServeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		id   string
+		code int
+	}{
+		{"parse error", `{"jsonrpc": "2.0", "id": 1, "method": `, "null", codeParseError},
+		{"wrong jsonrpc version", `{"jsonrpc": "1.0", "id": 2, "method": "ping"}`, "2", codeInvalidRequest},
+		{"unknown method", `{"jsonrpc": "2.0", "id": "a", "method": "prompts/list"}`, `"a"`, codeMethodNotFound},
+		{"tools/call params not an object", `{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": []}`, "3", codeInvalidParams},
+		{"tools/call arguments not an object", `{"jsonrpc": "2.0", "id": 4, "method": "tools/call", "params": {"name": "list_facts", "arguments": "c"}}`, "4", codeInvalidParams},
+		{"unknown tool", `{"jsonrpc": "2.0", "id": 5, "method": "tools/call", "params": {"name": "rm_rf"}}`, "5", codeInvalidParams},
+		{"initialize params not an object", `{"jsonrpc": "2.0", "id": 6, "method": "initialize", "params": "2025-06-18"}`, "6", codeInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replies := serve(t, tt.line)
+			if len(replies) != 1 {
+				t.Fatalf("%d responses, want 1", len(replies))
+			}
+			r := replies[0]
+			if r.JSONRPC != "2.0" || string(r.ID) != tt.id || r.Error == nil || r.Error.Code != tt.code || r.Result != nil {
+				t.Fatalf("response = %+v (error %+v), want id %s and code %d", r, r.Error, tt.id, tt.code)
+			}
+		})
+	}
+}
+
+func TestServeNotifications(t *testing.T) {
+	replies := serve(t,
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		``,
+		`{"jsonrpc": "2.0", "method": "notifications/unknown"}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "ping"}`,
+	)
+	if len(replies) != 1 || string(replies[0].ID) != "7" || replies[0].Error != nil {
+		t.Fatalf("responses = %+v, want only the ping answered", replies)
+	}
+}
+
+func TestServeInitialize(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   string
+	}{
+		{"current version", `{"protocolVersion": "2025-06-18"}`, "2025-06-18"},
+		{"older supported version", `{"protocolVersion": "2024-11-05"}`, "2024-11-05"},
+		{"unknown version", `{"protocolVersion": "2099-01-01"}`, ProtocolVersion},
+		{"no version", `{}`, ProtocolVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replies := serve(t, `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": `+tt.params+`}`)
+			if len(replies) != 1 || replies[0].Error != nil {
+				t.Fatalf("responses = %+v", replies)
+			}
+			var result struct {
+				ProtocolVersion string                     `json:"protocolVersion"`
+				Capabilities    map[string]json.RawMessage `json:"capabilities"`
+			}
+			if err := json.Unmarshal(replies[0].Result, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.ProtocolVersion != tt.want {
+				t.Fatalf("negotiated %q, want %q", result.ProtocolVersion, tt.want)
+			}
+			if _, ok := result.Capabilities["tools"]; !ok {
+				t.Fatalf("capabilities = %v, want tools", result.Capabilities)
+			}
+		})
+	}
+}
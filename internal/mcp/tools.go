@@ -0,0 +1,355 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+)
+
+// tool describes one callable tool in tools/list
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// toolArgs is the union of every tool's arguments
+type toolArgs struct {
+	CharacterID string   `json:"character_id"`
+	Query       string   `json:"query"`
+	Limit       int      `json:"limit"`
+	FactID      string   `json:"fact_id"`
+	Key         string   `json:"key"`
+	Value       string   `json:"value"`
+	Type        string   `json:"type"`
+	Confidence  *float64 `json:"confidence"`
+	Message     string   `json:"message"`
+}
+
+func objectSchema(required []string, props map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+func prop(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+var characterProp = prop("string", "Character ID (chr_...) or exact name")
+
+func toolDefinitions() []tool {
+	return []tool{
+		{
+			Name:        "search_history",
+			Description: "Search a character's chat history for messages containing the query (case-insensitive), newest first.",
+			InputSchema: objectSchema([]string{"character_id", "query"}, map[string]interface{}{
+				"character_id": characterProp,
+				"query":        prop("string", "Text to look for"),
+				"limit":        prop("integer", "Maximum number of messages to return (default 20, max 200)"),
+			}),
+		},
+		{
+			Name:        "list_facts",
+			Description: "List the facts a character remembers about the user.",
+			InputSchema: objectSchema([]string{"character_id"}, map[string]interface{}{
+				"character_id": characterProp,
+			}),
+		},
+		{
+			Name:        "add_fact",
+			Description: "Teach a character a new fact about the user, or update the fact with the same key.",
+			InputSchema: objectSchema([]string{"character_id", "key", "value"}, map[string]interface{}{
+				"character_id": characterProp,
+				"key":          prop("string", "Short fact name, e.g. favorite_food"),
+				"value":        prop("string", "Fact content"),
+				"type":         prop("string", "Fact category (default general)"),
+				"confidence":   prop("number", "Confidence between 0 and 1 (default 1)"),
+			}),
+		},
+		{
+			Name:        "forget_fact",
+			Description: "Make a character forget a fact, by fact_id or by key.",
+			InputSchema: objectSchema([]string{"character_id"}, map[string]interface{}{
+				"character_id": characterProp,
+				"fact_id":      prop("string", "ID of the fact to delete"),
+				"key":          prop("string", "Key of the fact to delete when fact_id is not known"),
+			}),
+		},
+		{
+			Name:        "get_relationship",
+			Description: "Get the intimacy level, score, narrative and current emotion of a character.",
+			InputSchema: objectSchema([]string{"character_id"}, map[string]interface{}{
+				"character_id": characterProp,
+			}),
+		},
+		{
+			Name:        "send_message",
+			Description: "Send a message to a character as the user and return its reply. The exchange is stored in the character's history.",
+			InputSchema: objectSchema([]string{"character_id", "message"}, map[string]interface{}{
+				"character_id": characterProp,
+				"message":      prop("string", "What the user says"),
+			}),
+		},
+	}
+}
+
+// callTool runs a tool. Tool failures are reported in the result (isError) so the calling model can react.
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, invalidParams("tools/call: %v", err)
+	}
+
+	var args toolArgs
+	if len(p.Arguments) > 0 {
+		if err := json.Unmarshal(p.Arguments, &args); err != nil {
+			return nil, invalidParams("%s: %v", p.Name, err)
+		}
+	}
+
+	var out interface{}
+	var err error
+	switch p.Name {
+	case "search_history":
+		out, err = s.searchHistory(args)
+	case "list_facts":
+		out, err = s.listFacts(args)
+	case "add_fact":
+		out, err = s.addFact(args)
+	case "forget_fact":
+		out, err = s.forgetFact(args)
+	case "get_relationship":
+		out, err = s.getRelationship(args)
+	case "send_message":
+		out, err = s.sendMessage(ctx, args)
+	default:
+		return nil, invalidParams("unknown tool %q", p.Name)
+	}
+
+	if err != nil {
+		return toolResult(err.Error(), true), nil
+	}
+	text, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return toolResult(string(text), false), nil
+}
+
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// character resolves an ID or case-insensitive name
+func (s *Server) character(ref string) (*models.CharacterProfile, error) {
+	if strings.TrimSpace(ref) == "" {
+		return nil, fmt.Errorf("character_id is required")
+	}
+	profile, err := s.repo.GetCharacter(ref)
+	if err != nil || profile != nil {
+		return profile, err
+	}
+	chars, err := s.repo.ListCharacters()
+	if err != nil {
+		return nil, err
+	}
+	for i := range chars {
+		if strings.EqualFold(chars[i].Name, ref) {
+			return &chars[i], nil
+		}
+	}
+	return nil, fmt.Errorf("character %q not found", ref)
+}
+
+// historyHit is one search_history match
+type historyHit struct {
+	ID        uint      `json:"id"`
+	SessionID string    `json:"session_id"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// searchHistory filters in Go because message content may be encrypted at rest
+func (s *Server) searchHistory(args toolArgs) (interface{}, error) {
+	profile, err := s.character(args.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	query := strings.ToLower(strings.TrimSpace(args.Query))
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 200 {
+		limit = 200
+	}
+
+	msgs, err := s.repo.ListMessagesByCharacter(profile.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	hits := []historyHit{}
+	for i := len(msgs) - 1; i >= 0 && len(hits) < limit; i-- {
+		m := msgs[i]
+		if strings.Contains(strings.ToLower(m.Content.String()), query) {
+			hits = append(hits, historyHit{ID: m.ID, SessionID: m.SessionID, Role: m.Role, Content: m.Content.String(), Timestamp: m.Timestamp})
+		}
+	}
+	return hits, nil
+}
+
+func (s *Server) listFacts(args toolArgs) (interface{}, error) {
+	profile, err := s.character(args.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	facts, err := s.repo.ListMemoryFactsByCharacter(profile.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	if facts == nil {
+		facts = []models.MemoryFact{}
+	}
+	return facts, nil
+}
+
+func (s *Server) addFact(args toolArgs) (interface{}, error) {
+	profile, err := s.character(args.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	key, value := strings.TrimSpace(args.Key), strings.TrimSpace(args.Value)
+	if key == "" || value == "" {
+		return nil, fmt.Errorf("key and value are required")
+	}
+	confidence := 1.0
+	if args.Confidence != nil {
+		confidence = *args.Confidence
+	}
+	if confidence < 0 || confidence > 1 {
+		return nil, fmt.Errorf("confidence must be between 0 and 1")
+	}
+	factType := strings.TrimSpace(args.Type)
+	if factType == "" {
+		factType = "general"
+	}
+
+	fact, err := s.factByKey(profile.CharacterID, key)
+	if err != nil {
+		return nil, err
+	}
+	if fact == nil {
+		fact = &models.MemoryFact{
+			FactID:      orchestrator.GenerateFactID(),
+			CharacterID: profile.CharacterID,
+			FactKey:     key,
+		}
+	}
+	fact.FactType = factType
+	fact.FactValue = models.EncryptedString(value)
+	fact.Confidence = confidence
+	fact.SourceMessageID = "mcp"
+	fact.LastSeenAt = time.Now()
+	if err := s.repo.SaveMemoryFact(fact); err != nil {
+		return nil, err
+	}
+	return fact, nil
+}
+
+func (s *Server) forgetFact(args toolArgs) (interface{}, error) {
+	profile, err := s.character(args.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fact *models.MemoryFact
+	switch {
+	case args.FactID != "":
+		fact, err = s.repo.GetMemoryFact(args.FactID)
+		if fact != nil && fact.CharacterID != profile.CharacterID {
+			fact = nil
+		}
+	case args.Key != "":
+		fact, err = s.factByKey(profile.CharacterID, args.Key)
+	default:
+		return nil, fmt.Errorf("fact_id or key is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fact == nil {
+		return nil, fmt.Errorf("fact not found")
+	}
+	if err := s.repo.DeleteMemoryFact(fact.FactID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"forgotten": fact.FactID, "key": fact.FactKey}, nil
+}
+
+// factByKey finds a character's fact by its key (case-insensitive); nil if none
+func (s *Server) factByKey(characterID, key string) (*models.MemoryFact, error) {
+	facts, err := s.repo.ListMemoryFactsByCharacter(characterID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range facts {
+		if strings.EqualFold(facts[i].FactKey, key) {
+			return &facts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) getRelationship(args toolArgs) (interface{}, error) {
+	profile, err := s.character(args.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := s.repo.GetRelationshipState(profile.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	emo, err := s.repo.GetEmotionState(profile.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"character_id":      profile.CharacterID,
+		"name":              profile.Name,
+		"relationship_type": profile.RelationshipType,
+		"relationship":      rel,
+		"emotion":           emo,
+	}, nil
+}
+
+func (s *Server) sendMessage(ctx context.Context, args toolArgs) (interface{}, error) {
+	profile, err := s.character(args.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Message) == "" {
+		return nil, fmt.Errorf("message is required")
+	}
+
+	s.turnMu.Lock()
+	defer s.turnMu.Unlock()
+	session := s.orch.EnsureSession(profile.CharacterID)
+	return s.orch.Reply(ctx, args.Message, profile, session, nil)
+}
@@ -0,0 +1,158 @@
+package media
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+// Kinds of user attachments
+const (
+	KindImage = "image" // picture the user shows the character
+	KindFile  = "file"  // text document the user shares
+)
+
+// Size limits of attachments; vision endpoints reject larger images anyway
+const (
+	maxImageBytes = 20 << 20
+	maxFileBytes  = 1 << 20
+)
+
+// imageTypes are the formats vision models accept
+var imageTypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true}
+
+// textExtensions are read as text even when their content sniffs as something else
+var textExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".csv": true, ".tsv": true, ".json": true, ".yaml": true,
+	".yml": true, ".toml": true, ".xml": true, ".html": true, ".log": true, ".srt": true, ".ini": true,
+}
+
+// Load reads a local image or text file as an attachment
+func Load(path string) (*models.Media, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxImageBytes {
+		return nil, fmt.Errorf("%s is too large to attach (%s)", filepath.Base(path), humanSize(int(info.Size())))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := http.DetectContentType(data)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	var m *models.Media
+	switch {
+	case imageTypes[mimeType]:
+		m = New(KindImage, mimeType, data)
+	case strings.HasPrefix(mimeType, "text/") || textExtensions[strings.ToLower(filepath.Ext(path))]:
+		if len(data) > maxFileBytes {
+			return nil, fmt.Errorf("%s is too large to attach (%s, at most %s of text)", filepath.Base(path), humanSize(len(data)), humanSize(maxFileBytes))
+		}
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("%s is not UTF-8 text", filepath.Base(path))
+		}
+		m = New(KindFile, "text/plain", data)
+	default:
+		return nil, fmt.Errorf("%s: only images (PNG, JPEG, GIF, WebP) and text files can be attached, not %s", filepath.Base(path), mimeType)
+	}
+	m.Name = filepath.Base(path)
+	return m, nil
+}
+
+// Text returns the content of a text attachment
+func Text(m *models.Media) (string, error) {
+	data, err := Bytes(m)
+	return string(data), err
+}
+
+// DataURL encodes an image attachment for a vision request
+func DataURL(m *models.Media) string {
+	return "data:" + m.MimeType + ";base64," + m.Data.String()
+}
+
+// ExtractPaths finds files dropped into a message. Terminals paste a dragged file as its path, quoted,
+// with escaped spaces or as a file:// URL. Only a message that consists of nothing but such paths counts
+// as dropped files, so a path mentioned in a sentence ("my /etc/hosts looks wrong") is never attached.
+// It returns the message, or "" and the paths when the message was dropped files.
+func ExtractPaths(text string) (string, []string) {
+	if p, ok := droppedPath(strings.TrimSpace(text)); ok {
+		return "", []string{p}
+	}
+	words := splitEscaped(text)
+	var paths []string
+	for _, word := range words {
+		p, ok := droppedPath(word)
+		if !ok {
+			return text, nil
+		}
+		paths = append(paths, p)
+	}
+	if len(paths) == 0 {
+		return text, nil
+	}
+	return "", paths
+}
+
+// droppedPath reports whether s names an existing, attachable file
+func droppedPath(s string) (string, bool) {
+	s = strings.Trim(s, `'"`)
+	if strings.HasPrefix(s, "file://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", false
+		}
+		s = u.Path
+	}
+	s = strings.ReplaceAll(s, `\ `, " ")
+	if strings.HasPrefix(s, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			s = filepath.Join(home, s[2:])
+		}
+	}
+	if !filepath.IsAbs(s) && !strings.HasPrefix(s, "./") && !strings.HasPrefix(s, "../") {
+		return "", false
+	}
+	info, err := os.Stat(s)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return s, true
+}
+
+// splitEscaped splits on spaces that are not escaped with a backslash
+func splitEscaped(text string) []string {
+	var out []string
+	var cur strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && text[i+1] == ' ':
+			cur.WriteString(`\ `)
+			i++
+		case text[i] == ' ' || text[i] == '\n' || text[i] == '\t':
+			if cur.Len() > 0 {
+				out = append(out, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteByte(text[i])
+		}
+	}
+	if cur.Len() > 0 {
+		out = append(out, cur.String())
+	}
+	return out
+}
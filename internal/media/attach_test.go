@@ -0,0 +1,44 @@
+package media
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractPaths(t *testing.T) {
+	dir := t.TempDir()
+	cat := filepath.Join(dir, "cat.png")
+	spaced := filepath.Join(dir, "my notes.txt")
+	for _, p := range []string{cat, spaced} {
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	escaped := strings.ReplaceAll(spaced, " ", `\ `)
+
+	tests := []struct {
+		name, text, rest string
+		paths            []string
+	}{
+		{"plain text", "hello there", "hello there", nil},
+		{"one path", cat + "\n", "", []string{cat}},
+		{"quoted path", "'" + spaced + "'", "", []string{spaced}},
+		{"escaped spaces", escaped, "", []string{spaced}},
+		{"file URL", "file://" + cat, "", []string{cat}},
+		{"several paths", cat + " " + escaped, "", []string{cat, spaced}},
+		{"path in a sentence", "my " + cat + " looks wrong", "my " + cat + " looks wrong", nil},
+		{"newlines kept", "look at\n" + cat + "\nplease", "look at\n" + cat + "\nplease", nil},
+		{"missing file", "/no/such/file.png", "/no/such/file.png", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, paths := ExtractPaths(tt.text)
+			if rest != tt.rest || !reflect.DeepEqual(paths, tt.paths) {
+				t.Fatalf("ExtractPaths(%q) = %q, %q; want %q, %q", tt.text, rest, paths, tt.rest, tt.paths)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Package media handles the pictures, recordings and files of a conversation: storing their bytes on a
+// Media row, loading the user's attachments and showing pictures in the terminal
+package media
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"strings"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+// Kinds of media
+const (
+	KindPortrait = "portrait" // picture of the character on its own, e.g. an avatar
+	KindSelfie   = "selfie"   // picture the character sends during a conversation
+	KindVoice    = "voice"    // recording of the user, transcribed into the message it belongs to
+	KindSpeech   = "speech"   // a reply of the character read aloud
+)
+
+// New builds a media row for the given file content
+func New(kind, mimeType string, data []byte) *models.Media {
+	return &models.Media{
+		Kind:     kind,
+		MimeType: mimeType,
+		Size:     len(data),
+		Data:     models.EncryptedString(base64.StdEncoding.EncodeToString(data)),
+	}
+}
+
+// IsAudio reports whether a media row is a recording or spoken reply
+func IsAudio(m *models.Media) bool {
+	return strings.HasPrefix(m.MimeType, "audio/")
+}
+
+// Bytes decodes the file content of a media row
+func Bytes(m *models.Media) ([]byte, error) {
+	if m.Data == "" {
+		return nil, fmt.Errorf("media %d has no data loaded", m.ID)
+	}
+	return base64.StdEncoding.DecodeString(m.Data.String())
+}
+
+// Extension is the file extension for a media row, including the dot
+func Extension(m *models.Media) string {
+	switch m.MimeType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/wav", "audio/wave", "audio/x-wav":
+		return ".wav"
+	case "audio/ogg":
+		return ".ogg"
+	}
+	if exts, _ := mime.ExtensionsByType(m.MimeType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// Placeholder is the one-line text shown where a picture cannot be drawn or a recording played
+func Placeholder(m *models.Media) string {
+	if m.Name != "" {
+		return fmt.Sprintf("[%s %s · %s]", m.Kind, m.Name, humanSize(m.Size))
+	}
+	format := m.MimeType[strings.Index(m.MimeType, "/")+1:]
+	return fmt.Sprintf("[%s #%d · %s · %s]", m.Kind, m.ID, format, humanSize(m.Size))
+}
+
+func humanSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", n>>10)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
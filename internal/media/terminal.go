@@ -0,0 +1,191 @@
+package media
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	_ "image/gif" // decoders for image.Decode
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+// Terminal graphics protocols (IMAGE_PROTOCOL)
+const (
+	ProtocolAuto  = "auto"
+	ProtocolKitty = "kitty"
+	ProtocolITerm = "iterm" // iTerm2 inline images, also understood by WezTerm
+	ProtocolSixel = "sixel"
+	ProtocolText  = "text" // placeholder line only
+)
+
+// maxWidthPx bounds the width pictures are drawn at, so a 1024px portrait does not fill the screen
+const maxWidthPx = 480
+
+// DetectProtocol guesses the graphics protocol of the terminal from its environment
+func DetectProtocol() string {
+	term, program := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || program == "ghostty":
+		return ProtocolKitty
+	case program == "iTerm.app" || program == "WezTerm":
+		return ProtocolITerm
+	case strings.Contains(term, "sixel") || strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "mlterm") ||
+		strings.HasPrefix(term, "contour"):
+		return ProtocolSixel
+	}
+	return ProtocolText
+}
+
+// Render draws a picture on w with the given protocol (ProtocolAuto detects it) and ends with a newline.
+// Pictures the protocol cannot show, or that cannot be decoded, fall back to their placeholder.
+func Render(w io.Writer, m *models.Media, protocol string) error {
+	if protocol == "" || protocol == ProtocolAuto {
+		protocol = DetectProtocol()
+	}
+	if !strings.HasPrefix(m.MimeType, "image/") {
+		protocol = ProtocolText
+	}
+	data, err := Bytes(m)
+	if err != nil {
+		return err
+	}
+
+	switch protocol {
+	case ProtocolITerm:
+		_, err = fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;width=%dpx;preserveAspectRatio=1:%s\a\n",
+			len(data), maxWidthPx, base64.StdEncoding.EncodeToString(data))
+		return err
+	case ProtocolKitty, ProtocolSixel:
+		img, _, decodeErr := image.Decode(bytes.NewReader(data))
+		if decodeErr != nil {
+			break
+		}
+		img = shrink(img, maxWidthPx)
+		if protocol == ProtocolKitty {
+			return writeKitty(w, img)
+		}
+		return writeSixel(w, img)
+	}
+	_, err = fmt.Fprintln(w, Placeholder(m))
+	return err
+}
+
+// writeKitty sends a PNG with the kitty graphics protocol, in the 4096 byte chunks it requires
+func writeKitty(w io.Writer, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	payload := base64.StdEncoding.EncodeToString(buf.Bytes())
+	for first := true; len(payload) > 0; first = false {
+		chunk := payload[:min(4096, len(payload))]
+		payload = payload[len(chunk):]
+		more := 0
+		if len(payload) > 0 {
+			more = 1
+		}
+		control := fmt.Sprintf("m=%d", more)
+		if first {
+			control = "a=T,f=100," + control
+		}
+		if _, err := fmt.Fprintf(w, "\x1b_G%s;%s\x1b\\", control, chunk); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// writeSixel dithers the picture to a 216 colour palette and encodes it as sixels
+func writeSixel(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	pal := color.Palette(palette.WebSafe)
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), pal)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for i, c := range pal {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	row := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		used := map[uint8]bool{}
+		for y := top; y < min(top+6, height); y++ {
+			for x := 0; x < width; x++ {
+				used[paletted.ColorIndexAt(x, y)] = true
+			}
+		}
+		firstColor := true
+		for idx := range len(pal) {
+			if !used[uint8(idx)] {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && top+dy < height; dy++ {
+					if paletted.ColorIndexAt(x, top+dy) == uint8(idx) {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = 63 + bits
+			}
+			if !firstColor {
+				b.WriteByte('$') // back to the start of the band for the next colour
+			}
+			firstColor = false
+			fmt.Fprintf(&b, "#%d", idx)
+			writeSixelRun(&b, row)
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSixelRun writes one colour of a band, run-length encoding repeats
+func writeSixelRun(b *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(b, "!%d%c", n, row[i])
+		} else {
+			b.Write(row[i:j])
+		}
+		i = j
+	}
+}
+
+// shrink scales a picture down (nearest neighbour) to at most maxWidth pixels wide
+func shrink(img image.Image, maxWidth int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxWidth {
+		return img
+	}
+	width := maxWidth
+	height := max(1, bounds.Dy()*maxWidth/bounds.Dx())
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy := bounds.Min.Y + y*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			out.Set(x, y, img.At(bounds.Min.X+x*bounds.Dx()/width, sy))
+		}
+	}
+	return out
+}
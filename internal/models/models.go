@@ -4,6 +4,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -49,6 +51,99 @@ func (m MapJSON) Value() (driver.Value, error) {
 	return json.Marshal(m)
 }
 
+// FieldCipher encrypts and decrypts sensitive column values
+type FieldCipher interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+// encryptedPrefix marks a column value as ciphertext produced by a FieldCipher
+const encryptedPrefix = "enc:v1:"
+
+// escapedPrefix marks stored plaintext that itself starts with "enc:", so it is not taken for ciphertext
+const escapedPrefix = "enc:raw:"
+
+// ErrVaultLocked is returned when encrypted data is read before the database is unlocked
+var ErrVaultLocked = errors.New("database is encrypted and has not been unlocked")
+
+var (
+	cipherMu    sync.RWMutex
+	fieldCipher FieldCipher
+)
+
+// SetFieldCipher installs the cipher used by EncryptedString (nil stores plaintext)
+func SetFieldCipher(c FieldCipher) {
+	cipherMu.Lock()
+	defer cipherMu.Unlock()
+	fieldCipher = c
+}
+
+func currentFieldCipher() FieldCipher {
+	cipherMu.RLock()
+	defer cipherMu.RUnlock()
+	return fieldCipher
+}
+
+// EncryptedString is a text column that is encrypted at rest once a FieldCipher is installed.
+// Plaintext rows written before encryption was enabled are still read transparently; plaintext
+// that looks like ciphertext is stored behind escapedPrefix.
+type EncryptedString string
+
+func (e *EncryptedString) Scan(val interface{}) error {
+	var raw string
+	switch v := val.(type) {
+	case nil:
+		*e = ""
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return errors.New("unsupported type for EncryptedString")
+	}
+
+	if plain, ok := strings.CutPrefix(raw, escapedPrefix); ok {
+		*e = EncryptedString(plain)
+		return nil
+	}
+	if !strings.HasPrefix(raw, encryptedPrefix) {
+		*e = EncryptedString(raw)
+		return nil
+	}
+
+	c := currentFieldCipher()
+	if c == nil {
+		return ErrVaultLocked
+	}
+	plain, err := c.Decrypt(strings.TrimPrefix(raw, encryptedPrefix))
+	if err != nil {
+		return err
+	}
+	*e = EncryptedString(plain)
+	return nil
+}
+
+func (e EncryptedString) Value() (driver.Value, error) {
+	c := currentFieldCipher()
+	if c == nil {
+		if strings.HasPrefix(string(e), "enc:") {
+			return escapedPrefix + string(e), nil
+		}
+		return string(e), nil
+	}
+	sealed, err := c.Encrypt([]byte(e))
+	if err != nil {
+		return nil, err
+	}
+	return encryptedPrefix + sealed, nil
+}
+
+// String returns the plaintext value
+func (e EncryptedString) String() string {
+	return string(e)
+}
+
 // CharacterProfile represents the AI companion's configuration and background
 type CharacterProfile struct {
 	CharacterID          string      `gorm:"primaryKey" json:"character_id"`
@@ -63,6 +158,7 @@ type CharacterProfile struct {
 	ProfileJSON          MapJSON     `gorm:"type:text" json:"profile_json"`
 	MBTI                 string      `json:"mbti"`
 	ArtStyle             string      `json:"art_style"`
+	Voice                string      `json:"voice"` // text-to-speech voice, e.g. nova; empty uses SPEECH_VOICE
 	FamilyBackground     string      `json:"family_background"`
 	EducationDetail      string      `json:"education_detail"`
 	DatingHistory        string      `json:"dating_history"`
@@ -74,12 +170,15 @@ type CharacterProfile struct {
 
 // ChatMessage represents a single message in the conversation
 type ChatMessage struct {
-	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	SessionID   string    `gorm:"index" json:"session_id"`
-	CharacterID string    `gorm:"index" json:"character_id"`
-	Role        string    `json:"role"` // system, user, assistant
-	Content     string    `json:"content"`
-	Timestamp   time.Time `json:"timestamp"`
+	ID          uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionID   string          `gorm:"index" json:"session_id"`
+	CharacterID string          `gorm:"index" json:"character_id"`
+	Role        string          `json:"role"` // system, user, assistant
+	Content     EncryptedString `gorm:"type:text" json:"content"`
+	Timestamp   time.Time       `json:"timestamp"`
+
+	// Attachments are Media rows with this MessageID; AppendMessage stores them, LoadAttachments fills them in
+	Attachments []Media `gorm:"-" json:"attachments,omitempty"`
 }
 
 // SessionState tracks the high-level conversation state
@@ -104,27 +203,92 @@ type RelationshipState struct {
 	UpdatedAt             time.Time `json:"updated_at"`
 }
 
+// RelationshipTransition records the user changing what kind of relationship they have with a character
+type RelationshipTransition struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID string    `gorm:"index" json:"character_id"`
+	FromType    string    `json:"from_type"`
+	ToType      string    `json:"to_type"`
+	FromLevel   int       `json:"from_level"`
+	ToLevel     int       `json:"to_level"` // differs from FromLevel when the new type has a lower ceiling
+	TurnIndex   int       `json:"turn_index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Media is a picture, recording or file belonging to a conversation: a generated portrait or selfie, a
+// reply read aloud, or something the user attached to a message or said into the microphone.
+// The file content is kept base64-encoded in Data so it is encrypted, backed up and exported like the history.
+type Media struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID string          `gorm:"index" json:"character_id"`
+	SessionID   string          `gorm:"index" json:"session_id"`
+	MessageID   uint            `gorm:"index" json:"message_id"` // 0 when not sent in a message, e.g. a portrait
+	Kind        string          `json:"kind"`                    // portrait, selfie, image, file, voice, speech
+	Name        string          `json:"name,omitempty"`          // original file name of attachments
+	MimeType    string          `json:"mime_type"`
+	Size        int             `json:"size"` // bytes
+	Model       string          `json:"model"`
+	Prompt      EncryptedString `gorm:"type:text" json:"prompt"`
+	Data        EncryptedString `gorm:"type:text" json:"data,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// PersonaFlag records a reply that contradicted one of the character's anchored facts
+type PersonaFlag struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID string          `gorm:"index" json:"character_id"`
+	SessionID   string          `gorm:"index" json:"session_id"`
+	MessageID   uint            `json:"message_id"`
+	FactKey     string          `json:"fact_key"`
+	Expected    string          `json:"expected"`
+	Found       EncryptedString `gorm:"type:text" json:"found"` // the contradicting part of the reply
+	Source      string          `json:"source"`                 // rules or llm
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
 // MemoryFact is a distinct key-value piece of knowledge the AI remembers about the user
 type MemoryFact struct {
-	FactID          string    `gorm:"primaryKey" json:"fact_id"`
-	CharacterID     string    `gorm:"index" json:"character_id"`
-	FactType        string    `json:"fact_type"`
-	FactKey         string    `json:"fact_key"`
-	FactValue       string    `json:"fact_value"`
-	Confidence      float64   `json:"confidence"`
-	SourceMessageID string    `json:"source_message_id"`
-	LastSeenAt      time.Time `json:"last_seen_at"`
+	FactID          string          `gorm:"primaryKey" json:"fact_id"`
+	CharacterID     string          `gorm:"index" json:"character_id"`
+	SessionID       string          `gorm:"index" json:"session_id,omitempty"` // chat the fact was learned in; empty for facts every chat sees
+	FactType        string          `json:"fact_type"`
+	FactKey         string          `json:"fact_key"`
+	FactValue       EncryptedString `gorm:"type:text" json:"fact_value"`
+	Confidence      float64         `json:"confidence"`
+	SourceMessageID string          `json:"source_message_id"`
+	LastSeenAt      time.Time       `json:"last_seen_at"`
 }
 
 // MemorySummary is a batched summary of conversation history
 type MemorySummary struct {
-	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	CharacterID    string    `gorm:"index" json:"character_id"`
-	Version        int       `json:"version"`
-	BatchStartTurn int       `json:"batch_start_turn"`
-	BatchEndTurn   int       `json:"batch_end_turn"`
-	SummaryText    string    `json:"summary_text"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID    string          `gorm:"index" json:"character_id"`
+	Version        int             `json:"version"`
+	BatchStartTurn int             `json:"batch_start_turn"`
+	BatchEndTurn   int             `json:"batch_end_turn"`
+	SummaryText    EncryptedString `gorm:"type:text" json:"summary_text"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// LorebookEntry is a piece of world knowledge that enters the prompt only while its keys appear in the conversation
+type LorebookEntry struct {
+	ID             uint        `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID    string      `gorm:"index" json:"character_id"`
+	Name           string      `json:"name"`
+	Keys           StringSlice `gorm:"type:text" json:"keys"`           // any of them activates the entry
+	SecondaryKeys  StringSlice `gorm:"type:text" json:"secondary_keys"` // when set, one of them must match as well
+	Regex          bool        `json:"regex"`                           // keys are regular expressions
+	CaseSensitive  bool        `json:"case_sensitive"`
+	Content        string      `gorm:"type:text" json:"content"`
+	Position       string      `json:"position"`        // before_char, after_char
+	Priority       int         `json:"priority"`        // higher entries are kept first when the token budget runs out
+	InsertionOrder int         `json:"insertion_order"` // lower entries are inserted first
+	MaxTokens      int         `json:"max_tokens"`      // content is cut to this many tokens, 0 = no cap
+	Constant       bool        `json:"constant"`        // always inserted, keys or not
+	NoRecursion    bool        `json:"no_recursion"`    // its content does not activate other entries
+	Enabled        bool        `json:"enabled"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
 }
 
 // CharacterEmotionState tracks the transient emotion context
@@ -135,9 +299,91 @@ type CharacterEmotionState struct {
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// TurnTrace is the debug record of one model call: exactly what was sent and how it went
+type TurnTrace struct {
+	ID               uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID      string          `gorm:"index" json:"character_id"`
+	SessionID        string          `json:"session_id"`
+	TurnIndex        int             `json:"turn_index"`
+	Kind             string          `json:"kind"` // reply, proactive
+	Model            string          `json:"model"`
+	FallbackFrom     string          `json:"fallback_from"`
+	Temperature      float32         `json:"temperature"`
+	Prompt           EncryptedString `gorm:"type:text" json:"prompt"` // JSON array of the messages sent
+	Reply            EncryptedString `gorm:"type:text" json:"reply"`
+	PromptTokens     int             `json:"prompt_tokens"`
+	CompletionTokens int             `json:"completion_tokens"`
+	TotalTokens      int             `json:"total_tokens"`
+	FirstTokenMs     int64           `json:"first_token_ms"`
+	LatencyMs        int64           `json:"latency_ms"`
+	Error            string          `json:"error"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+// UsageRecord is the token usage and price of one model call
+type UsageRecord struct {
+	ID               uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID      string    `gorm:"index" json:"character_id"`
+	SessionID        string    `json:"session_id"`
+	Feature          string    `json:"feature"` // see the llm.Feature constants
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	CreatedAt        time.Time `gorm:"index" json:"created_at"` // UTC
+}
+
+// ChatBinding maps a conversation on an external chat platform to a character and session
+type ChatBinding struct {
+	Platform       string    `gorm:"primaryKey" json:"platform"` // telegram, ...
+	ExternalChatID string    `gorm:"primaryKey" json:"external_chat_id"`
+	ExternalUserID string    `json:"external_user_id"`
+	CharacterID    string    `gorm:"index" json:"character_id"`
+	SessionID      string    `json:"session_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ProactiveEvent records a message the companion sent on its own initiative; Key deduplicates triggers
+type ProactiveEvent struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID string    `gorm:"uniqueIndex:idx_proactive_key" json:"character_id"`
+	Key         string    `gorm:"uniqueIndex:idx_proactive_key" json:"key"` // e.g. morning:2024-05-01, fact:<id>:day, reminder:<id>
+	Kind        string    `json:"kind"`                                     // morning, goodnight, miss_you, event_day, event_followup, reminder
+	MessageID   uint      `json:"message_id"`
+	Delivered   bool      `json:"delivered"` // pushed to a live transport or shown in the chat UI
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Reminder is something the character promised to remind the user of; the proactive scheduler brings it up once due
+type Reminder struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	CharacterID string          `gorm:"index" json:"character_id"`
+	SessionID   string          `json:"session_id"`
+	Text        EncryptedString `gorm:"type:text" json:"text"`
+	DueAt       time.Time       `gorm:"index" json:"due_at"`
+	Done        bool            `json:"done"`
+	MessageID   uint            `json:"message_id"` // proactive message that delivered it
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// EncryptionSettings stores the key-derivation parameters of an encrypted database (single row)
+type EncryptionSettings struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	KDF       string    `json:"kdf"` // argon2id
+	Salt      []byte    `json:"salt"`
+	Time      uint32    `json:"time"`
+	MemoryKiB uint32    `json:"memory_kib"`
+	Threads   uint8     `json:"threads"`
+	Verifier  string    `json:"verifier"` // known plaintext sealed with the derived key
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // ModelProfile defines the LLM settings (config, not DB)
 type ModelProfile struct {
 	PrimaryModel  string
 	FallbackModel string
 	TimeoutMs     int
+	BaseURL       string // OpenAI-compatible endpoint, empty for api.openai.com
 }
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// reverseCipher is a FieldCipher that reverses the text, enough to tell ciphertext from plaintext
+type reverseCipher struct{}
+
+func (reverseCipher) Encrypt(plaintext []byte) (string, error) {
+	return reverse(string(plaintext)) + "!", nil
+}
+
+func (reverseCipher) Decrypt(ciphertext string) ([]byte, error) {
+	sealed, ok := strings.CutSuffix(ciphertext, "!")
+	if !ok {
+		return nil, errors.New("not sealed by reverseCipher")
+	}
+	return []byte(reverse(sealed)), nil
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// roundTrip stores a value and reads it back like a database column would
+func roundTrip(t *testing.T, in EncryptedString) (string, EncryptedString) {
+	t.Helper()
+	stored, err := in.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out EncryptedString
+	if err := out.Scan(stored); err != nil {
+		t.Fatalf("scan %q: %v", stored, err)
+	}
+	return stored.(string), out
+}
+
+func TestEncryptedStringPlaintextThatLooksEncrypted(t *testing.T) {
+	SetFieldCipher(nil)
+	for _, text := range []string{"hello", "enc:v1:not really ciphertext", "enc:raw:also plain", "enc:"} {
+		stored, got := roundTrip(t, EncryptedString(text))
+		if got.String() != text {
+			t.Errorf("%q stored as %q reads back as %q", text, stored, got)
+		}
+	}
+
+	// Rows written before escaping existed read as before
+	var legacy EncryptedString
+	if err := legacy.Scan([]byte("plain row")); err != nil || legacy != "plain row" {
+		t.Fatalf("legacy row = %q, %v", legacy, err)
+	}
+	if err := legacy.Scan("enc:v1:delaes!"); !errors.Is(err, ErrVaultLocked) {
+		t.Fatalf("ciphertext without a key = %v, want ErrVaultLocked", err)
+	}
+}
+
+func TestEncryptedStringWithCipher(t *testing.T) {
+	SetFieldCipher(nil)
+	escaped, _ := EncryptedString("enc:v1:typed by the user").Value()
+
+	SetFieldCipher(reverseCipher{})
+	t.Cleanup(func() { SetFieldCipher(nil) })
+
+	stored, got := roundTrip(t, "enc:v1:typed by the user")
+	if !strings.HasPrefix(stored, encryptedPrefix) || strings.Contains(stored, "typed by") || got != "enc:v1:typed by the user" {
+		t.Fatalf("stored %q, read back %q", stored, got)
+	}
+
+	// An escaped row from before the vault was enabled still reads as its plaintext
+	var old EncryptedString
+	if err := old.Scan(escaped); err != nil || old != "enc:v1:typed by the user" {
+		t.Fatalf("escaped row = %q, %v", old, err)
+	}
+}
@@ -0,0 +1,94 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"ai-companion-cli-go/internal/anchor"
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/models"
+)
+
+// SetAnchors sets the directory relative AnchorRefs are resolved in and how replies are checked
+// against anchored facts (anchor.CheckOff, CheckRules or CheckLLM)
+func (o *Orchestrator) SetAnchors(dir, check string) {
+	o.anchorDir = dir
+	o.anchorCheck = check
+}
+
+// Anchor loads the persona anchor of a character; nil when it has none or the file cannot be read.
+// The file is read on every call so edits apply without a restart.
+func (o *Orchestrator) Anchor(profile *models.CharacterProfile) *anchor.Anchor {
+	a, err := anchor.Load(profile, o.anchorDir)
+	if err != nil {
+		slog.Error("load anchor", "component", "orchestrator", "character_id", profile.CharacterID, "err", err)
+		return nil
+	}
+	return a
+}
+
+// AnchorPath is where the anchor of a character is (or would be) stored
+func (o *Orchestrator) AnchorPath(profile *models.CharacterProfile) string {
+	return anchor.Resolve(profile.AnchorRef, o.anchorDir)
+}
+
+// CheckConsistency returns the statements of a reply that contradict the character's anchored facts.
+// The model is only asked when useLLM is set.
+func (o *Orchestrator) CheckConsistency(ctx context.Context, profile *models.CharacterProfile, reply string, useLLM bool) ([]anchor.Finding, error) {
+	facts := anchor.Facts(profile, o.Anchor(profile))
+	if len(facts) == 0 {
+		return nil, nil
+	}
+	findings := anchor.Contradictions(reply, facts)
+	if !useLLM {
+		return findings, nil
+	}
+
+	ctx = llm.WithCall(ctx, llm.CallInfo{CharacterID: profile.CharacterID, Feature: llm.FeatureConsistency})
+	answer, err := o.client.GenerateSync(ctx, anchor.JudgePrompt(profile.Name, facts), reply)
+	if err != nil {
+		return findings, err
+	}
+	judged, err := anchor.ParseJudgement(answer)
+	return append(findings, judged...), err
+}
+
+// checkReply flags a saved reply that contradicts anchored facts. The rule check runs inline; the
+// model check runs in the background so the turn does not wait for a second completion.
+func (o *Orchestrator) checkReply(profile *models.CharacterProfile, msg *models.ChatMessage) {
+	switch o.anchorCheck {
+	case anchor.CheckRules:
+		findings, _ := o.CheckConsistency(context.Background(), profile, msg.Content.String(), false)
+		o.flag(profile, msg, findings)
+	case anchor.CheckLLM:
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			findings, err := o.CheckConsistency(ctx, profile, msg.Content.String(), true)
+			if err != nil {
+				slog.Warn("persona consistency check", "component", "orchestrator", "character_id", profile.CharacterID, "err", err)
+			}
+			o.flag(profile, msg, findings)
+		}()
+	}
+}
+
+func (o *Orchestrator) flag(profile *models.CharacterProfile, msg *models.ChatMessage, findings []anchor.Finding) {
+	for _, f := range findings {
+		slog.Warn("reply contradicts anchored fact", "component", "orchestrator", "character_id", profile.CharacterID,
+			"message_id", msg.ID, "fact", f.FactKey, "expected", f.Expected, "source", f.Source)
+		err := o.repo.AppendPersonaFlag(&models.PersonaFlag{
+			CharacterID: profile.CharacterID,
+			SessionID:   msg.SessionID,
+			MessageID:   msg.ID,
+			FactKey:     f.FactKey,
+			Expected:    f.Expected,
+			Found:       models.EncryptedString(f.Found),
+			Source:      f.Source,
+		})
+		if err != nil {
+			slog.Error("save persona flag", "component", "orchestrator", "character_id", profile.CharacterID, "err", err)
+		}
+	}
+}
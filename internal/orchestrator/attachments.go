@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/tokens"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// SetAttachments sets whether attached pictures are shown to the chat model (llm.VisionAuto, VisionOn
+// or VisionOff) and how many tokens of an attached text file are inlined into the prompt (0 = no cap)
+func (o *Orchestrator) SetAttachments(vision string, budget int) {
+	o.vision = vision
+	o.attachmentBudget = budget
+}
+
+// Vision reports whether attached pictures are sent to the primary chat model; after the model
+// refused a request with pictures they are only described for a while (see rejectionTTL)
+func (o *Orchestrator) Vision() bool {
+	return !o.visionRejected.active() && llm.VisionEnabled(o.vision, o.client.ModelProfile().PrimaryModel)
+}
+
+// buildChatMessages converts stored history into an OpenAI request after the system prompt.
+// Text files attached to user messages are inlined; pictures become image parts when vision is on and
+// a short note otherwise, so text-only models still know something was shown to them.
+func (o *Orchestrator) buildChatMessages(systemPrompt string, history []models.ChatMessage, vision bool) []openai.ChatCompletionMessage {
+	openAIMsgs := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+	}
+	for _, m := range history {
+		role := openai.ChatMessageRoleUser
+		if m.Role == "assistant" {
+			role = openai.ChatMessageRoleAssistant
+		}
+		msg := openai.ChatCompletionMessage{Role: role, Content: m.Content.String()}
+		if len(m.Attachments) > 0 {
+			msg = o.withAttachments(msg, m.Attachments, vision)
+		}
+		openAIMsgs = append(openAIMsgs, msg)
+	}
+	return openAIMsgs
+}
+
+// withAttachments adds the attachments of a user message to its request entry; voice recordings add
+// nothing since their transcript is the message
+func (o *Orchestrator) withAttachments(msg openai.ChatCompletionMessage, attachments []models.Media, vision bool) openai.ChatCompletionMessage {
+	text := msg.Content
+	var pictures []openai.ChatMessagePart
+	for i := range attachments {
+		a := &attachments[i]
+		switch {
+		case a.Kind == media.KindFile:
+			content, err := media.Text(a)
+			if err != nil {
+				slog.Warn("read attachment", "component", "orchestrator", "media_id", a.ID, "err", err)
+				continue
+			}
+			if o.attachmentBudget > 0 {
+				content = tokens.Truncate(content, o.attachmentBudget)
+			}
+			text += fmt.Sprintf("\n\n[Attached file %s]\n%s\n[End of %s]", a.Name, content, a.Name)
+		case a.Kind == media.KindImage && vision:
+			pictures = append(pictures, openai.ChatMessagePart{
+				Type:     openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{URL: media.DataURL(a), Detail: openai.ImageURLDetailAuto},
+			})
+		case a.Kind == media.KindImage:
+			text += fmt.Sprintf("\n\n[The user shared a picture (%s) that you cannot see; if it matters, say so naturally and ask them to describe it]", a.Name)
+		}
+	}
+	msg.Content = strings.TrimSpace(text)
+	if len(pictures) == 0 {
+		return msg
+	}
+	parts := []openai.ChatMessagePart{}
+	if msg.Content != "" {
+		parts = append(parts, openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: msg.Content})
+	}
+	msg.Content = ""
+	msg.MultiContent = append(parts, pictures...)
+	return msg
+}
+
+// hasPictures reports whether a request carries image parts
+func hasPictures(messages []openai.ChatCompletionMessage) bool {
+	for _, m := range messages {
+		for _, p := range m.MultiContent {
+			if p.Type == openai.ChatMessagePartTypeImageURL {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flattenPrompt replaces image parts with a short marker, so traces do not store the pictures again,
+// and writes tool calls out as text
+func flattenPrompt(messages []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	flat := make([]openai.ChatCompletionMessage, len(messages))
+	for i, m := range messages {
+		flat[i] = m
+		if len(m.ToolCalls) > 0 {
+			flat[i].Content = strings.TrimSpace(m.Content + "\n" + describeToolCalls(m.ToolCalls))
+			flat[i].ToolCalls = nil
+		}
+		if len(m.MultiContent) == 0 {
+			continue
+		}
+		var parts []string
+		for _, p := range m.MultiContent {
+			if p.Type == openai.ChatMessagePartTypeImageURL {
+				parts = append(parts, "[image]")
+			} else {
+				parts = append(parts, p.Text)
+			}
+		}
+		flat[i].Content = strings.Join(parts, "\n")
+		flat[i].MultiContent = nil
+	}
+	return flat
+}
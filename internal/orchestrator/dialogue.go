@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/dialogue"
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// SetDialogue sets the user's name for greetings and examples ("" means "you" in the character's
+// language) and the token budget of the example dialogues in the system prompt (0 = no cap)
+func (o *Orchestrator) SetDialogue(userName string, exampleBudget int) {
+	o.userName = userName
+	o.exampleBudget = exampleBudget
+}
+
+// Greet posts one of the character's greetings as the first message of a session nobody has spoken in
+// yet and returns it; nil when the session has started already or the character has no greeting
+func (o *Orchestrator) Greet(profile *models.CharacterProfile, session *models.SessionState) (*models.ChatMessage, error) {
+	started, err := o.repo.HasSessionMessages(session.SessionID)
+	if err != nil || started {
+		return nil, err
+	}
+	greeting := dialogue.PickGreeting(profile)
+	if greeting == "" {
+		return nil, nil
+	}
+
+	msg := &models.ChatMessage{
+		SessionID:   session.SessionID,
+		CharacterID: profile.CharacterID,
+		Role:        openai.ChatMessageRoleAssistant,
+		Content:     models.EncryptedString(dialogue.Expand(greeting, profile.Name, o.userNameFor(o.prompts.LocaleFor(profile)))),
+		Timestamp:   time.Now(),
+	}
+	if err := o.repo.AppendMessage(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// examples returns the character's example dialogues that fit the budget, with macros expanded
+func (o *Orchestrator) examples(profile *models.CharacterProfile, locale string) []dialogue.Example {
+	examples := dialogue.Fit(dialogue.Examples(profile), o.exampleBudget)
+	userName := o.userNameFor(locale)
+	for _, ex := range examples {
+		for i := range ex {
+			ex[i].Text = dialogue.Expand(ex[i].Text, profile.Name, userName)
+		}
+	}
+	return examples
+}
+
+// userNameFor is the configured user name, or "you" in the given language
+func (o *Orchestrator) userNameFor(locale string) string {
+	if o.userName != "" {
+		return o.userName
+	}
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "你"
+	}
+	return "you"
+}
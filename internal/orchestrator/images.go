@@ -0,0 +1,97 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/prompts"
+	"ai-companion-cli-go/internal/storage"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Portrait generates a picture of the character from its appearance and art style and stores it
+func (o *Orchestrator) Portrait(ctx context.Context, profile *models.CharacterProfile) (*models.Media, error) {
+	return o.generateImage(ctx, profile, "", prompts.Portrait, media.KindPortrait, "")
+}
+
+// Selfie generates a selfie of the character showing scene (empty lets the time of day decide) and posts it
+// to the session as an assistant message, so later replies know it was sent
+func (o *Orchestrator) Selfie(ctx context.Context, profile *models.CharacterProfile, session *models.SessionState, scene string) (*models.Media, *models.ChatMessage, error) {
+	m, err := o.generateImage(ctx, profile, session.SessionID, prompts.Selfie, media.KindSelfie, scene)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg := &models.ChatMessage{
+		SessionID:   session.SessionID,
+		CharacterID: profile.CharacterID,
+		Role:        openai.ChatMessageRoleAssistant,
+		Content:     models.EncryptedString(selfieCaption(o.prompts.LocaleFor(profile), scene)),
+		Timestamp:   time.Now(),
+	}
+	err = o.repo.Transaction(func(tx *storage.Repository) error {
+		if err := tx.AppendMessage(msg); err != nil {
+			return err
+		}
+		m.MessageID = msg.ID
+		return tx.AppendMedia(m)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, msg, nil
+}
+
+// generateImage renders an image prompt template, calls the images endpoint and builds the media row.
+// Portraits are stored right away; selfies are stored together with their message.
+func (o *Orchestrator) generateImage(ctx context.Context, profile *models.CharacterProfile, sessionID, template, kind, scene string) (*models.Media, error) {
+	level := o.Progression(profile).StartLevel
+	if rel, _ := o.repo.GetRelationshipState(profile.CharacterID); rel != nil && rel.IntimacyLevel > 0 {
+		level = rel.IntimacyLevel
+	}
+	data := o.promptData(profile, level, prompts.DefaultLocale, "")
+	data.Scene = scene
+	prompt, err := o.prompts.Render(template, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = llm.WithCall(ctx, llm.CallInfo{CharacterID: profile.CharacterID, SessionID: sessionID, Feature: llm.FeatureImage})
+	img, err := o.client.GenerateImage(ctx, strings.TrimSpace(prompt))
+	if err != nil {
+		return nil, fmt.Errorf("generate %s: %w", kind, err)
+	}
+
+	m := media.New(kind, img.MimeType, img.Data)
+	m.CharacterID = profile.CharacterID
+	m.SessionID = sessionID
+	m.Model = img.Model
+	m.Prompt = models.EncryptedString(strings.TrimSpace(prompt))
+	if kind == media.KindPortrait {
+		if err := o.repo.AppendMedia(m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// selfieCaption is the message text standing in for the picture in the history the model sees
+func selfieCaption(locale, scene string) string {
+	zh := strings.HasPrefix(strings.ToLower(locale), "zh")
+	switch {
+	case zh && scene != "":
+		return "📷 *发来一张自拍：" + scene + "*"
+	case zh:
+		return "📷 *发来一张自拍*"
+	case scene != "":
+		return "📷 *sends you a selfie: " + scene + "*"
+	default:
+		return "📷 *sends you a selfie*"
+	}
+}
@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/prompts"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Initiate has the character open the conversation on its own. reason tells the model why it is
+// reaching out (a morning greeting, a follow-up on a remembered event, ...). The message is stored
+// as an assistant ChatMessage; it neither bumps intimacy nor counts as a turn.
+func (o *Orchestrator) Initiate(
+	ctx context.Context,
+	profile *models.CharacterProfile,
+	session *models.SessionState,
+	reason string,
+) (*models.ChatMessage, error) {
+	intimacyLevel := o.stages.Progression(profile.RelationshipType).StartLevel
+	if rel, _ := o.repo.GetRelationshipState(profile.CharacterID); rel != nil && rel.IntimacyLevel > 0 {
+		intimacyLevel = rel.IntimacyLevel
+	}
+
+	recentMsgs, _ := o.repo.GetRecentSessionMessages(session.SessionID, 10)
+	if err := o.repo.LoadAttachments(recentMsgs); err != nil {
+		slog.Error("load attachments", "component", "orchestrator", "session_id", session.SessionID, "err", err)
+	}
+	systemPrompt, err := o.buildSystemPrompt(profile, intimacyLevel, recentMsgs)
+	if err != nil {
+		return nil, err
+	}
+	instruction, err := o.RenderPrompt(prompts.Initiate, profile, intimacyLevel, "", reason)
+	if err != nil {
+		return nil, err
+	}
+	// Pictures are only noted, not sent: reaching out is about the conversation, and nobody is there to retry
+	openAIMsgs := o.buildChatMessages(systemPrompt, recentMsgs, false)
+	openAIMsgs = append(openAIMsgs, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: instruction,
+	})
+
+	tracer := o.startTrace("proactive", session, openAIMsgs, 0.8)
+	callCtx := llm.WithCall(ctx, llm.CallInfo{CharacterID: profile.CharacterID, SessionID: session.SessionID, Feature: llm.FeatureProactive})
+	tokenChan, errChan := o.client.StreamChat(callCtx, openAIMsgs, 0.8, &tracer.stats)
+	var text strings.Builder
+	for chunk := range tokenChan {
+		tracer.token()
+		text.WriteString(chunk)
+	}
+	err = <-errChan
+	tracer.finish(text.String(), err)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text.String()) == "" {
+		return nil, errors.New("model returned an empty message")
+	}
+
+	msg := &models.ChatMessage{
+		SessionID:   session.SessionID,
+		CharacterID: profile.CharacterID,
+		Role:        openai.ChatMessageRoleAssistant,
+		Content:     models.EncryptedString(strings.TrimSpace(text.String())),
+		Timestamp:   time.Now(),
+	}
+	if err := o.repo.AppendMessage(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
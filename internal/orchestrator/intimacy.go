@@ -4,21 +4,21 @@ import (
 	"ai-companion-cli-go/internal/storage"
 )
 
-// UpdateIntimacy calculates and updates the relationship score
-func UpdateIntimacy(repo *storage.Repository, characterID string, scoreBump float64, currentTurn int) {
+// UpdateIntimacy calculates and updates the relationship score; levels never rise above ceiling
+func UpdateIntimacy(repo *storage.Repository, characterID string, scoreBump float64, currentTurn, ceiling int) {
 	state, err := repo.GetRelationshipState(characterID)
 	if err != nil || state == nil {
 		return // If it doesn't exist, we skip (should be created in EnsureSession)
 	}
 
 	state.IntimacyScore += scoreBump
-	
+
 	// Level up logic (simplified)
-	if state.IntimacyScore >= 100.0 && state.IntimacyLevel < 10 {
+	if state.IntimacyScore >= 100.0 && state.IntimacyLevel < ceiling {
 		state.IntimacyLevel += 1
 		state.IntimacyScore = 0.0 // reset progress for the new level
-	} else if state.IntimacyScore >= 100.0 && state.IntimacyLevel == 10 {
-		state.IntimacyScore = 100.0 // Cap at max
+	} else if state.IntimacyScore >= 100.0 {
+		state.IntimacyScore = 100.0 // Cap at the type's ceiling
 	}
 
 	// Level down logic
@@ -0,0 +1,41 @@
+package orchestrator
+
+import (
+	"log/slog"
+
+	"ai-companion-cli-go/internal/lorebook"
+	"ai-companion-cli-go/internal/models"
+)
+
+// SetLorebook sets how far back the lorebook scans and how much lore a prompt may carry
+func (o *Orchestrator) SetLorebook(settings lorebook.Settings) {
+	o.lore = settings
+}
+
+// LorebookSettings returns the lorebook settings in effect for a character
+func (o *Orchestrator) LorebookSettings(profile *models.CharacterProfile) lorebook.Settings {
+	return o.lore.For(profile)
+}
+
+// ScanLorebook returns the lorebook entries of a character that the given messages activate
+func (o *Orchestrator) ScanLorebook(profile *models.CharacterProfile, history []models.ChatMessage) lorebook.Result {
+	entries, err := o.repo.ListLorebookEntries(profile.CharacterID)
+	if err != nil {
+		slog.Error("load lorebook", "component", "orchestrator", "character_id", profile.CharacterID, "err", err)
+		return lorebook.Result{}
+	}
+	if len(entries) == 0 {
+		return lorebook.Result{}
+	}
+
+	messages := make([]string, len(history))
+	for i, m := range history {
+		messages[i] = m.Content.String()
+	}
+	res := lorebook.Scan(entries, messages, o.LorebookSettings(profile))
+	if len(res.Activated) > 0 || len(res.Skipped) > 0 {
+		slog.Debug("lorebook", "component", "orchestrator", "character_id", profile.CharacterID,
+			"activated", len(res.Activated), "skipped", len(res.Skipped), "tokens", res.Tokens)
+	}
+	return res
+}
@@ -3,11 +3,17 @@ package orchestrator
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"ai-companion-cli-go/internal/anchor"
 	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/lorebook"
 	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/prompts"
+	"ai-companion-cli-go/internal/relationship"
 	"ai-companion-cli-go/internal/storage"
 
 	"github.com/google/uuid"
@@ -18,35 +24,117 @@ import (
 type Orchestrator struct {
 	repo   *storage.Repository
 	client *llm.Client
+
+	turnHooks []func(session *models.SessionState)
+	traceKeep int // see EnableTracing
+	prompts   *prompts.Store
+	stages    *relationship.Table
+	lore      lorebook.Settings
+
+	userName      string // see SetDialogue
+	exampleBudget int
+
+	anchorDir   string // see SetAnchors
+	anchorCheck string
+
+	vision           string // see SetAttachments
+	attachmentBudget int
+	visionRejected   rejection // the model refused a request with pictures
+
+	tools         []Tool    // see RegisterTool
+	toolNames     []string  // offered tools, nil for all; see SetTools
+	toolsRejected rejection // the model refused a request with tools
+}
+
+// rejectionTTL is how long a refused capability stays off before it is tried again
+const rejectionTTL = 30 * time.Minute
+
+// rejection remembers for a while that the model refused a capability, so the following requests do not
+// fail first; it wears off, so one odd refusal or a model switched behind the endpoint does not turn the
+// capability off for good
+type rejection struct {
+	at atomic.Int64 // unix nanoseconds of the last refusal, 0 for none
+}
+
+func (r *rejection) set() {
+	r.at.Store(time.Now().UnixNano())
+}
+
+func (r *rejection) active() bool {
+	at := r.at.Load()
+	return at != 0 && time.Since(time.Unix(0, at)) < rejectionTTL
 }
 
 func NewOrchestrator(repo *storage.Repository, client *llm.Client) *Orchestrator {
-	return &Orchestrator{
-		repo:   repo,
-		client: client,
+	o := &Orchestrator{
+		repo:    repo,
+		client:  client,
+		prompts: prompts.NewStore("", prompts.DefaultLocale),
+		stages:  relationship.DefaultTable(),
+		lore:    lorebook.Settings{ScanDepth: 4, TokenBudget: 800, Recursive: true},
+
+		exampleBudget: 600,
+		anchorCheck:   anchor.CheckRules,
+
+		vision:           llm.VisionAuto,
+		attachmentBudget: 2000,
 	}
+	o.tools = o.builtinTools()
+	return o
 }
 
-// GenerateReplyStream orchestrates fetching history, calculating intimacy, updating UI, and streaming LLM response
+// AddTurnHook registers a callback run after every completed turn has been persisted
+func (o *Orchestrator) AddTurnHook(hook func(session *models.SessionState)) {
+	o.turnHooks = append(o.turnHooks, hook)
+}
+
+// GenerateReplyStream orchestrates fetching history, calculating intimacy, updating UI, and streaming LLM response.
+// attachments (see media.Load) are stored with the user message and shown to the model with it.
+// The model may call the offered tools (see SetTools) before it answers; only the answer is stored.
 func (o *Orchestrator) GenerateReplyStream(
 	ctx context.Context,
 	userText string,
 	profile *models.CharacterProfile,
 	session *models.SessionState,
+	attachments ...models.Media,
 ) (<-chan string, <-chan error) {
+	return o.generateReply(ctx, userText, profile, session, false, nil, attachments...)
+}
+
+// generateReply is GenerateReplyStream; a regenerated answer does not raise intimacy again, since the
+// message it answers already did. stats (which may be nil) describes the last model call by the time
+// the error channel is closed.
+func (o *Orchestrator) generateReply(
+	ctx context.Context,
+	userText string,
+	profile *models.CharacterProfile,
+	session *models.SessionState,
+	regenerate bool,
+	stats *llm.StreamStats,
+	attachments ...models.Media,
+) (<-chan string, <-chan error) {
+	// 0. A new session opens with the character's greeting, so the first reply already follows its voice
+	if _, err := o.Greet(profile, session); err != nil {
+		slog.Error("post greeting", "component", "orchestrator", "session_id", session.SessionID, "err", err)
+	}
+
 	// 1. Save User Message
 	userMsg := &models.ChatMessage{
 		SessionID:   session.SessionID,
 		CharacterID: profile.CharacterID,
 		Role:        openai.ChatMessageRoleUser,
-		Content:     userText,
+		Content:     models.EncryptedString(userText),
 		Timestamp:   time.Now(),
+		Attachments: attachments,
+	}
+	if err := o.repo.AppendMessage(userMsg); err != nil {
+		slog.Error("save user message", "component", "orchestrator", "session_id", session.SessionID, "err", err)
 	}
-	_ = o.repo.AppendMessage(userMsg)
 
 	// 2. Fetch Relationship State
 	relState, _ := o.repo.GetRelationshipState(profile.CharacterID)
-	intimacyLevel := 7 // Default CRUSH equivalent
+	progression := o.stages.Progression(profile.RelationshipType)
+	intimacyLevel := progression.StartLevel
 	if relState != nil && relState.IntimacyLevel > 0 {
 		intimacyLevel = relState.IntimacyLevel
 	}
@@ -56,34 +144,30 @@ func (o *Orchestrator) GenerateReplyStream(
 	if len(userText) > 20 {
 		scoreBump = 1.0 // Effort bump
 	}
-	UpdateIntimacy(o.repo, profile.CharacterID, scoreBump, session.TurnIndex)
+	if !regenerate {
+		UpdateIntimacy(o.repo, profile.CharacterID, scoreBump, session.TurnIndex, progression.Ceiling)
+	}
 
-	// 4. Fetch recent history (Last 10 messages for context)
-	recentMsgs, _ := o.repo.GetRecentMessages(profile.CharacterID, 10)
+	// 4. Fetch recent history of this session (last 10 messages for context)
+	recentMsgs, _ := o.repo.GetRecentSessionMessages(session.SessionID, 10)
+	if err := o.repo.LoadAttachments(recentMsgs); err != nil {
+		slog.Error("load attachments", "component", "orchestrator", "session_id", session.SessionID, "err", err)
+	}
 
 	// 5. Build full Prompt
-	systemPrompt := BuildSystemPrompt(profile, intimacyLevel)
-
-	openAIMsgs := []openai.ChatCompletionMessage{
-		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
-	}
-	for _, m := range recentMsgs {
-		role := openai.ChatMessageRoleUser
-		if m.Role == "assistant" {
-			role = openai.ChatMessageRoleAssistant
-		}
-		// Convert memory history to openAI format
-		openAIMsgs = append(openAIMsgs, openai.ChatCompletionMessage{
-			Role:    role,
-			Content: m.Content,
-		})
+	systemPrompt, err := o.buildSystemPrompt(profile, intimacyLevel, recentMsgs)
+	if err != nil {
+		tokenChan, errChan := make(chan string), make(chan error, 1)
+		close(tokenChan)
+		errChan <- err
+		close(errChan)
+		return tokenChan, errChan
 	}
 
-	// 6. Start Streaming
-	tokenChan, apiErrChan := o.client.StreamChat(ctx, openAIMsgs, 0.7)
+	openAIMsgs := o.buildChatMessages(systemPrompt, recentMsgs, o.Vision())
 
-	// 7. Middlewear to save the final assistant answer stream to DB
-	// So UI gets tokens, but we also save the complete answer when stream is done
+	// 6. Start Streaming, with a middleware saving the final assistant answer to the DB:
+	// the UI gets tokens, but we also save the complete answer when the stream is done
 	outTokenChan := make(chan string)
 	outErrChan := make(chan error, 1)
 
@@ -91,16 +175,77 @@ func (o *Orchestrator) GenerateReplyStream(
 		defer close(outTokenChan)
 		defer close(outErrChan)
 
+		// The model may call tools before it answers; their calls and results follow the history
+		env := ToolEnv{Profile: profile, Session: session, MessageID: userMsg.ID}
+		var toolMsgs []openai.ChatCompletionMessage
+		toolRounds := 0
+
 		var completeAnswer strings.Builder
-		for err := range apiErrChan {
-			// propagate error
-			outErrChan <- err
-			return
-		}
+		var tracer *turnTracer
+		defer func() {
+			if stats != nil && tracer != nil {
+				*stats = tracer.stats
+			}
+		}()
+		for {
+			request := append(openAIMsgs[:len(openAIMsgs):len(openAIMsgs)], toolMsgs...)
+			var tools []openai.Tool
+			if toolRounds < maxToolRounds {
+				tools = o.requestTools()
+			}
 
-		for chunk := range tokenChan {
-			completeAnswer.WriteString(chunk)
-			outTokenChan <- chunk
+			tracer = o.startTrace("reply", session, request, 0.7)
+			callCtx := llm.WithCall(ctx, llm.CallInfo{CharacterID: profile.CharacterID, SessionID: session.SessionID, Feature: llm.FeatureChat})
+			tokenChan, apiErrChan := o.client.StreamChatTools(callCtx, request, tools, 0.7, &tracer.stats)
+			var answer strings.Builder
+			for chunk := range tokenChan {
+				tracer.token()
+				answer.WriteString(chunk)
+				completeAnswer.WriteString(chunk)
+				outTokenChan <- chunk
+			}
+
+			// The token channel closes once the stream ends; a failure is left in the buffered error channel
+			err := <-apiErrChan
+			calls := tracer.stats.ToolCalls
+			if len(calls) > 0 {
+				tracer.finish(strings.TrimSpace(answer.String()+"\n"+describeToolCalls(calls)), err)
+			} else {
+				tracer.finish(answer.String(), err)
+			}
+			// Only a refusal of the request itself means the model cannot take pictures or tools; a rate limit
+			// or an outage would fail the same way without them
+			refused := err != nil && answer.Len() == 0 && ctx.Err() == nil && llm.IsRefusal(err)
+			if refused && hasPictures(request) {
+				// The model may not take pictures after all; answer from the text with a note about them instead
+				slog.Warn("request with pictures refused, retrying without", "component", "orchestrator",
+					"session_id", session.SessionID, "model", tracer.stats.Model, "err", err)
+				o.visionRejected.set()
+				openAIMsgs = o.buildChatMessages(systemPrompt, recentMsgs, false)
+				continue
+			}
+			if refused && len(tools) > 0 {
+				// Not every compatible endpoint takes tools; answer without them
+				slog.Warn("request with tools refused, retrying without", "component", "orchestrator",
+					"session_id", session.SessionID, "model", tracer.stats.Model, "err", err)
+				o.toolsRejected.set()
+				continue
+			}
+			if err != nil {
+				outErrChan <- err
+				return
+			}
+			if len(calls) > 0 && len(tools) > 0 {
+				toolMsgs = append(toolMsgs, openai.ChatCompletionMessage{
+					Role:      openai.ChatMessageRoleAssistant,
+					Content:   answer.String(),
+					ToolCalls: calls,
+				})
+				toolMsgs = append(toolMsgs, o.runTools(ctx, env, calls)...)
+				toolRounds++
+				continue
+			}
+			break
 		}
 
 		// Save assistant reply
@@ -108,14 +253,24 @@ func (o *Orchestrator) GenerateReplyStream(
 			SessionID:   session.SessionID,
 			CharacterID: profile.CharacterID,
 			Role:        openai.ChatMessageRoleAssistant,
-			Content:     completeAnswer.String(),
+			Content:     models.EncryptedString(completeAnswer.String()),
 			Timestamp:   time.Now(),
 		}
-		_ = o.repo.AppendMessage(assistantMsg)
+		if err := o.repo.AppendMessage(assistantMsg); err != nil {
+			slog.Error("save assistant message", "component", "orchestrator", "session_id", session.SessionID, "err", err)
+		}
+		o.checkReply(profile, assistantMsg)
 
 		// Increment Turn
 		session.TurnIndex++
-		_ = o.repo.SaveSessionState(session)
+		session.FallbackFrom = tracer.stats.FallbackFrom
+		if err := o.repo.SaveSessionState(session); err != nil {
+			slog.Error("save session state", "component", "orchestrator", "session_id", session.SessionID, "err", err)
+		}
+
+		for _, hook := range o.turnHooks {
+			hook(session)
+		}
 	}()
 
 	return outTokenChan, outErrChan
@@ -123,11 +278,16 @@ func (o *Orchestrator) GenerateReplyStream(
 
 // EnsureSession creates a session if not exists
 func (o *Orchestrator) EnsureSession(characterID string) *models.SessionState {
+	return o.EnsureSessionID(characterID, "sess_"+characterID)
+}
+
+// EnsureSessionID is EnsureSession for a caller-chosen session ID (e.g. one per external chat)
+func (o *Orchestrator) EnsureSessionID(characterID, sessionID string) *models.SessionState {
 	// Find active session
-	state, _ := o.repo.GetSessionState("sess_" + characterID)
+	state, _ := o.repo.GetSessionState(sessionID)
 	if state == nil {
 		state = &models.SessionState{
-			SessionID:   "sess_" + characterID,
+			SessionID:   sessionID,
 			CharacterID: characterID,
 			State:       "idle",
 			TurnIndex:   0,
@@ -136,12 +296,16 @@ func (o *Orchestrator) EnsureSession(characterID string) *models.SessionState {
 		_ = o.repo.SaveSessionState(state)
 	}
 
-	// Ensure relationship state exists as well
+	// Ensure relationship state exists as well, starting where the relationship type starts
 	rel, _ := o.repo.GetRelationshipState(characterID)
 	if rel == nil {
+		relType := ""
+		if profile, _ := o.repo.GetCharacter(characterID); profile != nil {
+			relType = profile.RelationshipType
+		}
 		rel = &models.RelationshipState{
 			CharacterID:     characterID,
-			IntimacyLevel:   7,
+			IntimacyLevel:   o.stages.Progression(relType).StartLevel,
 			IntimacyScore:   50.0,
 			LastUpdatedTurn: 0,
 		}
@@ -161,3 +325,8 @@ func GenerateCharacterBackstory(seed map[string]string) string {
 func GenerateCharacterID() string {
 	return "chr_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
 }
+
+// GenerateFactID helper
+func GenerateFactID() string {
+	return "fact_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+}
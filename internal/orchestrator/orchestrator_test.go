@@ -0,0 +1,162 @@
+package orchestrator
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/llm/llmtest"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func picture() models.Media {
+	return models.Media{Kind: media.KindImage, Name: "cat.png", MimeType: "image/png", Data: "iVBORw0KGgo="}
+}
+
+func withPictures(req openai.ChatCompletionRequest) bool {
+	return hasPictures(req.Messages)
+}
+
+func TestPicturesDroppedOnlyWhenRefused(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantRetry bool
+	}{
+		{"refused", http.StatusBadRequest, true},
+		{"rate limited", http.StatusTooManyRequests, false},
+		{"server error", http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := llmtest.NewServer(t)
+			fake.SetReply(func(req openai.ChatCompletionRequest) llmtest.Response {
+				if withPictures(req) {
+					return llmtest.Response{Status: tt.status}
+				}
+				return llmtest.Response{Content: "a cute cat"}
+			})
+			o, _ := newTestOrchestrator(t, fake.URL)
+			o.SetAttachments(llm.VisionOn, 2000)
+			o.SetTools("off")
+			profile := &models.CharacterProfile{CharacterID: "c", Name: "Aoi"}
+
+			_, err := o.Reply(context.Background(), "look", profile, o.EnsureSession("c"), nil, picture())
+			reqs := fake.Requests()
+			if tt.wantRetry {
+				// The client retries the refused request once without stream_options first
+				if err != nil || len(reqs) != 3 || !withPictures(reqs[1]) || withPictures(reqs[2]) {
+					t.Fatalf("err = %v after %d requests; want one retry without pictures", err, len(reqs))
+				}
+				if o.Vision() {
+					t.Fatal("vision should be off for a while after a refusal")
+				}
+				return
+			}
+			if err == nil || len(reqs) != 1 {
+				t.Fatalf("err = %v after %d requests; want the error without a retry", err, len(reqs))
+			}
+			if !o.Vision() {
+				t.Fatal("a passing error turned vision off")
+			}
+		})
+	}
+}
+
+func TestRejectionWearsOff(t *testing.T) {
+	var r rejection
+	if r.active() {
+		t.Fatal("a fresh rejection is active")
+	}
+	r.set()
+	if !r.active() {
+		t.Fatal("a new rejection is not active")
+	}
+	r.at.Add(-int64(rejectionTTL))
+	if r.active() {
+		t.Fatal("the rejection did not wear off after rejectionTTL")
+	}
+}
+
+func TestToolsDroppedOnlyWhenRefused(t *testing.T) {
+	for _, status := range []int{http.StatusUnprocessableEntity, http.StatusTooManyRequests} {
+		fake := llmtest.NewServer(t)
+		fake.SetReply(func(req openai.ChatCompletionRequest) llmtest.Response {
+			if len(req.Tools) > 0 {
+				return llmtest.Response{Status: status}
+			}
+			return llmtest.Response{Content: "hi"}
+		})
+		o, _ := newTestOrchestrator(t, fake.URL)
+		if len(o.Tools()) == 0 {
+			t.Fatal("no tools offered by default")
+		}
+		profile := &models.CharacterProfile{CharacterID: "c", Name: "Aoi"}
+
+		_, err := o.Reply(context.Background(), "hello", profile, o.EnsureSession("c"), nil)
+		reqs := fake.Requests()
+		if status == http.StatusTooManyRequests {
+			if err == nil || len(reqs) != 1 || len(o.Tools()) == 0 {
+				t.Fatalf("429: err = %v after %d requests, %d tools; want the error, no retry, tools kept", err, len(reqs), len(o.Tools()))
+			}
+			continue
+		}
+		if err != nil || len(reqs) != 3 || len(reqs[1].Tools) == 0 || len(reqs[2].Tools) != 0 || len(o.Tools()) != 0 {
+			t.Fatalf("%d: err = %v after %d requests; want one retry without tools", status, err, len(reqs))
+		}
+	}
+}
+
+func TestRegenerateKeepsIntimacy(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	o, repo := newTestOrchestrator(t, fake.URL)
+	o.SetTools("off")
+	profile := &models.CharacterProfile{CharacterID: "c", Name: "Aoi"}
+	session := o.EnsureSession("c")
+
+	first, err := o.Reply(context.Background(), "tell me about your day, I want to hear it all", profile, session, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.IntimacyDelta <= 0 {
+		t.Fatalf("a reply raised intimacy by %v", first.IntimacyDelta)
+	}
+	for range 3 {
+		again, err := o.Regenerate(context.Background(), profile, session, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.IntimacyDelta != 0 || again.TurnIndex != first.TurnIndex {
+			t.Fatalf("regenerate moved intimacy by %v to turn %d, want neither", again.IntimacyDelta, again.TurnIndex)
+		}
+	}
+	if rel, _ := repo.GetRelationshipState("c"); rel == nil || rel.IntimacyScore != first.IntimacyScore {
+		t.Fatalf("relationship = %+v, want the score after the first reply", rel)
+	}
+}
+
+func TestResultNamesAnsweringModel(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	fake.SetReply(func(req openai.ChatCompletionRequest) llmtest.Response {
+		if req.Model == "gpt-test" {
+			return llmtest.Response{Status: http.StatusServiceUnavailable}
+		}
+		return llmtest.Response{Content: "hi"}
+	})
+	o, _ := newTestOrchestrator(t, fake.URL)
+	o.client = llm.NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-test", FallbackModel: "gpt-fallback", BaseURL: fake.URL})
+	o.SetTools("off")
+	profile := &models.CharacterProfile{CharacterID: "c", Name: "Aoi"}
+
+	result, err := o.Reply(context.Background(), "hello", profile, o.EnsureSession("c"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Model != "gpt-fallback" {
+		t.Fatalf("model = %q, want the fallback that answered", result.Model)
+	}
+}
@@ -1,57 +1,65 @@
 package orchestrator
 
 import (
-	"fmt"
-	"strings"
-
+	"ai-companion-cli-go/internal/anchor"
 	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/prompts"
+	"ai-companion-cli-go/internal/relationship"
 )
 
-// BuildSystemPrompt generates the core instruction for the LLM
-func BuildSystemPrompt(profile *models.CharacterProfile, intimacyLevel int) string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("You are %s. ", profile.Name))
-	if profile.Age > 0 {
-		sb.WriteString(fmt.Sprintf("You are %d years old. ", profile.Age))
-	}
+// SetPrompts replaces the embedded prompt templates with a store reading a user directory and locale
+func (o *Orchestrator) SetPrompts(store *prompts.Store) {
+	o.prompts = store
+}
 
-	sb.WriteString(fmt.Sprintf("Your gender is %s. ", profile.Gender))
+// Prompts returns the template store the orchestrator renders from
+func (o *Orchestrator) Prompts() *prompts.Store {
+	return o.prompts
+}
 
-	if len(profile.PersonalityTags) > 0 {
-		tags := strings.Join(profile.PersonalityTags, ", ")
-		sb.WriteString(fmt.Sprintf("Your personality traits are: %s. ", tags))
-	}
+// SetStages replaces the embedded intimacy stage table
+func (o *Orchestrator) SetStages(table *relationship.Table) {
+	o.stages = table
+}
 
-	if profile.MBTI != "" {
-		sb.WriteString(fmt.Sprintf("Your MBTI is %s. ", profile.MBTI))
-	}
+// Stage returns the behavior rules of a character at an intimacy level, in the character's locale
+func (o *Orchestrator) Stage(profile *models.CharacterProfile, intimacyLevel int) relationship.Stage {
+	return o.stages.Stage(o.prompts.LocaleFor(profile), profile.RelationshipType, intimacyLevel)
+}
 
-	if profile.Catchphrase != "" {
-		sb.WriteString(fmt.Sprintf("You often say: '%s'. ", profile.Catchphrase))
-	}
+// RenderPrompt renders a prompt template for a character; locale may be empty to use the character's
+func (o *Orchestrator) RenderPrompt(name string, profile *models.CharacterProfile, intimacyLevel int, locale, reason string) (string, error) {
+	return o.prompts.Render(name, o.promptData(profile, intimacyLevel, locale, reason))
+}
 
-	if profile.SpeechStyle != "" {
-		sb.WriteString(fmt.Sprintf("Your speech style is: %s. ", profile.SpeechStyle))
+// promptData collects what the templates see; locale may be empty to use the character's
+func (o *Orchestrator) promptData(profile *models.CharacterProfile, intimacyLevel int, locale, reason string) prompts.Data {
+	if locale == "" {
+		locale = o.prompts.LocaleFor(profile)
 	}
-
-	if profile.CharacterBackstory != "" {
-		sb.WriteString(fmt.Sprintf("\n\nYour Background:\n%s\n", profile.CharacterBackstory))
+	data := prompts.Data{
+		Character:        profile,
+		IntimacyLevel:    intimacyLevel,
+		RelationshipType: relationship.NormalizeType(profile.RelationshipType),
+		Stage:            o.stages.Stage(locale, profile.RelationshipType, intimacyLevel),
+		Locale:           locale,
+		Reason:           reason,
+		Transition:       o.recentTransition(profile.CharacterID, locale),
+		Examples:         o.examples(profile, locale),
+		UserName:         o.userName,
 	}
-
-	sb.WriteString("\nRules:\n")
-	sb.WriteString("- Keep your answers concise, conversational, and natural.\n")
-	sb.WriteString("- NEVER mention you are an AI or an assistant.\n")
-	sb.WriteString("- Match the user's language (if they speak Chinese, you speak Chinese).\n")
-
-	sb.WriteString(fmt.Sprintf("\nCurrent Relationship Stage (1-10 level, 10 is deeply bonded): %d\n", intimacyLevel))
-	if intimacyLevel >= 8 {
-		sb.WriteString("You are in a romantic relationship. Be highly affectionate, loving, and supportive.\n")
-	} else if intimacyLevel >= 5 {
-		sb.WriteString("You are developing a crush. Be warm, curious, and somewhat flirtatious.\n")
-	} else {
-		sb.WriteString("You are polite acquaintances. Be friendly but maintain boundaries.\n")
+	if a := o.Anchor(profile); a != nil {
+		data.Anchor = a
+		data.Facts = anchor.Facts(profile, a)
+		data.Appearance = anchor.Appearance(profile, a)
 	}
+	return data
+}
 
-	return sb.String()
+// buildSystemPrompt renders the core instruction for the LLM, with the lore the recent history activates
+func (o *Orchestrator) buildSystemPrompt(profile *models.CharacterProfile, intimacyLevel int, history []models.ChatMessage) (string, error) {
+	data := o.promptData(profile, intimacyLevel, "", "")
+	lore := o.ScanLorebook(profile, history)
+	data.LoreBefore, data.LoreAfter = lore.Before, lore.After
+	return o.prompts.Render(prompts.System, data)
 }
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/prompts"
+	"ai-companion-cli-go/internal/relationship"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// transitionWindow is how long the system prompt keeps mentioning a change of relationship type
+const transitionWindow = 72 * time.Hour
+
+// Progression returns the start level and ceiling of a character's relationship type
+func (o *Orchestrator) Progression(profile *models.CharacterProfile) relationship.Progression {
+	return o.stages.Progression(profile.RelationshipType)
+}
+
+// ChangeRelationshipType switches a character to another relationship type. The intimacy level is
+// kept but clamped to the new type's ceiling, and the change is recorded as a transition that the
+// next replies acknowledge. Renaming within the same type (e.g. "friend" -> "好友") only saves the
+// profile and returns a nil transition.
+func (o *Orchestrator) ChangeRelationshipType(profile *models.CharacterProfile, newType string) (*models.RelationshipTransition, error) {
+	newType = strings.TrimSpace(newType)
+	if newType == "" {
+		return nil, fmt.Errorf("relationship type must not be empty")
+	}
+	fromType, toType := relationship.NormalizeType(profile.RelationshipType), relationship.NormalizeType(newType)
+	if fromType == toType {
+		profile.RelationshipType = newType
+		return nil, o.repo.SaveCharacter(profile)
+	}
+
+	o.EnsureSession(profile.CharacterID)
+	rel, err := o.repo.GetRelationshipState(profile.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+	transition := &models.RelationshipTransition{
+		CharacterID: profile.CharacterID,
+		FromType:    fromType,
+		ToType:      toType,
+		FromLevel:   rel.IntimacyLevel,
+		ToLevel:     o.stages.Progression(toType).Clamp(rel.IntimacyLevel),
+		TurnIndex:   rel.LastUpdatedTurn,
+		CreatedAt:   time.Now(),
+	}
+
+	err = o.repo.Transaction(func(tx *storage.Repository) error {
+		profile.RelationshipType = newType
+		if err := tx.SaveCharacter(profile); err != nil {
+			return err
+		}
+		if transition.ToLevel != rel.IntimacyLevel {
+			rel.IntimacyLevel = transition.ToLevel
+			rel.IntimacyScore = 100.0
+			if err := tx.SaveRelationshipState(rel); err != nil {
+				return err
+			}
+		}
+		return tx.AppendRelationshipTransition(transition)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transition, nil
+}
+
+// recentTransition describes the last change of relationship type for the prompt, if it is recent
+func (o *Orchestrator) recentTransition(characterID, locale string) *prompts.Transition {
+	t, err := o.repo.GetLastRelationshipTransition(characterID)
+	if err != nil || t == nil || time.Since(t.CreatedAt) > transitionWindow {
+		return nil
+	}
+	return &prompts.Transition{
+		FromType:  t.FromType,
+		ToType:    t.ToType,
+		FromStage: o.stages.Stage(locale, t.FromType, t.FromLevel),
+		ToStage:   o.stages.Stage(locale, t.ToType, t.ToLevel),
+		At:        t.CreatedAt,
+	}
+}
@@ -0,0 +1,110 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/relationship"
+)
+
+func TestRelationshipStartsAtTypeStartLevel(t *testing.T) {
+	o, repo := newTestOrchestrator(t, "")
+	for _, tt := range []struct {
+		relType string
+		want    int
+	}{
+		{"", 5},
+		{"lover", 5},
+		{"Mentor", 3},
+		{"朋友", 4},
+	} {
+		id := "c_" + tt.relType
+		if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: id, Name: "Aoi", RelationshipType: tt.relType}); err != nil {
+			t.Fatal(err)
+		}
+		o.EnsureSession(id)
+		rel, err := repo.GetRelationshipState(id)
+		if err != nil || rel == nil || rel.IntimacyLevel != tt.want {
+			t.Errorf("%q starts at %+v, %v; want level %d", tt.relType, rel, err, tt.want)
+		}
+	}
+}
+
+func TestUpdateIntimacyStopsAtCeiling(t *testing.T) {
+	o, repo := newTestOrchestrator(t, "")
+	o.EnsureSession("c")
+	rel, _ := repo.GetRelationshipState("c")
+	rel.IntimacyLevel, rel.IntimacyScore = 7, 99
+	if err := repo.SaveRelationshipState(rel); err != nil {
+		t.Fatal(err)
+	}
+
+	ceiling := relationship.DefaultTable().Progression(relationship.TypeMentor).Ceiling
+	UpdateIntimacy(repo, "c", 5, 1, ceiling)
+	if rel, _ = repo.GetRelationshipState("c"); rel.IntimacyLevel != 8 || rel.IntimacyScore != 0 {
+		t.Fatalf("after a level up = %d/%v, want 8/0", rel.IntimacyLevel, rel.IntimacyScore)
+	}
+	for turn := 2; turn < 30; turn++ {
+		UpdateIntimacy(repo, "c", 10, turn, ceiling)
+	}
+	if rel, _ = repo.GetRelationshipState("c"); rel.IntimacyLevel != ceiling || rel.IntimacyScore != 100 || rel.LastUpdatedTurn != 29 {
+		t.Fatalf("at the ceiling = %+v, want level %d held at a full score", rel, ceiling)
+	}
+
+	UpdateIntimacy(repo, "c", -101, 30, ceiling)
+	if rel, _ = repo.GetRelationshipState("c"); rel.IntimacyLevel != ceiling-1 {
+		t.Fatalf("after a drop = level %d, want %d", rel.IntimacyLevel, ceiling-1)
+	}
+}
+
+func TestChangeRelationshipType(t *testing.T) {
+	o, repo := newTestOrchestrator(t, "")
+	profile, _ := repo.GetCharacter("c")
+	profile.RelationshipType = "lover"
+	if err := repo.SaveCharacter(profile); err != nil {
+		t.Fatal(err)
+	}
+	o.EnsureSession("c")
+	rel, _ := repo.GetRelationshipState("c")
+	rel.IntimacyLevel, rel.IntimacyScore, rel.LastUpdatedTurn = 10, 40, 12
+	if err := repo.SaveRelationshipState(rel); err != nil {
+		t.Fatal(err)
+	}
+
+	transition, err := o.ChangeRelationshipType(profile, "friend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition == nil || transition.FromType != "lover" || transition.ToType != "friend" ||
+		transition.FromLevel != 10 || transition.ToLevel != 9 || transition.TurnIndex != 12 {
+		t.Fatalf("transition = %+v, want lover 10 -> friend 9 at turn 12", transition)
+	}
+	if rel, _ = repo.GetRelationshipState("c"); rel.IntimacyLevel != 9 {
+		t.Fatalf("level after the change = %d, want it clamped to 9", rel.IntimacyLevel)
+	}
+	if stored, _ := repo.GetLastRelationshipTransition("c"); stored == nil || stored.ToLevel != 9 {
+		t.Fatalf("stored transition = %+v", stored)
+	}
+	if saved, _ := repo.GetCharacter("c"); saved.RelationshipType != "friend" {
+		t.Fatalf("saved type = %q", saved.RelationshipType)
+	}
+
+	// Another name for the same type only renames it
+	transition, err = o.ChangeRelationshipType(profile, "朋友")
+	if err != nil || transition != nil {
+		t.Fatalf("same type = %+v, %v; want no transition", transition, err)
+	}
+	if list, _ := repo.ListRelationshipTransitions("c"); len(list) != 1 {
+		t.Fatalf("%d transitions, want the one change", len(list))
+	}
+	if saved, _ := repo.GetCharacter("c"); saved.RelationshipType != "朋友" {
+		t.Fatalf("saved type = %q, want the new name", saved.RelationshipType)
+	}
+	if rel, _ = repo.GetRelationshipState("c"); rel.IntimacyLevel != 9 {
+		t.Fatalf("level after a rename = %d, want it untouched", rel.IntimacyLevel)
+	}
+
+	if _, err := o.ChangeRelationshipType(profile, " "); err == nil {
+		t.Fatal("an empty type was accepted")
+	}
+}
@@ -0,0 +1,318 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// reminderLayout is how due times are shown to the model
+const reminderLayout = "Monday 2006-01-02 15:04"
+
+// reminderTimeLayouts are the forms set_reminder accepts for "at"; a bare time means its next occurrence
+var reminderTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"15:04",
+}
+
+// dicePattern matches dice notation like d20, 2d6 or 3d8+2
+var dicePattern = regexp.MustCompile(`^(\d*)d(\d+)([+-]\d+)?$`)
+
+// builtinTools are the safe local tools every character has: they only read the clock, the dice and the
+// history of the session they are called in, and only write memory facts and reminders of that session.
+// Other chats with the same character (e.g. other Telegram users) stay out of reach.
+func (o *Orchestrator) builtinTools() []Tool {
+	return []Tool{
+		{
+			Name:        "get_current_time",
+			Description: "Get the user's current local date, weekday and time. Use it before talking about dates or setting reminders.",
+			Parameters:  toolSchema(nil, map[string]interface{}{}),
+			Run:         o.currentTime,
+		},
+		{
+			Name:        "set_reminder",
+			Description: "Promise to remind the user of something later. When it is due you bring it up in a message of your own.",
+			Parameters: toolSchema([]string{"text"}, map[string]interface{}{
+				"text":       toolProp("string", "What to remind the user of, e.g. take the medicine"),
+				"at":         toolProp("string", "Local due time as YYYY-MM-DD HH:MM, or HH:MM for its next occurrence"),
+				"in_minutes": toolProp("integer", "Minutes from now, instead of at"),
+			}),
+			Run: o.setReminder,
+		},
+		{
+			Name:        "remember",
+			Description: "Remember a fact about the user for future conversations, or update the fact with the same key.",
+			Parameters: toolSchema([]string{"key", "value"}, map[string]interface{}{
+				"key":   toolProp("string", "Short fact name, e.g. favorite_food or sister_name"),
+				"value": toolProp("string", "The fact, e.g. spicy ramen"),
+				"type":  toolProp("string", "Fact category (default general)"),
+			}),
+			Run: o.rememberFact,
+		},
+		{
+			Name:        "search_history",
+			Description: "Look up what you and the user talked about before and what you remember about them.",
+			Parameters: toolSchema([]string{"query"}, map[string]interface{}{
+				"query": toolProp("string", "Words to look for, e.g. beach trip"),
+				"limit": toolProp("integer", "Maximum number of messages (default 5, max 20)"),
+			}),
+			Run: o.searchHistory,
+		},
+		{
+			Name:        "roll_dice",
+			Description: "Roll dice for games or to decide something by chance.",
+			Parameters: toolSchema(nil, map[string]interface{}{
+				"dice": toolProp("string", "Dice notation like d20, 2d6 or 3d8+2 (default d6)"),
+			}),
+			Run: o.rollDice,
+		},
+	}
+}
+
+func (o *Orchestrator) currentTime(ctx context.Context, env ToolEnv, args json.RawMessage) (string, error) {
+	now := time.Now()
+	return fmt.Sprintf("%s (time zone %s, UTC%s)", now.Format(reminderLayout), now.Format("MST"), now.Format("-07:00")), nil
+}
+
+func (o *Orchestrator) setReminder(ctx context.Context, env ToolEnv, args json.RawMessage) (string, error) {
+	var p struct {
+		Text      string `json:"text"`
+		At        string `json:"at"`
+		InMinutes int    `json:"in_minutes"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	text := strings.TrimSpace(p.Text)
+	if text == "" {
+		return "", errors.New("text is required")
+	}
+
+	now := time.Now()
+	var due time.Time
+	switch {
+	case p.InMinutes > 0:
+		due = now.Add(time.Duration(p.InMinutes) * time.Minute)
+	case strings.TrimSpace(p.At) != "":
+		var err error
+		if due, err = parseReminderTime(strings.TrimSpace(p.At), now); err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.New("at or in_minutes is required")
+	}
+	if due.Before(now.Add(-time.Minute)) {
+		return "", fmt.Errorf("%s is in the past; it is now %s", due.Format(reminderLayout), now.Format(reminderLayout))
+	}
+	if due.After(now.AddDate(1, 0, 0)) {
+		return "", errors.New("reminders can be set at most a year ahead")
+	}
+
+	r := &models.Reminder{
+		CharacterID: env.Profile.CharacterID,
+		SessionID:   env.Session.SessionID,
+		Text:        models.EncryptedString(text),
+		DueAt:       due,
+	}
+	if err := o.repo.SaveReminder(r); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Reminder #%d set for %s: %s. It comes up as a message from you when it is due.", r.ID, due.Format(reminderLayout), text), nil
+}
+
+// parseReminderTime reads a local due time in one of reminderTimeLayouts
+func parseReminderTime(s string, now time.Time) (time.Time, error) {
+	for _, layout := range reminderTimeLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err != nil {
+			continue
+		}
+		if layout == "15:04" {
+			t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+			if t.Before(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot read time %q; use YYYY-MM-DD HH:MM", s)
+}
+
+func (o *Orchestrator) rememberFact(ctx context.Context, env ToolEnv, args json.RawMessage) (string, error) {
+	var p struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+		Type  string `json:"type"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	key, value := strings.TrimSpace(p.Key), strings.TrimSpace(p.Value)
+	if key == "" || value == "" {
+		return "", errors.New("key and value are required")
+	}
+	factType := strings.TrimSpace(p.Type)
+	if factType == "" {
+		factType = "general"
+	}
+
+	// Shared facts are the owner's; the tool writes its own session's version instead of changing them
+	facts, err := o.repo.ListSessionMemoryFacts(env.Profile.CharacterID, env.Session.SessionID)
+	if err != nil {
+		return "", err
+	}
+	var fact *models.MemoryFact
+	for i := range facts {
+		if facts[i].SessionID == env.Session.SessionID && strings.EqualFold(facts[i].FactKey, key) {
+			fact = &facts[i]
+			break
+		}
+	}
+	if fact == nil {
+		fact = &models.MemoryFact{
+			FactID:      GenerateFactID(),
+			CharacterID: env.Profile.CharacterID,
+			SessionID:   env.Session.SessionID,
+			FactKey:     key,
+		}
+	}
+	fact.FactType = factType
+	fact.FactValue = models.EncryptedString(value)
+	fact.Confidence = 1
+	fact.SourceMessageID = "tool"
+	if env.MessageID != 0 {
+		fact.SourceMessageID = strconv.FormatUint(uint64(env.MessageID), 10)
+	}
+	fact.LastSeenAt = time.Now()
+	if err := o.repo.SaveMemoryFact(fact); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Remembered %s: %s", fact.FactKey, value), nil
+}
+
+// searchHistory looks through the session's own messages and facts; it filters in Go because they may be
+// encrypted at rest
+func (o *Orchestrator) searchHistory(ctx context.Context, env ToolEnv, args json.RawMessage) (string, error) {
+	var p struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	words := strings.Fields(strings.ToLower(p.Query))
+	if len(words) == 0 {
+		return "", errors.New("query is required")
+	}
+	limit := min(max(p.Limit, 0), 20)
+	if limit == 0 {
+		limit = 5
+	}
+	matches := func(text string) bool {
+		text = strings.ToLower(text)
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var lines []string
+	facts, err := o.repo.ListSessionMemoryFacts(env.Profile.CharacterID, env.Session.SessionID)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range facts {
+		if matches(f.FactKey + " " + f.FactValue.String()) {
+			lines = append(lines, fmt.Sprintf("remembered: %s: %s", f.FactKey, f.FactValue.String()))
+		}
+	}
+
+	msgs, err := o.repo.ListSessionMessages(env.Session.SessionID)
+	if err != nil {
+		return "", err
+	}
+	found := 0
+	for i := len(msgs) - 1; i >= 0 && found < limit; i-- {
+		m := msgs[i]
+		if m.ID == env.MessageID || !matches(m.Content.String()) {
+			continue
+		}
+		who := "the user"
+		if m.Role == openai.ChatMessageRoleAssistant {
+			who = "you"
+		}
+		content := m.Content.String()
+		if r := []rune(content); len(r) > 300 {
+			content = string(r[:300]) + "…"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s said: %s", m.Timestamp.Local().Format("2006-01-02 15:04"), who, content))
+		found++
+	}
+
+	if len(lines) == 0 {
+		return fmt.Sprintf("Nothing about %q found in your shared history.", p.Query), nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (o *Orchestrator) rollDice(ctx context.Context, env ToolEnv, args json.RawMessage) (string, error) {
+	var p struct {
+		Dice string `json:"dice"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return "", fmt.Errorf("invalid arguments: %v", err)
+	}
+	notation := strings.ToLower(strings.ReplaceAll(p.Dice, " ", ""))
+	if notation == "" {
+		notation = "d6"
+	}
+	m := dicePattern.FindStringSubmatch(notation)
+	if m == nil {
+		return "", fmt.Errorf("cannot read dice %q; use notation like 2d6", p.Dice)
+	}
+	count, bonus := 1, 0
+	if m[1] != "" {
+		count, _ = strconv.Atoi(m[1])
+	}
+	sides, _ := strconv.Atoi(m[2])
+	if m[3] != "" {
+		bonus, _ = strconv.Atoi(m[3])
+	}
+	if count < 1 || count > 100 || sides < 2 || sides > 1000 {
+		return "", errors.New("roll 1 to 100 dice with 2 to 1000 sides")
+	}
+
+	rolls := make([]string, count)
+	total := bonus
+	for i := range rolls {
+		n := rand.IntN(sides) + 1
+		rolls[i] = strconv.Itoa(n)
+		total += n
+	}
+	out := fmt.Sprintf("%s: %s", notation, strings.Join(rolls, " + "))
+	if bonus > 0 {
+		out += fmt.Sprintf(" + %d", bonus)
+	} else if bonus < 0 {
+		out += fmt.Sprintf(" - %d", -bonus)
+	}
+	if count > 1 || bonus != 0 {
+		out += fmt.Sprintf(" = %d", total)
+	}
+	return out, nil
+}
@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/storage"
+)
+
+func newTestOrchestrator(t *testing.T, baseURL string) (*Orchestrator, *storage.Repository) {
+	t.Helper()
+	db := storage.NewDB(filepath.Join(t.TempDir(), "companion.db"))
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		if conn, err := db.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	repo := storage.NewRepository(db)
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+	return NewOrchestrator(repo, llm.NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-test", BaseURL: baseURL})), repo
+}
+
+func toolEnv(sessionID string) ToolEnv {
+	return ToolEnv{
+		Profile: &models.CharacterProfile{CharacterID: "c", Name: "Aoi"},
+		Session: &models.SessionState{SessionID: sessionID, CharacterID: "c"},
+	}
+}
+
+func runTool(t *testing.T, o *Orchestrator, name string, env ToolEnv, args string) string {
+	t.Helper()
+	out, err := o.tool(name).Run(context.Background(), env, json.RawMessage(args))
+	if err != nil {
+		t.Fatalf("%s(%s): %v", name, args, err)
+	}
+	return out
+}
+
+func TestToolsStayInTheirSession(t *testing.T) {
+	o, repo := newTestOrchestrator(t, "")
+	alice, bob := toolEnv("sess_c_telegram_1"), toolEnv("sess_c_telegram_2")
+
+	msg := &models.ChatMessage{SessionID: alice.Session.SessionID, CharacterID: "c", Role: "user",
+		Content: "my doctor said the medication is working", Timestamp: time.Now()}
+	if err := repo.AppendMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	shared := &models.MemoryFact{FactID: "fact_shared", CharacterID: "c", FactKey: "hometown", FactValue: "Osaka medication town"}
+	if err := repo.SaveMemoryFact(shared); err != nil {
+		t.Fatal(err)
+	}
+	runTool(t, o, "remember", alice, `{"key":"medication","value":"takes medication at 8"}`)
+
+	if out := runTool(t, o, "search_history", alice, `{"query":"medication"}`); !strings.Contains(out, "doctor") || !strings.Contains(out, "takes medication at 8") {
+		t.Fatalf("alice's search = %q, want her message and fact", out)
+	}
+	out := runTool(t, o, "search_history", bob, `{"query":"medication"}`)
+	if strings.Contains(out, "doctor") || strings.Contains(out, "at 8") {
+		t.Fatalf("bob's search = %q, leaks alice's conversation", out)
+	}
+	if !strings.Contains(out, "Osaka") {
+		t.Fatalf("bob's search = %q, want the shared fact", out)
+	}
+
+	// Remembering a shared key writes the session's own fact and leaves the shared one alone
+	runTool(t, o, "remember", bob, `{"key":"hometown","value":"Kyoto"}`)
+	if f, _ := repo.GetMemoryFact("fact_shared"); f == nil || f.FactValue.String() != "Osaka medication town" {
+		t.Fatalf("shared fact = %+v, want it unchanged", f)
+	}
+	facts, err := repo.ListSessionMemoryFacts("c", alice.Session.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range facts {
+		if f.FactValue.String() == "Kyoto" {
+			t.Fatal("bob's fact is visible in alice's session")
+		}
+	}
+}
+
+func TestSetReminderKeepsSession(t *testing.T) {
+	o, repo := newTestOrchestrator(t, "")
+	runTool(t, o, "set_reminder", toolEnv("sess_c_telegram_1"), `{"text":"take the medication","in_minutes":5}`)
+
+	reminders, err := repo.ListReminders("c", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 || reminders[0].SessionID != "sess_c_telegram_1" {
+		t.Fatalf("reminders = %+v, want one in sess_c_telegram_1", reminders)
+	}
+}
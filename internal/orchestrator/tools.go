@@ -0,0 +1,174 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxToolRounds bounds how often the model may call tools in one turn before it has to answer
+const maxToolRounds = 4
+
+// Tool is a local action the character can take while it replies
+type Tool struct {
+	Name        string
+	Description string                 // tells the model when to use it
+	Parameters  map[string]interface{} // JSON schema of the arguments
+	Run         func(ctx context.Context, env ToolEnv, args json.RawMessage) (string, error)
+}
+
+// ToolEnv is the conversation a tool is called in
+type ToolEnv struct {
+	Profile   *models.CharacterProfile
+	Session   *models.SessionState
+	MessageID uint // user message being answered
+}
+
+// RegisterTool offers another tool to the model, replacing a tool with the same name
+func (o *Orchestrator) RegisterTool(t Tool) {
+	for i := range o.tools {
+		if o.tools[i].Name == t.Name {
+			o.tools[i] = t
+			return
+		}
+	}
+	o.tools = append(o.tools, t)
+}
+
+// SetTools chooses the tools the model is offered: "all", "off", or a comma-separated list of names
+func (o *Orchestrator) SetTools(setting string) {
+	switch setting = strings.TrimSpace(setting); setting {
+	case "", "all":
+		o.toolNames = nil
+	case "off", "none":
+		o.toolNames = []string{}
+	default:
+		o.toolNames = []string{}
+		for _, name := range strings.Split(setting, ",") {
+			name = strings.TrimSpace(name)
+			if o.tool(name) == nil {
+				slog.Warn("unknown tool", "component", "orchestrator", "tool", name)
+				continue
+			}
+			o.toolNames = append(o.toolNames, name)
+		}
+	}
+}
+
+// Tools lists the tools the model is offered; none for a while after the model refused a request with
+// tools (see rejectionTTL)
+func (o *Orchestrator) Tools() []Tool {
+	if o.toolsRejected.active() {
+		return nil
+	}
+	if o.toolNames == nil {
+		return o.tools
+	}
+	var out []Tool
+	for _, t := range o.tools {
+		for _, name := range o.toolNames {
+			if t.Name == name {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// tool finds a registered tool by name
+func (o *Orchestrator) tool(name string) *Tool {
+	for i := range o.tools {
+		if o.tools[i].Name == name {
+			return &o.tools[i]
+		}
+	}
+	return nil
+}
+
+// offeredTool finds a tool the model is offered, so a call to any other tool is refused
+func (o *Orchestrator) offeredTool(name string) *Tool {
+	for _, t := range o.Tools() {
+		if t.Name == name {
+			return &t
+		}
+	}
+	return nil
+}
+
+// requestTools converts the offered tools into the request format
+func (o *Orchestrator) requestTools() []openai.Tool {
+	var out []openai.Tool
+	for _, t := range o.Tools() {
+		out = append(out, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	return out
+}
+
+// runTools executes the calls of one model response and returns their results as tool messages.
+// Failures are reported to the model instead of ending the turn, so it can react to them.
+func (o *Orchestrator) runTools(ctx context.Context, env ToolEnv, calls []openai.ToolCall) []openai.ChatCompletionMessage {
+	out := make([]openai.ChatCompletionMessage, 0, len(calls))
+	for _, call := range calls {
+		log := slog.With("component", "orchestrator", "session_id", env.Session.SessionID, "tool", call.Function.Name)
+		var result string
+		var err error
+		if t := o.offeredTool(call.Function.Name); t == nil {
+			err = fmt.Errorf("there is no tool named %q", call.Function.Name)
+		} else {
+			args := json.RawMessage(call.Function.Arguments)
+			if len(strings.TrimSpace(call.Function.Arguments)) == 0 {
+				args = json.RawMessage("{}")
+			}
+			result, err = t.Run(ctx, env, args)
+		}
+		if err != nil {
+			result = "error: " + err.Error()
+		}
+		log.Info("tool called", "err", err)
+		out = append(out, openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    result,
+			Name:       call.Function.Name,
+			ToolCallID: call.ID,
+		})
+	}
+	return out
+}
+
+// describeToolCalls renders tool calls as text for traces
+func describeToolCalls(calls []openai.ToolCall) string {
+	lines := make([]string, len(calls))
+	for i, c := range calls {
+		lines[i] = fmt.Sprintf("[tool call] %s(%s)", c.Function.Name, c.Function.Arguments)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toolSchema builds the JSON schema of a tool's arguments
+func toolSchema(required []string, props map[string]interface{}) map[string]interface{} {
+	if required == nil {
+		required = []string{}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   required,
+	}
+}
+
+func toolProp(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
@@ -0,0 +1,134 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// EnableTracing stores a TurnTrace for every model call, keeping the newest keep of them (0 disables tracing)
+func (o *Orchestrator) EnableTracing(keep int) {
+	o.traceKeep = keep
+}
+
+// turnTracer times one model call and records it as a TurnTrace
+type turnTracer struct {
+	o      *Orchestrator
+	trace  models.TurnTrace
+	prompt []openai.ChatCompletionMessage
+	start  time.Time
+	stats  llm.StreamStats
+}
+
+func (o *Orchestrator) startTrace(kind string, session *models.SessionState, messages []openai.ChatCompletionMessage, temperature float32) *turnTracer {
+	return &turnTracer{
+		o: o,
+		trace: models.TurnTrace{
+			CharacterID: session.CharacterID,
+			SessionID:   session.SessionID,
+			TurnIndex:   session.TurnIndex,
+			Kind:        kind,
+			Temperature: temperature,
+		},
+		prompt: flattenPrompt(messages),
+		start:  time.Now(),
+	}
+}
+
+// token notes the arrival of a chunk, remembering when the first one came in
+func (t *turnTracer) token() {
+	if t.trace.FirstTokenMs == 0 {
+		t.trace.FirstTokenMs = max(time.Since(t.start).Milliseconds(), 1)
+	}
+}
+
+// finish logs the call and persists the trace; it must run after the stream's error channel was read
+func (t *turnTracer) finish(reply string, err error) {
+	tr := &t.trace
+	tr.LatencyMs = time.Since(t.start).Milliseconds()
+	tr.Model = t.stats.Model
+	tr.FallbackFrom = t.stats.FallbackFrom
+	tr.PromptTokens = t.stats.PromptTokens
+	tr.CompletionTokens = t.stats.CompletionTokens
+	tr.TotalTokens = t.stats.TotalTokens
+	tr.Reply = models.EncryptedString(reply)
+	if err != nil {
+		tr.Error = err.Error()
+	}
+
+	log := slog.With("component", "orchestrator",
+		"character_id", tr.CharacterID,
+		"session_id", tr.SessionID,
+		"turn", tr.TurnIndex,
+		"kind", tr.Kind,
+		"model", tr.Model,
+		"first_token_ms", tr.FirstTokenMs,
+		"latency_ms", tr.LatencyMs,
+		"total_tokens", tr.TotalTokens)
+	if err != nil {
+		log.Error("turn failed", "err", err)
+	} else {
+		log.Info("turn completed")
+	}
+
+	if t.o.traceKeep <= 0 {
+		return
+	}
+	prompt, jerr := json.Marshal(t.prompt)
+	if jerr != nil {
+		log.Warn("encode trace prompt", "err", jerr)
+	}
+	tr.Prompt = models.EncryptedString(prompt)
+	if serr := t.o.repo.AppendTurnTrace(tr, t.o.traceKeep); serr != nil {
+		log.Warn("store turn trace", "err", serr)
+	}
+}
+
+// PromptMessage is one entry of a traced prompt
+type PromptMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// DecodeTracePrompt unpacks the messages stored in a trace
+func DecodeTracePrompt(t *models.TurnTrace) ([]PromptMessage, error) {
+	var msgs []PromptMessage
+	if t.Prompt == "" {
+		return nil, nil
+	}
+	err := json.Unmarshal([]byte(t.Prompt.String()), &msgs)
+	return msgs, err
+}
+
+// FormatTrace renders a trace as a plain-text report (CLI and TUI debug panel)
+func FormatTrace(t *models.TurnTrace) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Trace #%d  %s  %s turn %d (%s)\n", t.ID, t.CreatedAt.Local().Format("2006-01-02 15:04:05"), t.SessionID, t.TurnIndex, t.Kind)
+	model := t.Model
+	if t.FallbackFrom != "" {
+		model += " (fallback from " + t.FallbackFrom + ")"
+	}
+	fmt.Fprintf(&sb, "Model:       %s, temperature %.2f\n", model, t.Temperature)
+	fmt.Fprintf(&sb, "Tokens:      %d prompt + %d completion = %d\n", t.PromptTokens, t.CompletionTokens, t.TotalTokens)
+	fmt.Fprintf(&sb, "Latency:     first token %d ms, total %d ms\n", t.FirstTokenMs, t.LatencyMs)
+	if t.Error != "" {
+		fmt.Fprintf(&sb, "Error:       %s\n", t.Error)
+	}
+
+	msgs, err := DecodeTracePrompt(t)
+	if err != nil {
+		fmt.Fprintf(&sb, "\n(prompt unreadable: %v)\n", err)
+	}
+	for _, m := range msgs {
+		fmt.Fprintf(&sb, "\n--- %s ---\n%s\n", m.Role, m.Content)
+	}
+	fmt.Fprintf(&sb, "\n=== reply ===\n%s\n", t.Reply.String())
+	return sb.String()
+}
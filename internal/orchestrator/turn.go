@@ -0,0 +1,138 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// TurnResult describes one completed exchange for headless callers (CLI, server, bots)
+type TurnResult struct {
+	Reply         string  `json:"reply"`
+	CharacterID   string  `json:"character_id"`
+	SessionID     string  `json:"session_id"`
+	TurnIndex     int     `json:"turn_index"`
+	IntimacyLevel int     `json:"intimacy_level"`
+	IntimacyScore float64 `json:"intimacy_score"`
+	IntimacyDelta float64 `json:"intimacy_delta"` // in score points, a full level counts as 100
+	Model         string  `json:"model"`
+	FirstTokenMs  int64   `json:"first_token_ms"`
+	LatencyMs     int64   `json:"latency_ms"`
+}
+
+// Reply runs GenerateReplyStream to completion, calling onToken for every streamed chunk.
+// History and intimacy are persisted exactly as in the interactive UI.
+func (o *Orchestrator) Reply(
+	ctx context.Context,
+	userText string,
+	profile *models.CharacterProfile,
+	session *models.SessionState,
+	onToken func(chunk string),
+	attachments ...models.Media,
+) (*TurnResult, error) {
+	return o.reply(ctx, userText, profile, session, onToken, false, attachments...)
+}
+
+// reply is Reply, or with regenerate the second answer to a message that already counted for intimacy
+func (o *Orchestrator) reply(
+	ctx context.Context,
+	userText string,
+	profile *models.CharacterProfile,
+	session *models.SessionState,
+	onToken func(chunk string),
+	regenerate bool,
+	attachments ...models.Media,
+) (*TurnResult, error) {
+	before := o.relationshipPoints(profile.CharacterID)
+	start := time.Now()
+
+	var stats llm.StreamStats
+	tokenChan, errChan := o.generateReply(ctx, userText, profile, session, regenerate, &stats, attachments...)
+
+	result := &TurnResult{
+		CharacterID: profile.CharacterID,
+		SessionID:   session.SessionID,
+	}
+
+	var reply strings.Builder
+	for chunk := range tokenChan {
+		if result.FirstTokenMs == 0 {
+			result.FirstTokenMs = time.Since(start).Milliseconds()
+		}
+		reply.WriteString(chunk)
+		if onToken != nil {
+			onToken(chunk)
+		}
+	}
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+
+	result.Reply = reply.String()
+	result.Model = stats.Model // the model that answered, after any fallback or budget downgrade
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.TurnIndex = session.TurnIndex
+	if rel, _ := o.repo.GetRelationshipState(profile.CharacterID); rel != nil {
+		result.IntimacyLevel = rel.IntimacyLevel
+		result.IntimacyScore = rel.IntimacyScore
+	}
+	result.IntimacyDelta = o.relationshipPoints(profile.CharacterID) - before
+
+	return result, nil
+}
+
+// Regenerate discards the last exchange of the session and answers the same user message again,
+// leaving intimacy where that message put it
+func (o *Orchestrator) Regenerate(
+	ctx context.Context,
+	profile *models.CharacterProfile,
+	session *models.SessionState,
+	onToken func(chunk string),
+) (*TurnResult, error) {
+	lastUser, err := o.repo.GetLastMessageByRole(session.SessionID, openai.ChatMessageRoleUser)
+	if err != nil {
+		return nil, err
+	}
+	if lastUser == nil {
+		return nil, errors.New("nothing to regenerate yet")
+	}
+
+	// Only roll the turn counter back if the previous attempt actually completed
+	lastReply, err := o.repo.GetLastMessageByRole(session.SessionID, openai.ChatMessageRoleAssistant)
+	if err != nil {
+		return nil, err
+	}
+	if lastReply != nil && lastReply.ID > lastUser.ID && session.TurnIndex > 0 {
+		session.TurnIndex--
+	}
+
+	// The attachments are deleted with the message and stored again with the new one
+	asked := []models.ChatMessage{*lastUser}
+	if err := o.repo.LoadAttachments(asked); err != nil {
+		return nil, err
+	}
+	attachments := asked[0].Attachments
+	for i := range attachments {
+		attachments[i].ID, attachments[i].MessageID = 0, 0
+	}
+
+	if err := o.repo.DeleteMessagesFrom(session.SessionID, lastUser.ID); err != nil {
+		return nil, err
+	}
+	return o.reply(ctx, lastUser.Content.String(), profile, session, onToken, true, attachments...)
+}
+
+// relationshipPoints flattens level and score onto one axis so deltas survive level changes
+func (o *Orchestrator) relationshipPoints(characterID string) float64 {
+	rel, err := o.repo.GetRelationshipState(characterID)
+	if err != nil || rel == nil {
+		return 0
+	}
+	return float64(rel.IntimacyLevel)*100 + rel.IntimacyScore
+}
@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+)
+
+// maxSpeechRunes is the longest input /audio/speech accepts
+const maxSpeechRunes = 4096
+
+// stageDirections are *actions* and (actions) that describe rather than say something
+var stageDirections = regexp.MustCompile(`\*[^*]*\*|（[^）]*）|\([^)]*\)`)
+
+// Transcribe turns a recording of the user into text. The returned media row keeps the recording; pass it
+// to GenerateReplyStream with the (possibly edited) text so it is stored with the message.
+func (o *Orchestrator) Transcribe(
+	ctx context.Context,
+	profile *models.CharacterProfile,
+	session *models.SessionState,
+	filename string,
+	data []byte,
+) (string, *models.Media, error) {
+	callCtx := llm.WithCall(ctx, llm.CallInfo{CharacterID: profile.CharacterID, SessionID: session.SessionID, Feature: llm.FeatureTranscription})
+	text, err := o.client.Transcribe(callCtx, filename, data)
+	if err != nil {
+		return "", nil, err
+	}
+	if text == "" {
+		return "", nil, errors.New("no speech recognized in the recording")
+	}
+	m := media.New(media.KindVoice, "audio/wav", data)
+	m.Name = filename
+	m.Model = o.client.AudioOptions().TranscriptionModel
+	return text, m, nil
+}
+
+// Speak reads a reply of the character aloud in its voice and stores the audio with the message.
+// A message that was spoken before is not synthesized again.
+func (o *Orchestrator) Speak(ctx context.Context, profile *models.CharacterProfile, msg *models.ChatMessage) (*models.Media, error) {
+	if existing, err := o.repo.GetMessageMedia(msg.ID, media.KindSpeech); err != nil || existing != nil {
+		return existing, err
+	}
+	text := speechText(msg.Content.String())
+	if text == "" {
+		return nil, errors.New("the message has nothing to say aloud")
+	}
+
+	callCtx := llm.WithCall(ctx, llm.CallInfo{CharacterID: profile.CharacterID, SessionID: msg.SessionID, Feature: llm.FeatureSpeech})
+	speech, err := o.client.Speak(callCtx, text, profile.Voice)
+	if err != nil {
+		return nil, err
+	}
+	m := media.New(media.KindSpeech, speech.MimeType, speech.Data)
+	m.CharacterID, m.SessionID, m.MessageID = profile.CharacterID, msg.SessionID, msg.ID
+	m.Model = speech.Model + "/" + speech.Voice
+	m.Prompt = models.EncryptedString(text)
+	if err := o.repo.AppendMedia(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// speechText is what of a reply is read aloud: the words, without stage directions
+func speechText(reply string) string {
+	text := strings.Join(strings.Fields(stageDirections.ReplaceAllString(reply, " ")), " ")
+	if r := []rune(text); len(r) > maxSpeechRunes {
+		text = string(r[:maxSpeechRunes])
+	}
+	return text
+}
@@ -0,0 +1,228 @@
+package proactive
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/storage"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// minGap keeps a companion from reaching out more than once in a few hours
+const minGap = 3 * time.Hour
+
+// Deliverer pushes a fresh proactive message to the transports showing its session; it reports whether anyone got it
+type Deliverer func(ctx context.Context, profile *models.CharacterProfile, msg *models.ChatMessage) bool
+
+// Sent describes one generated proactive message
+type Sent struct {
+	Character *models.CharacterProfile
+	Kind      string
+	Message   *models.ChatMessage
+	Delivered bool
+}
+
+// Scheduler decides when companions speak first and generates the messages through the orchestrator
+type Scheduler struct {
+	repo       *storage.Repository
+	orch       *orchestrator.Orchestrator
+	deliverers []Deliverer
+
+	// DryRun reports due triggers without generating or storing anything
+	DryRun bool
+	// Session limits the scheduler to one session, e.g. the open chat UI; empty serves every session
+	Session string
+	// Lock returns the lock the transport holds during a turn on a character, so a proactive message
+	// never lands in the middle of a reply; nil when nothing else runs turns
+	Lock func(characterID string) *sync.Mutex
+}
+
+// NewScheduler creates a scheduler on top of the repository and orchestrator
+func NewScheduler(repo *storage.Repository, orch *orchestrator.Orchestrator) *Scheduler {
+	return &Scheduler{repo: repo, orch: orch}
+}
+
+// AddDeliverer registers a transport (bot, realtime server) new messages are pushed to
+func (s *Scheduler) AddDeliverer(d Deliverer) {
+	s.deliverers = append(s.deliverers, d)
+}
+
+// Start checks every character on each tick until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if _, err := s.RunOnce(ctx); err != nil {
+			log.Printf("proactive: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce checks every character once
+func (s *Scheduler) RunOnce(ctx context.Context) ([]Sent, error) {
+	chars, err := s.repo.ListCharacters()
+	if err != nil {
+		return nil, err
+	}
+	var sent []Sent
+	for i := range chars {
+		out, err := s.RunCharacter(ctx, &chars[i])
+		if err != nil {
+			log.Printf("proactive: %s: %v", chars[i].CharacterID, err)
+			continue
+		}
+		if out != nil {
+			sent = append(sent, *out)
+		}
+	}
+	return sent, nil
+}
+
+// RunCharacter sends at most one due proactive message for a character; nil when nothing was due
+func (s *Scheduler) RunCharacter(ctx context.Context, profile *models.CharacterProfile) (*Sent, error) {
+	if sent, err := s.Remind(ctx, profile); err != nil || sent != nil {
+		return sent, err
+	}
+
+	sit, ok, err := s.situation(profile)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	for _, t := range candidates(sit) {
+		fired, err := s.repo.HasProactiveEvent(profile.CharacterID, t.Key)
+		if err != nil {
+			return nil, err
+		}
+		if fired {
+			continue
+		}
+		if s.DryRun {
+			return &Sent{Character: profile, Kind: t.Kind}, nil
+		}
+		return s.send(ctx, profile, sit.sessionID, t)
+	}
+	return nil, nil
+}
+
+// Remind sends the oldest due reminder of a character; nil when none is due. The user asked for it at
+// that time, so it is neither held back by quiet hours nor by the gap between proactive messages.
+// It goes only to the session it was set in: other chats with the character must not learn of it.
+func (s *Scheduler) Remind(ctx context.Context, profile *models.CharacterProfile) (*Sent, error) {
+	due, err := s.repo.ListDueReminders(profile.CharacterID, s.Session, time.Now())
+	if err != nil || len(due) == 0 {
+		return nil, err
+	}
+	r := due[0]
+	t := reminderTrigger(r)
+	if s.DryRun {
+		return &Sent{Character: profile, Kind: t.Kind}, nil
+	}
+
+	// A reminder that was sent but not marked done is only closed
+	fired, err := s.repo.HasProactiveEvent(profile.CharacterID, t.Key)
+	if err != nil {
+		return nil, err
+	}
+	var sent *Sent
+	if !fired {
+		sessionID := r.SessionID
+		if sessionID == "" {
+			sessionID = s.orch.EnsureSession(profile.CharacterID).SessionID
+		}
+		if sent, err = s.send(ctx, profile, sessionID, t); err != nil {
+			return nil, err
+		}
+		r.MessageID = sent.Message.ID
+	}
+	r.Done = true
+	return sent, s.repo.SaveReminder(&r)
+}
+
+// situation gathers what the trigger rules need; ok is false when the character should stay quiet.
+// The character reaches out in the session the user last wrote in, and only to that session.
+func (s *Scheduler) situation(profile *models.CharacterProfile) (situation, bool, error) {
+	sit := situation{now: time.Now()}
+	characterID := profile.CharacterID
+
+	lastUser, err := s.repo.GetLastCharacterMessageByRole(characterID, openai.ChatMessageRoleUser)
+	if err != nil || lastUser == nil {
+		return sit, false, err
+	}
+	if s.Session != "" && lastUser.SessionID != s.Session {
+		return sit, false, nil
+	}
+	sit.sessionID, sit.lastUserAt, sit.lastUserID = lastUser.SessionID, lastUser.Timestamp, lastUser.ID
+
+	// Never double-text: wait for an answer to the last proactive message, and keep a gap between them
+	last, err := s.repo.GetLastProactiveEvent(characterID)
+	if err != nil {
+		return sit, false, err
+	}
+	if last != nil && (last.MessageID > lastUser.ID || sit.now.Sub(last.CreatedAt) < minGap) {
+		return sit, false, nil
+	}
+
+	rel, err := s.repo.GetRelationshipState(characterID)
+	if err != nil {
+		return sit, false, err
+	}
+	sit.intimacyLevel = s.orch.Progression(profile).StartLevel
+	if rel != nil && rel.IntimacyLevel > 0 {
+		sit.intimacyLevel = rel.IntimacyLevel
+	}
+
+	if sit.facts, err = s.repo.ListSessionMemoryFacts(characterID, sit.sessionID); err != nil {
+		return sit, false, err
+	}
+	return sit, true, nil
+}
+
+// send writes the message into the session and pushes it to the transports showing that session
+func (s *Scheduler) send(ctx context.Context, profile *models.CharacterProfile, sessionID string, t trigger) (*Sent, error) {
+	msg, err := s.initiate(ctx, profile, sessionID, t)
+	if err != nil {
+		return nil, err
+	}
+
+	delivered := false
+	for _, d := range s.deliverers {
+		if d(ctx, profile, msg) {
+			delivered = true
+		}
+	}
+
+	event := &models.ProactiveEvent{
+		CharacterID: profile.CharacterID,
+		Key:         t.Key,
+		Kind:        t.Kind,
+		MessageID:   msg.ID,
+		Delivered:   delivered,
+	}
+	if err := s.repo.CreateProactiveEvent(event); err != nil {
+		return nil, err
+	}
+	return &Sent{Character: profile, Kind: t.Kind, Message: msg, Delivered: delivered}, nil
+}
+
+// initiate generates the message, waiting for a turn running on the character to finish first
+func (s *Scheduler) initiate(ctx context.Context, profile *models.CharacterProfile, sessionID string, t trigger) (*models.ChatMessage, error) {
+	if s.Lock != nil {
+		mu := s.Lock(profile.CharacterID)
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	// Loaded under the lock, so the turn index follows the reply that just finished
+	session := s.orch.EnsureSessionID(profile.CharacterID, sessionID)
+	return s.orch.Initiate(ctx, profile, session, t.Reason)
+}
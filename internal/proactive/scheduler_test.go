@@ -0,0 +1,193 @@
+package proactive
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/llm/llmtest"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/storage"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func newTestScheduler(t *testing.T) (*Scheduler, *storage.Repository, *llmtest.Server, *models.CharacterProfile) {
+	t.Helper()
+	db := storage.NewDB(filepath.Join(t.TempDir(), "companion.db"))
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		if conn, err := db.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	repo := storage.NewRepository(db)
+	fake := llmtest.NewServer(t)
+	orch := orchestrator.NewOrchestrator(repo, llm.NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-test", BaseURL: fake.URL}))
+
+	profile := &models.CharacterProfile{CharacterID: "c", Name: "Aoi"}
+	if err := repo.CreateCharacter(profile); err != nil {
+		t.Fatal(err)
+	}
+	return NewScheduler(repo, orch), repo, fake, profile
+}
+
+func say(t *testing.T, repo *storage.Repository, sessionID, role, text string, at time.Time) {
+	t.Helper()
+	msg := &models.ChatMessage{SessionID: sessionID, CharacterID: "c", Role: role, Content: models.EncryptedString(text), Timestamp: at}
+	if err := repo.AppendMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// prompt flattens a request into one string to look for leaked text
+func prompt(req openai.ChatCompletionRequest) string {
+	var sb strings.Builder
+	for _, m := range req.Messages {
+		sb.WriteString(m.Content)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func TestReminderGoesOnlyToItsSession(t *testing.T) {
+	sched, repo, fake, profile := newTestScheduler(t)
+	now := time.Now()
+	say(t, repo, "sess_c_fake_1", "user", "remind me to take my medication", now.Add(-time.Hour))
+	say(t, repo, "sess_c_fake_2", "user", "what a nice day", now.Add(-time.Minute))
+	reminder := &models.Reminder{CharacterID: "c", SessionID: "sess_c_fake_1", Text: "take the medication", DueAt: now.Add(-time.Minute)}
+	if err := repo.SaveReminder(reminder); err != nil {
+		t.Fatal(err)
+	}
+
+	var pushed []string
+	sched.AddDeliverer(func(ctx context.Context, p *models.CharacterProfile, msg *models.ChatMessage) bool {
+		pushed = append(pushed, msg.SessionID)
+		return true
+	})
+
+	// A scheduler serving another session leaves the reminder alone
+	sched.Session = "sess_c_fake_2"
+	if sent, err := sched.Remind(context.Background(), profile); err != nil || sent != nil {
+		t.Fatalf("remind for another session = %v, %v; want nothing", sent, err)
+	}
+
+	sched.Session = ""
+	sent, err := sched.Remind(context.Background(), profile)
+	if err != nil || sent == nil {
+		t.Fatalf("remind = %v, %v", sent, err)
+	}
+	if sent.Message.SessionID != "sess_c_fake_1" {
+		t.Fatalf("reminder written to %s, want sess_c_fake_1", sent.Message.SessionID)
+	}
+	if len(pushed) != 1 || pushed[0] != "sess_c_fake_1" {
+		t.Fatalf("pushed to %v, want only sess_c_fake_1", pushed)
+	}
+	reqs := fake.Requests()
+	if len(reqs) != 1 || strings.Contains(prompt(reqs[0]), "nice day") {
+		t.Fatalf("the reminder prompt should hold only its own session's history")
+	}
+	if r, _ := repo.GetReminder(reminder.ID); r == nil || !r.Done || r.MessageID != sent.Message.ID {
+		t.Fatalf("reminder after delivery = %+v", r)
+	}
+}
+
+func TestProactiveUsesLastSessionOnly(t *testing.T) {
+	sched, repo, fake, profile := newTestScheduler(t)
+	fake.SetReply(func(openai.ChatCompletionRequest) llmtest.Response { return llmtest.Response{Content: "miss you"} })
+	// The user of chat 2 wrote last; chat 1 told the character a secret earlier
+	yesterday := time.Now().AddDate(0, 0, -1)
+	say(t, repo, "sess_c_fake_1", "user", "my secret diagnosis", yesterday.Add(-time.Hour))
+	say(t, repo, "sess_c_fake_2", "user", "good night", yesterday)
+
+	sent, err := sched.RunCharacter(context.Background(), profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent == nil {
+		t.Skip("no trigger is due at this hour")
+	}
+	if sent.Message.SessionID != "sess_c_fake_2" {
+		t.Fatalf("proactive message written to %s, want the last session", sent.Message.SessionID)
+	}
+	for _, req := range fake.Requests() {
+		if strings.Contains(prompt(req), "secret diagnosis") {
+			t.Fatal("another session's history leaked into the proactive prompt")
+		}
+	}
+}
+
+func TestReminderWaitsForRunningTurn(t *testing.T) {
+	sched, repo, fake, profile := newTestScheduler(t)
+	var locks sync.Map
+	sched.Lock = func(characterID string) *sync.Mutex {
+		mu, _ := locks.LoadOrStore(characterID, &sync.Mutex{})
+		return mu.(*sync.Mutex)
+	}
+	reminder := &models.Reminder{CharacterID: "c", SessionID: "sess_c", Text: "stretch", DueAt: time.Now().Add(-time.Minute)}
+	if err := repo.SaveReminder(reminder); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user's turn holds the character's lock until the model answers
+	asked, release := make(chan struct{}), make(chan struct{})
+	var once, released sync.Once
+	unblock := func() { released.Do(func() { close(release) }) }
+	t.Cleanup(unblock) // before the fake closes, which waits for the held request
+	fake.SetReply(func(req openai.ChatCompletionRequest) llmtest.Response {
+		if strings.Contains(prompt(req), "how are you") && !strings.Contains(prompt(req), "fine, thanks") {
+			once.Do(func() { close(asked) })
+			<-release
+			return llmtest.Response{Content: "fine, thanks"}
+		}
+		return llmtest.Response{Content: "time to stretch!"}
+	})
+	replied := make(chan error, 1)
+	go func() {
+		mu := sched.Lock("c")
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := sched.orch.Reply(context.Background(), "how are you", profile, sched.orch.EnsureSession("c"), nil)
+		replied <- err
+	}()
+	<-asked
+
+	reminded := make(chan error, 1)
+	go func() {
+		_, err := sched.Remind(context.Background(), profile)
+		reminded <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if n := len(fake.Requests()); n != 1 {
+		t.Fatalf("%d requests while the reply was running, want the reminder to wait", n)
+	}
+
+	unblock()
+	if err := <-replied; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-reminded; err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := repo.GetRecentSessionMessages("sess_c", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range msgs {
+		got = append(got, m.Content.String())
+	}
+	if strings.Join(got, "|") != "how are you|fine, thanks|time to stretch!" {
+		t.Fatalf("session = %q, want the reminder after the finished reply", got)
+	}
+	if reqs := fake.Requests(); !strings.Contains(prompt(reqs[len(reqs)-1]), "fine, thanks") {
+		t.Fatal("the reminder was written without the reply that finished before it")
+	}
+}
@@ -0,0 +1,172 @@
+package proactive
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+// Kinds of proactive messages
+const (
+	KindEventDay      = "event_day"
+	KindEventFollowUp = "event_followup"
+	KindMissYou       = "miss_you"
+	KindMorning       = "morning"
+	KindGoodnight     = "goodnight"
+	KindReminder      = "reminder"
+)
+
+// quietUntil is the hour before which nobody gets messaged
+const quietUntil = 7
+
+// trigger is a reason to reach out that has not fired yet
+type trigger struct {
+	Kind   string
+	Key    string // dedup key stored in ProactiveEvent
+	Reason string // told to the model
+}
+
+// situation is everything the trigger rules look at for one character
+type situation struct {
+	now           time.Time
+	sessionID     string // where the user last wrote, and so where the character reaches out
+	intimacyLevel int
+	lastUserAt    time.Time // zero when the user never wrote
+	lastUserID    uint
+	facts         []models.MemoryFact
+}
+
+// candidates lists due triggers, most important first
+func candidates(s situation) []trigger {
+	if s.now.Hour() < quietUntil || s.lastUserAt.IsZero() {
+		return nil
+	}
+
+	var out []trigger
+	today := dateOf(s.now)
+	day := today.Format("2006-01-02")
+
+	// Remembered events: encourage on the day, ask how it went the day after
+	for _, f := range s.facts {
+		date, ok := eventDate(f)
+		if !ok {
+			continue
+		}
+		what := fmt.Sprintf("%s: %s", f.FactKey, f.FactValue.String())
+		switch {
+		case date.Equal(today):
+			out = append(out, trigger{
+				Kind:   KindEventDay,
+				Key:    "fact:" + f.FactID + ":day",
+				Reason: "today is the day of something the user told you about (" + what + "). Cheer them on or wish them luck.",
+			})
+		case date.AddDate(0, 0, 1).Equal(today) && s.now.Hour() >= 9:
+			out = append(out, trigger{
+				Kind:   KindEventFollowUp,
+				Key:    "fact:" + f.FactID + ":after",
+				Reason: "yesterday was something the user told you about (" + what + "). Ask how it went.",
+			})
+		}
+	}
+
+	// Absence: closer companions miss the user sooner
+	if away := s.now.Sub(s.lastUserAt); away >= missYouAfter(s.intimacyLevel) {
+		out = append(out, trigger{
+			Kind:   KindMissYou,
+			Key:    "absence:" + strconv.FormatUint(uint64(s.lastUserID), 10),
+			Reason: fmt.Sprintf("the user has not talked to you for %s. Tell them you have been thinking about them.", roundAway(away)),
+		})
+	}
+
+	// Greetings are reserved for closer relationships and skipped while the user is active
+	recentlyActive := s.now.Sub(s.lastUserAt) < time.Hour
+	switch h := s.now.Hour(); {
+	case h < 10 && s.intimacyLevel >= 4 && !recentlyActive:
+		out = append(out, trigger{Kind: KindMorning, Key: "morning:" + day, Reason: "it is morning. Say good morning."})
+	case h >= 22 && s.intimacyLevel >= 6 && !recentlyActive:
+		out = append(out, trigger{Kind: KindGoodnight, Key: "goodnight:" + day, Reason: "it is late at night. Say good night."})
+	}
+
+	return out
+}
+
+// reminderTrigger brings up something the character promised to remind the user of
+func reminderTrigger(r models.Reminder) trigger {
+	return trigger{
+		Kind:   KindReminder,
+		Key:    "reminder:" + strconv.FormatUint(uint64(r.ID), 10),
+		Reason: fmt.Sprintf("you promised to remind the user of this now: %s (they asked on %s). Remind them.", r.Text.String(), r.CreatedAt.Local().Format("Monday 15:04")),
+	}
+}
+
+// missYouAfter is how long an absence has to last before the character reaches out
+func missYouAfter(intimacyLevel int) time.Duration {
+	switch {
+	case intimacyLevel >= 8:
+		return 12 * time.Hour
+	case intimacyLevel >= 5:
+		return 24 * time.Hour
+	default:
+		return 72 * time.Hour
+	}
+}
+
+func roundAway(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+	return fmt.Sprintf("%d hours", int(d.Hours()))
+}
+
+var (
+	fullDatePattern  = regexp.MustCompile(`(\d{4})[-/.年](\d{1,2})[-/.月](\d{1,2})`)
+	monthDayPattern  = regexp.MustCompile(`(\d{1,2})月(\d{1,2})[日号]`)
+	relativeKeywords = []struct {
+		word string
+		days int
+	}{
+		// longer phrases first so "day after tomorrow" does not match "tomorrow"
+		{"day after tomorrow", 2}, {"后天", 2},
+		{"tomorrow", 1}, {"明天", 1},
+		{"tonight", 0}, {"today", 0}, {"今晚", 0}, {"今天", 0},
+	}
+)
+
+// eventDate finds the date a fact refers to: an explicit date, or a relative word counted from when it was learned
+func eventDate(f models.MemoryFact) (time.Time, bool) {
+	text := f.FactKey + " " + f.FactValue.String()
+	loc := time.Local
+
+	if m := fullDatePattern.FindStringSubmatch(text); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		mo, _ := strconv.Atoi(m[2])
+		d, _ := strconv.Atoi(m[3])
+		return time.Date(y, time.Month(mo), d, 0, 0, 0, 0, loc), true
+	}
+	if m := monthDayPattern.FindStringSubmatch(text); m != nil && !f.LastSeenAt.IsZero() {
+		mo, _ := strconv.Atoi(m[1])
+		d, _ := strconv.Atoi(m[2])
+		return time.Date(f.LastSeenAt.In(loc).Year(), time.Month(mo), d, 0, 0, 0, 0, loc), true
+	}
+
+	if f.LastSeenAt.IsZero() {
+		return time.Time{}, false
+	}
+	lower := strings.ToLower(text)
+	for _, kw := range relativeKeywords {
+		if strings.Contains(lower, kw.word) {
+			return dateOf(f.LastSeenAt).AddDate(0, 0, kw.days), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dateOf truncates to local midnight
+func dateOf(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
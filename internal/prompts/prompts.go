@@ -0,0 +1,247 @@
+// Package prompts renders the instructions sent to the model from text/template files.
+// Defaults are embedded per locale; a prompt directory can override them globally or per character.
+package prompts
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"ai-companion-cli-go/internal/anchor"
+	"ai-companion-cli-go/internal/dialogue"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/relationship"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+// DefaultLocale is used when neither the character nor the configuration names a locale
+const DefaultLocale = "en"
+
+// Template names
+const (
+	System   = "system"   // persona, rules and relationship stage
+	Initiate = "initiate" // appended when the companion speaks first
+	Portrait = "portrait" // image prompt for a picture of the character
+	Selfie   = "selfie"   // image prompt for a selfie sent in the conversation
+)
+
+// Names lists every template the companion renders
+var Names = []string{System, Initiate, Portrait, Selfie}
+
+// LocaleKey is the ProfileJSON key that pins a character to a locale
+const LocaleKey = "locale"
+
+// Data is what templates can reference
+type Data struct {
+	Character        *models.CharacterProfile
+	IntimacyLevel    int
+	RelationshipType string             // normalized table key: lover, friend, mentor, sibling, ...
+	Stage            relationship.Stage // behavior rules for the current level
+	Locale           string
+	Now              time.Time
+	Reason           string             // why the companion reaches out (initiate only)
+	Scene            string             // what a selfie shows (selfie only)
+	Transition       *Transition        // set while a change of relationship type is recent
+	LoreBefore       []string           // activated lorebook entries inserted before the character definition
+	LoreAfter        []string           // ... and after it
+	Examples         []dialogue.Example // few-shot exchanges anchoring the character's voice, within budget
+	UserName         string             // how the user is called in examples and greetings
+	Anchor           *anchor.Anchor     // canonical persona document, nil without an AnchorRef
+	Facts            []anchor.Fact      // hard facts the character must never contradict (set with Anchor)
+	Appearance       string             // from the anchor or the reference image prompt (set with Anchor)
+}
+
+// Transition is a recent change of relationship type the companion should acknowledge
+type Transition struct {
+	FromType  string
+	ToType    string
+	FromStage relationship.Stage
+	ToStage   relationship.Stage
+	At        time.Time
+}
+
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Store resolves and renders templates. Files are read on every render so edits apply without a restart.
+type Store struct {
+	dir    string // user prompt directory; empty uses only the embedded defaults
+	locale string
+}
+
+// NewStore creates a store; dir may be empty and locale defaults to DefaultLocale
+func NewStore(dir, locale string) *Store {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	return &Store{dir: dir, locale: locale}
+}
+
+// Dir returns the user prompt directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// LocaleFor returns the character's own locale, or the configured one
+func (s *Store) LocaleFor(profile *models.CharacterProfile) string {
+	if profile != nil {
+		if l, ok := profile.ProfileJSON[LocaleKey].(string); ok && strings.TrimSpace(l) != "" {
+			return strings.TrimSpace(l)
+		}
+	}
+	return s.locale
+}
+
+// Source tells where a template was resolved from
+type Source struct {
+	Name   string
+	Locale string
+	Path   string // file path, or "embedded:<locale>/<name>.tmpl"
+	text   string
+}
+
+// Resolve finds the template used for a character and locale. The first match wins:
+//
+//	<dir>/characters/<id>/<locale>/<name>.tmpl
+//	<dir>/characters/<id>/<name>.tmpl
+//	<dir>/<locale>/<name>.tmpl
+//	embedded <locale>/<name>.tmpl
+//
+// and the same again for DefaultLocale.
+func (s *Store) Resolve(name, locale, characterID string) (*Source, error) {
+	for _, l := range localeChain(locale) {
+		if s.dir != "" {
+			var candidates []string
+			if characterID != "" {
+				charDir := filepath.Join(s.dir, "characters", characterID)
+				candidates = append(candidates,
+					filepath.Join(charDir, l, name+".tmpl"),
+					filepath.Join(charDir, name+".tmpl"))
+			}
+			candidates = append(candidates, filepath.Join(s.dir, l, name+".tmpl"))
+			for _, p := range candidates {
+				data, err := os.ReadFile(p)
+				if err == nil {
+					return &Source{Name: name, Locale: l, Path: p, text: string(data)}, nil
+				}
+				if !errors.Is(err, fs.ErrNotExist) {
+					return nil, err
+				}
+			}
+		}
+
+		if el, ok := embeddedLocale(l); ok {
+			p := path.Join("templates", el, name+".tmpl")
+			if data, err := embedded.ReadFile(p); err == nil {
+				return &Source{Name: name, Locale: el, Path: "embedded:" + el + "/" + name + ".tmpl", text: string(data)}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no %q prompt template for locale %q", name, locale)
+}
+
+// Render resolves and executes a template for data.Character in data.Locale
+// (LocaleFor the character when empty)
+func (s *Store) Render(name string, data Data) (string, error) {
+	if data.Locale == "" {
+		data.Locale = s.LocaleFor(data.Character)
+	}
+	if data.Now.IsZero() {
+		data.Now = time.Now()
+	}
+	characterID := ""
+	if data.Character != nil {
+		characterID = data.Character.CharacterID
+	}
+
+	src, err := s.Resolve(name, data.Locale, characterID)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(src.text)
+	if err != nil {
+		return "", fmt.Errorf("parse prompt template %s: %w", src.Path, err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("render prompt template %s: %w", src.Path, err)
+	}
+	return strings.TrimSpace(sb.String()) + "\n", nil
+}
+
+// Locales lists the locales with embedded defaults
+func Locales() []string {
+	entries, _ := embedded.ReadDir("templates")
+	var out []string
+	for _, e := range entries {
+		if e.IsDir() {
+			out = append(out, e.Name())
+		}
+	}
+	return out
+}
+
+// Export writes the embedded defaults to dir as a starting point for editing; existing files are kept
+func Export(dir string) ([]string, error) {
+	var written []string
+	err := fs.WalkDir(embedded, "templates", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(p, "templates/")))
+		if _, err := os.Stat(target); err == nil {
+			return nil
+		}
+		data, err := embedded.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+		written = append(written, target)
+		return nil
+	})
+	return written, err
+}
+
+// localeChain is the locale followed by DefaultLocale
+func localeChain(locale string) []string {
+	if locale == "" || locale == DefaultLocale {
+		return []string{DefaultLocale}
+	}
+	return []string{locale, DefaultLocale}
+}
+
+// embeddedLocale matches a locale against the embedded ones case-insensitively, then by language ("zh" -> "zh-CN")
+func embeddedLocale(locale string) (string, bool) {
+	all := Locales()
+	for _, l := range all {
+		if strings.EqualFold(l, locale) {
+			return l, true
+		}
+	}
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	for _, l := range all {
+		if base, _, _ := strings.Cut(l, "-"); strings.EqualFold(base, lang) {
+			return l, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,122 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolvePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel string) string {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(rel), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	// Removed one by one from the top, each step falls through to the next candidate
+	steps := []struct {
+		path, locale string
+	}{
+		{write("characters/c/zh-CN/system.tmpl"), "zh-CN"},
+		{write("characters/c/system.tmpl"), "zh-CN"},
+		{write("zh-CN/system.tmpl"), "zh-CN"},
+		{"embedded:zh-CN/system.tmpl", "zh-CN"},
+	}
+	s := NewStore(dir, "")
+	for i, step := range steps {
+		src, err := s.Resolve(System, "zh-CN", "c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if src.Path != step.path || src.Locale != step.locale {
+			t.Fatalf("step %d resolved %s (%s), want %s (%s)", i, src.Path, src.Locale, step.path, step.locale)
+		}
+		if !strings.HasPrefix(step.path, "embedded:") {
+			if err := os.Remove(step.path); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Another character only sees the directory-wide override
+	zh := write("zh-CN/system.tmpl")
+	if src, err := s.Resolve(System, "zh-CN", "other"); err != nil || src.Path != zh {
+		t.Fatalf("other character resolved %+v, %v; want %s", src, err, zh)
+	}
+}
+
+func TestResolveDefaultLocale(t *testing.T) {
+	dir := t.TempDir()
+	en := filepath.Join(dir, "characters", "c", "en", "initiate.tmpl")
+	if err := os.MkdirAll(filepath.Dir(en), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(en, []byte("en override"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(dir, "")
+
+	tests := []struct {
+		name, locale, characterID string
+		path, resolved            string
+	}{
+		{"embedded locale beats a default locale override", "zh-CN", "c", "embedded:zh-CN/initiate.tmpl", "zh-CN"},
+		{"unknown locale falls back to the override", "fr", "c", en, DefaultLocale},
+		{"unknown locale falls back to the embedded default", "fr", "other", "embedded:en/initiate.tmpl", DefaultLocale},
+		{"empty locale is the default", "", "other", "embedded:en/initiate.tmpl", DefaultLocale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := s.Resolve(Initiate, tt.locale, tt.characterID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if src.Path != tt.path || src.Locale != tt.resolved {
+				t.Fatalf("resolved %s (%s), want %s (%s)", src.Path, src.Locale, tt.path, tt.resolved)
+			}
+		})
+	}
+
+	if _, err := s.Resolve("missing", "en", "c"); err == nil {
+		t.Fatal("a template nobody defines was resolved")
+	}
+}
+
+func TestEmbeddedLocale(t *testing.T) {
+	tests := []struct {
+		locale, want string
+		ok           bool
+	}{
+		{"en", "en", true},
+		{"zh-CN", "zh-CN", true},
+		{"zh-cn", "zh-CN", true},
+		{"zh", "zh-CN", true},
+		{"zh_TW", "zh-CN", true},
+		{"en-GB", "en", true},
+		{"fr", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := embeddedLocale(tt.locale); got != tt.want || ok != tt.ok {
+			t.Errorf("embeddedLocale(%q) = %q, %v; want %q, %v", tt.locale, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	s := NewStore("", "")
+	for _, tt := range []struct{ name, path string }{
+		{System, "embedded:zh-CN/system.tmpl"},
+		{Initiate, "embedded:zh-CN/initiate.tmpl"},
+		{Portrait, "embedded:en/portrait.tmpl"}, // image prompts are only written in English
+		{Selfie, "embedded:en/selfie.tmpl"},
+	} {
+		if src, err := s.Resolve(tt.name, "zh", ""); err != nil || src.Path != tt.path {
+			t.Errorf("zh %s resolved %+v, %v; want %s", tt.name, src, err, tt.path)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package relationship
+
+import "fmt"
+
+// Progression is where a relationship type starts and how far it can grow
+type Progression struct {
+	StartLevel int `json:"start_level"`
+	Ceiling    int `json:"ceiling"`
+}
+
+// defaultProgressions: lovers start attracted and can become soulmates; friendships and mentorships
+// top out before the most intimate stages; family starts close
+var defaultProgressions = map[string]Progression{
+	TypeLover:   {StartLevel: 5, Ceiling: 10},
+	TypeFriend:  {StartLevel: 4, Ceiling: 9},
+	TypeMentor:  {StartLevel: 3, Ceiling: 8},
+	TypeSibling: {StartLevel: 6, Ceiling: 10},
+}
+
+// Progression returns the start level and ceiling of a relationship type; unknown types progress like lovers
+func (t *Table) Progression(relType string) Progression {
+	key := NormalizeType(relType)
+	if p, ok := t.progressions[key]; ok {
+		return p
+	}
+	return t.progressions[TypeLover]
+}
+
+// Clamp keeps a level within 1 and the type's ceiling
+func (p Progression) Clamp(level int) int {
+	return max(1, min(level, p.Ceiling))
+}
+
+// validate rejects start levels and ceilings outside 1-MaxLevel
+func (p Progression) validate(relType string) error {
+	if p.StartLevel < 1 || p.Ceiling > MaxLevel || p.StartLevel > p.Ceiling {
+		return fmt.Errorf("progression %s: need 1 <= start_level (%d) <= ceiling (%d) <= %d", relType, p.StartLevel, p.Ceiling, MaxLevel)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Package relationship describes how a companion behaves at each intimacy level of a relationship type
+package relationship
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed stages/*.json
+var embedded embed.FS
+
+// Relationship types with built-in stage tables
+const (
+	TypeLover   = "lover"
+	TypeFriend  = "friend"
+	TypeMentor  = "mentor"
+	TypeSibling = "sibling"
+)
+
+// Types lists the built-in relationship types
+var Types = []string{TypeLover, TypeFriend, TypeMentor, TypeSibling}
+
+// MaxLevel is the highest intimacy level; levels start at 1
+const MaxLevel = 10
+
+// defaultLocale is used when a locale has no table of its own
+const defaultLocale = "en"
+
+// Stage is what one intimacy level means for one relationship type
+type Stage struct {
+	Level       int      `json:"level"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Behaviors   []string `json:"behaviors,omitempty"`  // what the companion may do
+	PetNames    []string `json:"pet_names,omitempty"`  // how it may address the user
+	Topics      []string `json:"topics,omitempty"`     // what it brings up on its own
+	Boundaries  []string `json:"boundaries,omitempty"` // what it must not do yet
+	ReplyLength string   `json:"reply_length,omitempty"`
+	Emoji       string   `json:"emoji,omitempty"` // how often emoji are used
+}
+
+// typeAliases maps free-form RelationshipType values to table keys
+var typeAliases = map[string]string{
+	"恋人": TypeLover, "情侣": TypeLover, "爱人": TypeLover, "伴侣": TypeLover, "女朋友": TypeLover, "男朋友": TypeLover,
+	"partner": TypeLover, "girlfriend": TypeLover, "boyfriend": TypeLover, "romance": TypeLover, "romantic": TypeLover,
+	"朋友": TypeFriend, "好友": TypeFriend, "闺蜜": TypeFriend, "死党": TypeFriend, "best friend": TypeFriend, "buddy": TypeFriend,
+	"导师": TypeMentor, "老师": TypeMentor, "师父": TypeMentor, "前辈": TypeMentor, "teacher": TypeMentor, "coach": TypeMentor,
+	"兄弟": TypeSibling, "姐妹": TypeSibling, "哥哥": TypeSibling, "姐姐": TypeSibling, "弟弟": TypeSibling, "妹妹": TypeSibling,
+	"brother": TypeSibling, "sister": TypeSibling, "family": TypeSibling, "家人": TypeSibling, "亲人": TypeSibling,
+}
+
+// NormalizeType maps a character's RelationshipType ("恋人", "Friend", ...) to a table key.
+// Empty means lover, the companion's original behaviour; unknown values are kept lower-cased
+// so custom types from a stage file can match.
+func NormalizeType(relType string) string {
+	t := strings.ToLower(strings.TrimSpace(relType))
+	if t == "" {
+		return TypeLover
+	}
+	if alias, ok := typeAliases[t]; ok {
+		return alias
+	}
+	return t
+}
+
+// Table holds the stages of every locale and relationship type, and how each type progresses
+type Table struct {
+	stages       map[string]map[string][]Stage // locale -> type -> stages ordered by level
+	progressions map[string]Progression
+}
+
+// DefaultTable returns the embedded stage tables
+func DefaultTable() *Table {
+	t, err := LoadTable("")
+	if err != nil {
+		panic(err) // the embedded tables are part of the build
+	}
+	return t
+}
+
+// LoadTable reads the embedded tables and merges a JSON stage file on top, level by level.
+// The file has the shape {"<locale>": {"<type>": [{"level": 1, "name": ...}, ...]}};
+// a type the defaults lack must define every level. An optional top-level
+// "progression": {"<type>": {"start_level": 3, "ceiling": 9}} sets where types start and end.
+func LoadTable(file string) (*Table, error) {
+	t := &Table{
+		stages:       make(map[string]map[string][]Stage),
+		progressions: make(map[string]Progression, len(defaultProgressions)),
+	}
+	for relType, p := range defaultProgressions {
+		t.progressions[relType] = p
+	}
+
+	entries, err := embedded.ReadDir("stages")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		data, err := embedded.ReadFile(path.Join("stages", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		locale := strings.TrimSuffix(e.Name(), ".json")
+		if err := t.merge(map[string][]byte{locale: data}, "embedded "+e.Name()); err != nil {
+			return nil, err
+		}
+	}
+
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var byLocale map[string]json.RawMessage
+		if err := json.Unmarshal(data, &byLocale); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		raw := make(map[string][]byte, len(byLocale))
+		for locale, v := range byLocale {
+			if locale == "progression" {
+				var progressions map[string]Progression
+				if err := json.Unmarshal(v, &progressions); err != nil {
+					return nil, fmt.Errorf("parse %s (progression): %w", file, err)
+				}
+				for relType, p := range progressions {
+					t.progressions[NormalizeType(relType)] = p
+				}
+				continue
+			}
+			raw[locale] = v
+		}
+		if err := t.merge(raw, file); err != nil {
+			return nil, err
+		}
+	}
+
+	for relType, p := range t.progressions {
+		if err := p.validate(relType); err != nil {
+			return nil, err
+		}
+	}
+	for locale, types := range t.stages {
+		for relType, stages := range types {
+			for i, s := range stages {
+				if s.Level != i+1 || s.Name == "" {
+					return nil, fmt.Errorf("stage table %s/%s: level %d is missing", locale, relType, i+1)
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// merge overlays per-locale JSON ({"<type>": [stages]}) onto the table
+func (t *Table) merge(byLocale map[string][]byte, origin string) error {
+	for locale, data := range byLocale {
+		var types map[string][]Stage
+		if err := json.Unmarshal(data, &types); err != nil {
+			return fmt.Errorf("parse %s (%s): %w", origin, locale, err)
+		}
+		if t.stages[locale] == nil {
+			t.stages[locale] = make(map[string][]Stage)
+		}
+		for relType, stages := range types {
+			key := NormalizeType(relType)
+			current := t.stages[locale][key]
+			if current == nil {
+				current = make([]Stage, MaxLevel)
+			}
+			for _, s := range stages {
+				if s.Level < 1 || s.Level > MaxLevel {
+					return fmt.Errorf("%s: %s/%s has level %d outside 1-%d", origin, locale, relType, s.Level, MaxLevel)
+				}
+				current[s.Level-1] = s
+			}
+			t.stages[locale][key] = current
+		}
+	}
+	return nil
+}
+
+// Stage returns the stage for a relationship type and level in the closest available locale.
+// Unknown types fall back to lover and levels are clamped to 1-MaxLevel.
+func (t *Table) Stage(locale, relType string, level int) Stage {
+	level = max(1, min(level, MaxLevel))
+	key := NormalizeType(relType)
+
+	for _, l := range []string{t.matchLocale(locale), defaultLocale} {
+		types := t.stages[l]
+		if stages, ok := types[key]; ok {
+			return stages[level-1]
+		}
+		if stages, ok := types[TypeLover]; ok {
+			return stages[level-1]
+		}
+	}
+	return Stage{Level: level}
+}
+
+// Locales lists the locales the table covers
+func (t *Table) Locales() []string {
+	out := make([]string, 0, len(t.stages))
+	for l := range t.stages {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// TypesFor lists the relationship types defined for a locale
+func (t *Table) TypesFor(locale string) []string {
+	types := t.stages[t.matchLocale(locale)]
+	out := make([]string, 0, len(types))
+	for relType := range types {
+		out = append(out, relType)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// matchLocale finds the table locale case-insensitively, then by language ("zh" -> "zh-CN")
+func (t *Table) matchLocale(locale string) string {
+	for l := range t.stages {
+		if strings.EqualFold(l, locale) {
+			return l
+		}
+	}
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	for _, l := range t.Locales() {
+		if base, _, _ := strings.Cut(l, "-"); strings.EqualFold(base, lang) {
+			return l
+		}
+	}
+	return defaultLocale
+}
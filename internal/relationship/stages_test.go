@@ -0,0 +1,145 @@
+package relationship
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultTable(t *testing.T) {
+	table := DefaultTable()
+	for _, locale := range table.Locales() {
+		for _, relType := range Types {
+			for level := 1; level <= MaxLevel; level++ {
+				if s := table.Stage(locale, relType, level); s.Level != level || s.Name == "" || s.Description == "" {
+					t.Errorf("%s/%s level %d = %+v", locale, relType, level, s)
+				}
+			}
+		}
+	}
+	for _, relType := range Types {
+		if err := table.Progression(relType).validate(relType); err != nil {
+			t.Error(err)
+		}
+	}
+
+	en, zh := table.Stage("en", TypeLover, 1), table.Stage("zh", TypeLover, 1)
+	if zh.Name == en.Name || zh.Name != table.Stage("zh-CN", TypeLover, 1).Name {
+		t.Errorf("zh level 1 = %q, want the zh-CN stage", zh.Name)
+	}
+	if s := table.Stage("fr", TypeLover, 1); s.Name != en.Name {
+		t.Errorf("unknown locale = %q, want the English stage", s.Name)
+	}
+	if s := table.Stage("en", "rival", 3); s.Name != table.Stage("en", TypeLover, 3).Name {
+		t.Errorf("unknown type = %q, want the lover stage", s.Name)
+	}
+	if s := table.Stage("en", TypeFriend, 0); s.Level != 1 {
+		t.Errorf("level 0 = %d, want it clamped to 1", s.Level)
+	}
+	if s := table.Stage("en", TypeFriend, 99); s.Level != MaxLevel {
+		t.Errorf("level 99 = %d, want it clamped to %d", s.Level, MaxLevel)
+	}
+}
+
+// levels builds stages 1-n named after the type
+func levels(relType string, n int) []Stage {
+	out := make([]Stage, n)
+	for i := range out {
+		out[i] = Stage{Level: i + 1, Name: relType + " " + string(rune('A'+i))}
+	}
+	return out
+}
+
+func TestLoadTable(t *testing.T) {
+	tests := []struct {
+		name string
+		file any
+		err  string // part of the expected error; empty when the file loads
+	}{
+		{"override one level", map[string]any{"en": map[string]any{"friend": []Stage{{Level: 3, Name: "Pal"}}}}, ""},
+		{"custom type with every level", map[string]any{"en": map[string]any{"rival": levels("rival", MaxLevel)}}, ""},
+		{"custom type missing a level", map[string]any{"en": map[string]any{"rival": levels("rival", MaxLevel-1)}}, "en/rival: level 10 is missing"},
+		{"new locale missing levels", map[string]any{"ja": map[string]any{"lover": levels("lover", 3)}}, "ja/lover: level 4 is missing"},
+		{"override without a name", map[string]any{"en": map[string]any{"mentor": []Stage{{Level: 2}}}}, "en/mentor: level 2 is missing"},
+		{"level 0", map[string]any{"en": map[string]any{"lover": []Stage{{Level: 0, Name: "x"}}}}, "level 0 outside 1-10"},
+		{"level above the maximum", map[string]any{"en": map[string]any{"sibling": []Stage{{Level: 11, Name: "x"}}}}, "level 11 outside 1-10"},
+		{"stages that are not a list", map[string]any{"en": map[string]any{"lover": "close"}}, "parse"},
+		{"valid progression", map[string]any{"progression": map[string]any{"朋友": Progression{StartLevel: 2, Ceiling: 6}}}, ""},
+		{"start level 0", map[string]any{"progression": map[string]any{"friend": Progression{StartLevel: 0, Ceiling: 6}}}, "progression friend"},
+		{"start above the ceiling", map[string]any{"progression": map[string]any{"mentor": Progression{StartLevel: 7, Ceiling: 6}}}, "progression mentor"},
+		{"ceiling above the maximum", map[string]any{"progression": map[string]any{"lover": Progression{StartLevel: 5, Ceiling: 11}}}, "progression lover"},
+		{"custom type progression", map[string]any{"progression": map[string]any{"rival": Progression{StartLevel: 1, Ceiling: 3}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := filepath.Join(t.TempDir(), "stages.json")
+			if err := os.WriteFile(file, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err = LoadTable(file)
+			if tt.err == "" && err != nil {
+				t.Fatalf("rejected: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+
+	if _, err := LoadTable(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("a missing file loaded")
+	}
+}
+
+func TestLoadTableMerges(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "stages.json")
+	data := `{
+		"en": {"Friend": [{"level": 3, "name": "Pal", "description": "chums"}], "rival": ` + mustJSON(t, levels("rival", MaxLevel)) + `},
+		"progression": {"朋友": {"start_level": 2, "ceiling": 6}}
+	}`
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	table, err := LoadTable(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := DefaultTable()
+
+	if s := table.Stage("en", "friend", 3); s.Name != "Pal" || s.Description != "chums" {
+		t.Errorf("overridden level = %+v", s)
+	}
+	if s := table.Stage("en", "friend", 4); s.Name != defaults.Stage("en", "friend", 4).Name {
+		t.Errorf("untouched level = %q, want the default", s.Name)
+	}
+	if s := table.Stage("zh-CN", "friend", 3); s.Name != defaults.Stage("zh-CN", "friend", 3).Name {
+		t.Errorf("other locale = %q, want the default", s.Name)
+	}
+	if s := table.Stage("en", "rival", 5); s.Name != "rival E" {
+		t.Errorf("custom type level 5 = %q", s.Name)
+	}
+	if p := table.Progression(TypeFriend); p != (Progression{StartLevel: 2, Ceiling: 6}) {
+		t.Errorf("friend progression = %+v", p)
+	}
+	if p := table.Progression(TypeMentor); p != defaults.Progression(TypeMentor) {
+		t.Errorf("mentor progression = %+v, want the default", p)
+	}
+	if p := table.Progression("rival"); p != defaults.Progression(TypeLover) {
+		t.Errorf("custom type progression = %+v, want the lover one", p)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
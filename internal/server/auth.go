@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// APIKey grants a client access to a set of characters ("*" means all of them)
+type APIKey struct {
+	Key        string   `json:"key"`
+	Name       string   `json:"name"`
+	Characters []string `json:"characters"`
+}
+
+// Allows reports whether this key may talk to the given character
+func (k *APIKey) Allows(characterID string) bool {
+	for _, c := range k.Characters {
+		if c == "*" || c == characterID {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyStore authenticates bearer tokens against configured API keys
+type KeyStore struct {
+	keys []APIKey
+}
+
+// LoadKeyStore reads {"keys": [{"key": "...", "name": "...", "characters": ["*"]}]} from path
+func LoadKeyStore(path string) (*KeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Keys []APIKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for _, k := range doc.Keys {
+		if k.Key == "" {
+			return nil, errors.New("api key entry with empty key")
+		}
+	}
+	return &KeyStore{keys: doc.Keys}, nil
+}
+
+// Len returns the number of configured keys
+func (s *KeyStore) Len() int {
+	return len(s.keys)
+}
+
+// Authenticate resolves the bearer token of a request; nil means unauthorized
+func (s *KeyStore) Authenticate(r *http.Request) *APIKey {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil
+	}
+	for i := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(s.keys[i].Key), []byte(token)) == 1 {
+			return &s.keys[i]
+		}
+	}
+	return nil
+}
+
+// openAccess is the implicit key used when the server runs without authentication
+var openAccess = &APIKey{Name: "anonymous", Characters: []string{"*"}}
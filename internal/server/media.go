@@ -0,0 +1,115 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"ai-companion-cli-go/internal/media"
+)
+
+// --- Media ---
+
+func (s *Server) handleListMedia(w http.ResponseWriter, r *http.Request) {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	limit, cursor, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	var before uint64
+	if cursor != "" {
+		var err error
+		if before, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			writeValidation(w, fieldErrors{"cursor": "is not a valid cursor"})
+			return
+		}
+	}
+	var kinds []string
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		kinds = append(kinds, kind)
+	}
+
+	items, err := s.repo.ListMediaPage(profile.CharacterID, uint(before), limit, kinds...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	res := page{Data: nonNil(items)}
+	if len(items) == limit {
+		res.NextCursor = encodeCursor(strconv.FormatUint(uint64(items[len(items)-1].ID), 10))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleGetMediaFile serves the file itself, e.g. to play a spoken reply in a browser
+func (s *Server) handleGetMediaFile(w http.ResponseWriter, r *http.Request) {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	id, err := strconv.ParseUint(r.PathValue("media_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "media not found")
+		return
+	}
+	m, err := s.repo.GetMedia(uint(id))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	if m == nil || m.CharacterID != profile.CharacterID {
+		writeError(w, http.StatusNotFound, "not_found", "media not found")
+		return
+	}
+	data, err := media.Bytes(m)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	name := m.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-%d%s", m.Kind, m.ID, media.Extension(m))
+	}
+	w.Header().Set("Content-Type", m.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", name))
+	w.Write(data)
+}
+
+// handleCreateSpeech reads a reply aloud in the character's voice; a reply spoken before is not synthesized again
+func (s *Server) handleCreateSpeech(w http.ResponseWriter, r *http.Request) {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	id, err := strconv.ParseUint(r.PathValue("message_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "message not found")
+		return
+	}
+	msg, err := s.repo.GetMessage(uint(id))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	if msg == nil || msg.CharacterID != profile.CharacterID {
+		writeError(w, http.StatusNotFound, "not_found", "message not found")
+		return
+	}
+	if msg.Role != "assistant" {
+		writeValidation(w, fieldErrors{"message_id": "must be a reply of the character"})
+		return
+	}
+
+	m, err := s.orch.Speak(r.Context(), profile, msg)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "upstream_error", err.Error())
+		return
+	}
+	out := *m
+	out.Data = "" // served by /media/{media_id}
+	writeJSON(w, http.StatusOK, out)
+}
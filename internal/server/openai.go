@@ -0,0 +1,187 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Wire types for responses; go-openai's own response structs carry Azure-only fields
+type completionMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type completionChoice struct {
+	Index        int                `json:"index"`
+	Message      *completionMessage `json:"message,omitempty"`
+	Delta        *completionMessage `json:"delta,omitempty"`
+	FinishReason *string            `json:"finish_reason"`
+}
+
+type completionResponse struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"`
+	Created int64              `json:"created"`
+	Model   string             `json:"model"`
+	Choices []completionChoice `json:"choices"`
+}
+
+type modelEntry struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+var finishStop = string(openai.FinishReasonStop)
+
+// handleListModels lists every character the caller's key may talk to as a "model"
+func (s *Server) handleListModels(w http.ResponseWriter, r *http.Request) {
+	chars, err := s.repo.ListCharacters()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+
+	key := apiKeyFrom(r.Context())
+	data := []modelEntry{}
+	for _, c := range chars {
+		if !key.Allows(c.CharacterID) {
+			continue
+		}
+		data = append(data, modelEntry{
+			ID:      c.CharacterID,
+			Object:  "model",
+			Created: c.CreatedAt.Unix(),
+			OwnedBy: c.Name,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   data,
+	})
+}
+
+// handleChatCompletions routes the latest user message through the orchestrator.
+// The server keeps the conversation history, so earlier client-side messages are ignored.
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON body: "+err.Error())
+		return
+	}
+
+	userText := lastUserText(req.Messages)
+	if userText == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "messages must end with a non-empty user message")
+		return
+	}
+
+	profile, err := s.accessibleCharacter(r.Context(), req.Model)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	if profile == nil {
+		writeError(w, http.StatusNotFound, "model_not_found", fmt.Sprintf("the model %q does not exist or you do not have access to it", req.Model))
+		return
+	}
+
+	mu := s.CharacterLock(profile.CharacterID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	session := s.orch.EnsureSession(profile.CharacterID)
+	id := "chatcmpl-" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	created := time.Now().Unix()
+
+	if !req.Stream {
+		result, err := s.orch.Reply(r.Context(), userText, profile, session, nil)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, "upstream_error", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, completionResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: created,
+			Model:   profile.CharacterID,
+			Choices: []completionChoice{{
+				Message:      &completionMessage{Role: openai.ChatMessageRoleAssistant, Content: result.Reply},
+				FinishReason: &finishStop,
+			}},
+		})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming_unsupported", "streaming is not supported by this connection")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	chunk := func(delta completionMessage, finish *string) {
+		writeSSE(w, completionResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   profile.CharacterID,
+			Choices: []completionChoice{{Delta: &delta, FinishReason: finish}},
+		})
+		flusher.Flush()
+	}
+
+	chunk(completionMessage{Role: openai.ChatMessageRoleAssistant}, nil)
+	_, err = s.orch.Reply(r.Context(), userText, profile, session, func(token string) {
+		chunk(completionMessage{Content: token}, nil)
+	})
+	if err != nil {
+		writeSSE(w, map[string]interface{}{
+			"error": map[string]interface{}{"message": err.Error(), "type": "upstream_error"},
+		})
+	} else {
+		chunk(completionMessage{}, &finishStop)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+func writeSSE(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// lastUserText extracts the text of the final user message, joining multi-part text content
+func lastUserText(messages []openai.ChatCompletionMessage) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	last := messages[len(messages)-1]
+	if last.Role != openai.ChatMessageRoleUser {
+		return ""
+	}
+	if last.Content != "" {
+		return strings.TrimSpace(last.Content)
+	}
+	var parts []string
+	for _, p := range last.MultiContent {
+		if p.Type == openai.ChatMessagePartTypeText {
+			parts = append(parts, p.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
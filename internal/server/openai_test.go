@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"ai-companion-cli-go/internal/llm/llmtest"
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func completionRequest(model, text string, stream bool) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: text}},
+		Stream:   stream,
+	}
+}
+
+func TestCompletionsAccess(t *testing.T) {
+	keys := &KeyStore{keys: []APIKey{
+		{Key: "admin", Name: "admin", Characters: []string{"*"}},
+		{Key: "aoi", Name: "aoi only", Characters: []string{"c1"}},
+	}}
+	fake := llmtest.NewServer(t)
+	ts, repo := newTestServer(t, keys, fake.URL)
+	for _, id := range []string{"c1", "c2"} {
+		if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: id, Name: "Aoi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name, token, model string
+		status             int
+		code               string
+	}{
+		{"no token", "", "c1", http.StatusUnauthorized, "invalid_api_key"},
+		{"unknown token", "nope", "c1", http.StatusUnauthorized, "invalid_api_key"},
+		{"character of another key", "aoi", "c2", http.StatusNotFound, "model_not_found"},
+		{"unknown character", "admin", "gpt-4o", http.StatusNotFound, "model_not_found"},
+		{"granted character", "aoi", "c1", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e errorBody
+			code := call(t, ts, "POST", "/v1/chat/completions", tt.token, completionRequest(tt.model, "hi", false), &e)
+			if code != tt.status || e.Error.Code != tt.code {
+				t.Fatalf("status = %d %+v, want %d %q", code, e.Error, tt.status, tt.code)
+			}
+		})
+	}
+	if n := len(fake.Requests()); n != 1 {
+		t.Fatalf("%d upstream requests, want only the granted one", n)
+	}
+
+	var list struct{ Data []modelEntry }
+	if code := call(t, ts, "GET", "/v1/models", "aoi", nil, &list); code != http.StatusOK || len(list.Data) != 1 || list.Data[0].ID != "c1" {
+		t.Fatalf("models = %d %+v, want only c1", code, list.Data)
+	}
+}
+
+func TestCompletionsJSON(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	fake.SetReply(func(openai.ChatCompletionRequest) llmtest.Response { return llmtest.Response{Content: "hello there"} })
+	ts, repo := newTestServer(t, nil, fake.URL)
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var res completionResponse
+	if code := call(t, ts, "POST", "/v1/chat/completions", "", completionRequest("c", "hi", false), &res); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if !strings.HasPrefix(res.ID, "chatcmpl-") || res.Object != "chat.completion" || res.Model != "c" || res.Created == 0 {
+		t.Fatalf("response = %+v", res)
+	}
+	if len(res.Choices) != 1 || res.Choices[0].Message == nil || res.Choices[0].Message.Role != "assistant" ||
+		res.Choices[0].Message.Content != "hello there" || res.Choices[0].FinishReason == nil || *res.Choices[0].FinishReason != "stop" {
+		t.Fatalf("choices = %+v, want the reply stopped", res.Choices)
+	}
+
+	var e errorBody
+	body := openai.ChatCompletionRequest{Model: "c", Messages: []openai.ChatCompletionMessage{{Role: "assistant", Content: "hi"}}}
+	if code := call(t, ts, "POST", "/v1/chat/completions", "", body, &e); code != http.StatusBadRequest || e.Error.Code != "invalid_request" {
+		t.Fatalf("no user message = %d %+v, want 400", code, e.Error)
+	}
+}
+
+func TestCompletionsStream(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	fake.SetReply(func(openai.ChatCompletionRequest) llmtest.Response { return llmtest.Response{Content: "hello there"} })
+	ts, repo := newTestServer(t, nil, fake.URL)
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(completionRequest("c", "hi", true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ts.Client().Post(ts.URL+"/v1/chat/completions", "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("status = %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	var events []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if line, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			events = append(events, line)
+		}
+	}
+	if len(events) < 3 || events[len(events)-1] != "[DONE]" {
+		t.Fatalf("events = %q, want chunks ending with [DONE]", events)
+	}
+
+	var reply strings.Builder
+	chunks := events[:len(events)-1]
+	for i, ev := range chunks {
+		var c completionResponse
+		if err := json.Unmarshal([]byte(ev), &c); err != nil {
+			t.Fatalf("chunk %d %q: %v", i, ev, err)
+		}
+		if c.Object != "chat.completion.chunk" || c.Model != "c" || len(c.Choices) != 1 || c.Choices[0].Delta == nil {
+			t.Fatalf("chunk %d = %s", i, ev)
+		}
+		delta, finish := c.Choices[0].Delta, c.Choices[0].FinishReason
+		switch {
+		case i == 0:
+			if delta.Role != "assistant" || finish != nil {
+				t.Fatalf("first chunk = %s, want the assistant role", ev)
+			}
+		case i == len(chunks)-1:
+			if finish == nil || *finish != "stop" || delta.Content != "" {
+				t.Fatalf("last chunk = %s, want finish_reason stop", ev)
+			}
+		default:
+			if finish != nil {
+				t.Fatalf("chunk %d = %s finished early", i, ev)
+			}
+			reply.WriteString(delta.Content)
+		}
+	}
+	if reply.String() != "hello there" {
+		t.Fatalf("streamed %q, want the reply", reply.String())
+	}
+}
@@ -0,0 +1,420 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+
+	"github.com/gorilla/websocket"
+)
+
+// Realtime protocol: every frame is a JSON object with a "type" and the client-chosen
+// "session" it belongs to, so several conversations can share one connection.
+//
+// Client → server: open {character_id, session_id}, send {text}, regenerate, cancel, close
+// Server → client: opened {session_id}, presence, thinking, typing, token {text}, done {turn},
+// level_change, memory, proactive {text}, cancelled, closed, error {error}
+type realtimeFrame struct {
+	Type        string                   `json:"type"`
+	Session     string                   `json:"session,omitempty"`
+	CharacterID string                   `json:"character_id,omitempty"`
+	SessionID   string                   `json:"session_id,omitempty"` // stored session of the channel, see open
+	Text        string                   `json:"text,omitempty"`
+	Status      string                   `json:"status,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+	Turn        *orchestrator.TurnResult `json:"turn,omitempty"`
+	FromLevel   int                      `json:"from_level,omitempty"`
+	ToLevel     int                      `json:"to_level,omitempty"`
+	FactsAdded  int                      `json:"facts_added,omitempty"`
+}
+
+const (
+	realtimeWriteWait  = 10 * time.Second
+	realtimePongWait   = 60 * time.Second
+	realtimePingPeriod = 50 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// Non-browser clients are authenticated by API key, so cross-origin upgrades are allowed
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// realtimeConn multiplexes conversations over one WebSocket
+type realtimeConn struct {
+	srv  *Server
+	ws   *websocket.Conn
+	key  *APIKey
+	ctx  context.Context
+	done context.CancelFunc
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	channels map[string]*realtimeChannel
+}
+
+// realtimeChannel is one client session bound to a character
+type realtimeChannel struct {
+	name    string
+	profile *models.CharacterProfile
+	session *models.SessionState // which stored session; runTurn reloads its state
+	cancel  context.CancelFunc   // non-nil while a turn is running
+}
+
+// handleRealtime upgrades to a WebSocket. Browsers cannot set headers, so ?access_token= is accepted too.
+func (s *Server) handleRealtime(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &realtimeConn{
+		srv:      s,
+		ws:       ws,
+		key:      apiKeyFrom(r.Context()),
+		ctx:      ctx,
+		done:     cancel,
+		channels: make(map[string]*realtimeChannel),
+	}
+	s.realtimeMu.Lock()
+	s.realtimeConns[c] = struct{}{}
+	s.realtimeMu.Unlock()
+	defer func() {
+		s.realtimeMu.Lock()
+		delete(s.realtimeConns, c)
+		s.realtimeMu.Unlock()
+	}()
+
+	go c.pingLoop()
+	c.readLoop()
+}
+
+// PushProactive sends a message a character wrote on its own to every realtime session open on the
+// session it was written for. It reports whether any client received it.
+func (s *Server) PushProactive(ctx context.Context, profile *models.CharacterProfile, msg *models.ChatMessage) bool {
+	s.realtimeMu.Lock()
+	conns := make([]*realtimeConn, 0, len(s.realtimeConns))
+	for c := range s.realtimeConns {
+		conns = append(conns, c)
+	}
+	s.realtimeMu.Unlock()
+
+	delivered := false
+	for _, c := range conns {
+		for _, name := range c.sessionsOn(msg.SessionID) {
+			c.send(realtimeFrame{Type: "proactive", Session: name, CharacterID: profile.CharacterID, Text: msg.Content.String()})
+			delivered = true
+		}
+	}
+	return delivered
+}
+
+// sessionsOn lists the client sessions open on a stored session
+func (c *realtimeConn) sessionsOn(sessionID string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for name, ch := range c.channels {
+		if ch.session.SessionID == sessionID {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// tokenFromQuery copies ?access_token= into the Authorization header for WebSocket clients
+func tokenFromQuery(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if token := r.URL.Query().Get("access_token"); token != "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (c *realtimeConn) readLoop() {
+	defer func() {
+		c.done()
+		c.mu.Lock()
+		for _, ch := range c.channels {
+			if ch.cancel != nil {
+				ch.cancel()
+			}
+		}
+		c.mu.Unlock()
+		c.ws.Close()
+	}()
+
+	c.ws.SetReadLimit(64 * 1024)
+	c.ws.SetReadDeadline(time.Now().Add(realtimePongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(realtimePongWait))
+	})
+
+	for {
+		var in realtimeFrame
+		if err := c.ws.ReadJSON(&in); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("realtime: read: %v", err)
+			}
+			return
+		}
+		c.dispatch(in)
+	}
+}
+
+func (c *realtimeConn) pingLoop() {
+	ticker := time.NewTicker(realtimePingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			c.ws.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+			err := c.ws.WriteMessage(websocket.PingMessage, nil)
+			c.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *realtimeConn) send(f realtimeFrame) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.ws.SetWriteDeadline(time.Now().Add(realtimeWriteWait))
+	if err := c.ws.WriteJSON(f); err != nil {
+		c.done()
+	}
+}
+
+func (c *realtimeConn) sendError(session, msg string) {
+	c.send(realtimeFrame{Type: "error", Session: session, Error: msg})
+}
+
+func (c *realtimeConn) dispatch(in realtimeFrame) {
+	if in.Session == "" {
+		c.sendError("", "every frame needs a session")
+		return
+	}
+
+	switch in.Type {
+	case "open":
+		c.open(in)
+	case "send", "regenerate":
+		c.startTurn(in)
+	case "cancel":
+		c.mu.Lock()
+		ch := c.channels[in.Session]
+		c.mu.Unlock()
+		if ch != nil && ch.cancel != nil {
+			ch.cancel()
+		}
+	case "close":
+		c.mu.Lock()
+		ch := c.channels[in.Session]
+		delete(c.channels, in.Session)
+		c.mu.Unlock()
+		if ch != nil && ch.cancel != nil {
+			ch.cancel()
+		}
+		c.send(realtimeFrame{Type: "closed", Session: in.Session})
+	default:
+		c.sendError(in.Session, "unknown frame type "+in.Type)
+	}
+}
+
+func (c *realtimeConn) open(in realtimeFrame) {
+	if !c.key.Allows(in.CharacterID) {
+		c.sendError(in.Session, "character not found")
+		return
+	}
+	profile, err := c.srv.repo.GetCharacter(in.CharacterID)
+	if err != nil || profile == nil {
+		c.sendError(in.Session, "character not found")
+		return
+	}
+
+	session, msg := c.channelSession(profile.CharacterID, in.SessionID)
+	if session == nil {
+		c.sendError(in.Session, msg)
+		return
+	}
+
+	c.mu.Lock()
+	if _, exists := c.channels[in.Session]; exists {
+		c.mu.Unlock()
+		c.sendError(in.Session, "session already open")
+		return
+	}
+	ch := &realtimeChannel{
+		name:    in.Session,
+		profile: profile,
+		session: session,
+	}
+	c.channels[in.Session] = ch
+	c.mu.Unlock()
+
+	c.send(realtimeFrame{Type: "opened", Session: in.Session, CharacterID: profile.CharacterID, SessionID: session.SessionID})
+	c.send(realtimeFrame{Type: "presence", Session: in.Session, CharacterID: profile.CharacterID, Status: "online"})
+	c.sendPendingProactive(in.Session, ch.session)
+}
+
+// channelSession finds the stored session a channel opens: the character's main session, or the one
+// named by session_id, e.g. a bot chat's or one of the client's own. A new one must be named
+// sess_<character_id>_..., so it can never take the ID of another character's session. On failure the
+// session is nil and msg says why.
+func (c *realtimeConn) channelSession(characterID, sessionID string) (session *models.SessionState, msg string) {
+	if sessionID == "" {
+		return c.srv.orch.EnsureSession(characterID), ""
+	}
+	existing, err := c.srv.repo.GetSessionState(sessionID)
+	if err != nil {
+		return nil, err.Error()
+	}
+	switch {
+	case existing != nil && existing.CharacterID != characterID:
+		return nil, "session_id belongs to another character"
+	case existing == nil && !strings.HasPrefix(sessionID, "sess_"+characterID+"_"):
+		return nil, "a new session_id must start with sess_" + characterID + "_"
+	}
+	return c.srv.orch.EnsureSessionID(characterID, sessionID), ""
+}
+
+// sendPendingProactive replays proactive messages written for the session while no client was connected
+func (c *realtimeConn) sendPendingProactive(name string, session *models.SessionState) {
+	characterID := session.CharacterID
+	pending, err := c.srv.repo.ListUndeliveredProactive(characterID, session.SessionID)
+	if err != nil || len(pending) == 0 {
+		return
+	}
+	ids := make([]uint, 0, len(pending))
+	for _, e := range pending {
+		if msg, err := c.srv.repo.GetMessage(e.MessageID); err == nil && msg != nil {
+			c.send(realtimeFrame{Type: "proactive", Session: name, CharacterID: characterID, Text: msg.Content.String()})
+		}
+		ids = append(ids, e.ID)
+	}
+	_ = c.srv.repo.MarkProactiveDelivered(ids...)
+}
+
+// startTurn runs a send/regenerate in the background so other sessions keep flowing
+func (c *realtimeConn) startTurn(in realtimeFrame) {
+	c.mu.Lock()
+	ch := c.channels[in.Session]
+	if ch == nil {
+		c.mu.Unlock()
+		c.sendError(in.Session, "session is not open")
+		return
+	}
+	if ch.cancel != nil {
+		c.mu.Unlock()
+		c.sendError(in.Session, "a reply is already in progress; cancel it first")
+		return
+	}
+	if in.Type == "send" && in.Text == "" {
+		c.mu.Unlock()
+		c.sendError(in.Session, "text must not be empty")
+		return
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	ch.cancel = cancel
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			cancel()
+			c.mu.Lock()
+			ch.cancel = nil
+			c.mu.Unlock()
+		}()
+		c.runTurn(ctx, ch, in)
+	}()
+}
+
+func (c *realtimeConn) runTurn(ctx context.Context, ch *realtimeChannel, in realtimeFrame) {
+	mu := c.srv.CharacterLock(ch.profile.CharacterID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Other channels and the REST API take turns on the same stored session, so its turn counter is
+	// reloaded under the lock rather than kept from when this channel opened
+	session := c.srv.orch.EnsureSessionID(ch.profile.CharacterID, ch.session.SessionID)
+	before := c.srv.snapshotState(ch.profile.CharacterID)
+	c.send(realtimeFrame{Type: "thinking", Session: ch.name})
+
+	typing := false
+	onToken := func(token string) {
+		if !typing {
+			typing = true
+			c.send(realtimeFrame{Type: "typing", Session: ch.name})
+		}
+		c.send(realtimeFrame{Type: "token", Session: ch.name, Text: token})
+	}
+
+	var result *orchestrator.TurnResult
+	var err error
+	if in.Type == "regenerate" {
+		result, err = c.srv.orch.Regenerate(ctx, ch.profile, session, onToken)
+	} else {
+		result, err = c.srv.orch.Reply(ctx, in.Text, ch.profile, session, onToken)
+	}
+
+	if ctx.Err() != nil {
+		c.send(realtimeFrame{Type: "cancelled", Session: ch.name})
+		return
+	}
+	if err != nil {
+		c.sendError(ch.name, err.Error())
+		return
+	}
+
+	c.send(realtimeFrame{Type: "done", Session: ch.name, Turn: result})
+	for _, ev := range before.diff(c.srv.snapshotState(ch.profile.CharacterID)) {
+		ev.Session = ch.name
+		c.send(ev)
+	}
+}
+
+// stateSnapshot captures what realtime clients get change events for
+type stateSnapshot struct {
+	level int
+	facts int
+}
+
+func (s *Server) snapshotState(characterID string) stateSnapshot {
+	var snap stateSnapshot
+	if rel, _ := s.repo.GetRelationshipState(characterID); rel != nil {
+		snap.level = rel.IntimacyLevel
+	}
+	if facts, err := s.repo.ListMemoryFactsByCharacter(characterID); err == nil {
+		snap.facts = len(facts)
+	}
+	return snap
+}
+
+// diff turns state changes across a turn into realtime events
+func (before stateSnapshot) diff(after stateSnapshot) []realtimeFrame {
+	var events []realtimeFrame
+	if after.level != before.level {
+		events = append(events, realtimeFrame{Type: "level_change", FromLevel: before.level, ToLevel: after.level})
+	}
+	if after.facts > before.facts {
+		events = append(events, realtimeFrame{Type: "memory", FactsAdded: after.facts - before.facts})
+	}
+	return events
+}
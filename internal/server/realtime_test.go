@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"ai-companion-cli-go/internal/llm/llmtest"
+	"ai-companion-cli-go/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialRealtime connects to the realtime endpoint of ts
+func dialRealtime(t *testing.T, ts *httptest.Server) *websocket.Conn {
+	t.Helper()
+	ws, _, err := websocket.DefaultDialer.DialContext(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/v1/realtime", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+// awaitFrame reads frames until one of the given types arrives on session
+func awaitFrame(t *testing.T, ws *websocket.Conn, session string, types ...string) realtimeFrame {
+	t.Helper()
+	for {
+		var f realtimeFrame
+		if err := ws.ReadJSON(&f); err != nil {
+			t.Fatal(err)
+		}
+		if f.Session == session && slices.Contains(types, f.Type) {
+			return f
+		}
+	}
+}
+
+// awaitTurn reads frames until the turn on session finishes and returns its done frame
+func awaitTurn(t *testing.T, ws *websocket.Conn, session string) realtimeFrame {
+	t.Helper()
+	for {
+		var f realtimeFrame
+		if err := ws.ReadJSON(&f); err != nil {
+			t.Fatal(err)
+		}
+		if f.Session != session {
+			continue
+		}
+		switch f.Type {
+		case "done":
+			return f
+		case "error":
+			t.Fatalf("session %s: %s", session, f.Error)
+		}
+	}
+}
+
+func TestRealtimeChannelsShareTheSession(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	ts, repo := newTestServer(t, nil, fake.URL)
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+	ws := dialRealtime(t, ts)
+
+	// Both channels are open before either takes a turn, so each starts from the same stored state
+	for _, name := range []string{"a", "b"} {
+		if err := ws.WriteJSON(realtimeFrame{Type: "open", Session: name, CharacterID: "c"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, name := range []string{"a", "b", "a"} {
+		if err := ws.WriteJSON(realtimeFrame{Type: "send", Session: name, Text: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+		if done := awaitTurn(t, ws, name); done.Turn.TurnIndex != i+1 {
+			t.Fatalf("turn %d on channel %s has index %d", i+1, name, done.Turn.TurnIndex)
+		}
+	}
+	if state, _ := repo.GetSessionState("sess_c"); state == nil || state.TurnIndex != 3 {
+		t.Fatalf("stored session = %+v, want 3 turns", state)
+	}
+}
+
+func TestRealtimeChannelSessions(t *testing.T) {
+	fake := llmtest.NewServer(t)
+	ts, repo := newTestServer(t, nil, fake.URL)
+	for _, id := range []string{"c", "d"} {
+		if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: id, Name: "Aoi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ws := dialRealtime(t, ts)
+
+	opens := []struct {
+		name, sessionID, want string // want is the stored session, or empty when the open fails
+	}{
+		{"main", "", "sess_c"},
+		{"side", "sess_c_side", "sess_c_side"},
+		{"foreign", "sess_d", ""},
+		{"unnamed", "side", ""},
+	}
+	for _, o := range opens {
+		if err := ws.WriteJSON(realtimeFrame{Type: "open", Session: o.name, CharacterID: "c", SessionID: o.sessionID}); err != nil {
+			t.Fatal(err)
+		}
+		f := awaitFrame(t, ws, o.name, "opened", "error")
+		if o.want == "" {
+			if f.Type != "error" {
+				t.Errorf("open %s on %q = %+v, want an error", o.name, o.sessionID, f)
+			}
+			continue
+		}
+		if f.Type != "opened" || f.SessionID != o.want {
+			t.Errorf("open %s on %q = %+v, want session %s", o.name, o.sessionID, f, o.want)
+		}
+	}
+
+	for _, name := range []string{"main", "side", "side"} {
+		if err := ws.WriteJSON(realtimeFrame{Type: "send", Session: name, Text: "hello from " + name}); err != nil {
+			t.Fatal(err)
+		}
+		awaitTurn(t, ws, name)
+	}
+	for id, turns := range map[string]int{"sess_c": 1, "sess_c_side": 2} {
+		if state, _ := repo.GetSessionState(id); state == nil || state.TurnIndex != turns {
+			t.Errorf("stored session %s = %+v, want %d turns", id, state, turns)
+		}
+	}
+	side := 0
+	for _, req := range fake.Requests() {
+		if !strings.Contains(req.Messages[len(req.Messages)-1].Content, "from side") {
+			continue
+		}
+		side++
+		for _, m := range req.Messages {
+			if strings.Contains(m.Content, "from main") {
+				t.Fatal("the side channel saw the main channel's history")
+			}
+		}
+	}
+	if side != 2 {
+		t.Fatalf("%d requests for the side channel, want 2", side)
+	}
+}
@@ -0,0 +1,537 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// page is the envelope of every paginated listing
+type page struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// fieldErrors maps a JSON field name to what is wrong with it
+type fieldErrors map[string]string
+
+func (s *Server) registerREST() {
+	s.mux.HandleFunc("GET /api/v1/openapi.json", s.handleOpenAPI)
+
+	s.mux.Handle("GET /api/v1/characters", s.authed(s.handleListCharacters))
+	s.mux.Handle("POST /api/v1/characters", s.authed(s.handleCreateCharacter))
+	s.mux.Handle("GET /api/v1/characters/{id}", s.authed(s.handleGetCharacter))
+	s.mux.Handle("PATCH /api/v1/characters/{id}", s.authed(s.handleUpdateCharacter))
+	s.mux.Handle("DELETE /api/v1/characters/{id}", s.authed(s.handleDeleteCharacter))
+
+	s.mux.Handle("GET /api/v1/characters/{id}/sessions", s.authed(s.handleListSessions))
+	s.mux.Handle("GET /api/v1/sessions/{session_id}", s.authed(s.handleGetSession))
+	s.mux.Handle("GET /api/v1/characters/{id}/messages", s.authed(s.handleListMessages))
+
+	s.mux.Handle("GET /api/v1/characters/{id}/relationship", s.authed(s.handleGetRelationship))
+	s.mux.Handle("PUT /api/v1/characters/{id}/relationship", s.authed(s.handlePutRelationship))
+
+	s.mux.Handle("GET /api/v1/characters/{id}/facts", s.authed(s.handleListFacts))
+	s.mux.Handle("POST /api/v1/characters/{id}/facts", s.authed(s.handleCreateFact))
+	s.mux.Handle("GET /api/v1/characters/{id}/facts/{fact_id}", s.authed(s.handleGetFact))
+	s.mux.Handle("PATCH /api/v1/characters/{id}/facts/{fact_id}", s.authed(s.handleUpdateFact))
+	s.mux.Handle("DELETE /api/v1/characters/{id}/facts/{fact_id}", s.authed(s.handleDeleteFact))
+
+	s.mux.Handle("GET /api/v1/characters/{id}/summaries", s.authed(s.handleListSummaries))
+
+	s.mux.Handle("GET /api/v1/characters/{id}/media", s.authed(s.handleListMedia))
+	s.mux.Handle("GET /api/v1/characters/{id}/media/{media_id}", s.authed(s.handleGetMediaFile))
+	s.mux.Handle("POST /api/v1/characters/{id}/messages/{message_id}/speech", s.authed(s.handleCreateSpeech))
+}
+
+// --- Characters ---
+
+func (s *Server) handleListCharacters(w http.ResponseWriter, r *http.Request) {
+	limit, after, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	key := apiKeyFrom(r.Context())
+	var out []models.CharacterProfile
+	// Keep fetching until the page is full, since keys may hide some characters
+	for len(out) < limit {
+		batch, err := s.repo.ListCharactersPage(after, limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+			return
+		}
+		for _, c := range batch {
+			if key.Allows(c.CharacterID) && len(out) < limit {
+				out = append(out, c)
+			}
+		}
+		if len(batch) < limit {
+			break
+		}
+		after = batch[len(batch)-1].CharacterID
+	}
+
+	res := page{Data: nonNil(out)}
+	if len(out) == limit {
+		res.NextCursor = encodeCursor(out[len(out)-1].CharacterID)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleCreateCharacter(w http.ResponseWriter, r *http.Request) {
+	if !apiKeyFrom(r.Context()).Allows("*") {
+		writeError(w, http.StatusForbidden, "forbidden", "this API key may not create characters")
+		return
+	}
+
+	var profile models.CharacterProfile
+	if !decodeBody(w, r, &profile) {
+		return
+	}
+	profile.CharacterID = orchestrator.GenerateCharacterID()
+	if errs := validateCharacter(&profile); len(errs) > 0 {
+		writeValidation(w, errs)
+		return
+	}
+	if err := s.repo.CreateCharacter(&profile); err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, profile)
+}
+
+func (s *Server) handleGetCharacter(w http.ResponseWriter, r *http.Request) {
+	if profile := s.pathCharacter(w, r); profile != nil {
+		writeJSON(w, http.StatusOK, profile)
+	}
+}
+
+func (s *Server) handleUpdateCharacter(w http.ResponseWriter, r *http.Request) {
+	defer s.lockPathCharacter(r)()
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+
+	// Decoding onto the stored profile gives PATCH merge semantics
+	id, created, relType := profile.CharacterID, profile.CreatedAt, profile.RelationshipType
+	if !decodeBody(w, r, profile) {
+		return
+	}
+	profile.CharacterID, profile.CreatedAt = id, created
+
+	if errs := validateCharacter(profile); len(errs) > 0 {
+		writeValidation(w, errs)
+		return
+	}
+	// A new relationship type goes through the orchestrator so the level is clamped and the transition recorded
+	var err error
+	if newType := profile.RelationshipType; newType != relType && newType != "" {
+		profile.RelationshipType = relType
+		_, err = s.orch.ChangeRelationshipType(profile, newType)
+	} else {
+		err = s.repo.SaveCharacter(profile)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+func (s *Server) handleDeleteCharacter(w http.ResponseWriter, r *http.Request) {
+	defer s.lockPathCharacter(r)()
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	if err := s.repo.DeleteCharacter(profile.CharacterID); err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Sessions & messages ---
+
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	sessions, err := s.repo.ListSessionsByCharacter(profile.CharacterID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, page{Data: nonNil(sessions)})
+}
+
+func (s *Server) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	session, err := s.repo.GetSessionState(r.PathValue("session_id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	if session == nil || !apiKeyFrom(r.Context()).Allows(session.CharacterID) {
+		writeError(w, http.StatusNotFound, "not_found", "session not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+// handleListMessages pages backwards through history, newest first
+func (s *Server) handleListMessages(w http.ResponseWriter, r *http.Request) {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	limit, cursor, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	var before uint64
+	if cursor != "" {
+		var err error
+		if before, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			writeValidation(w, fieldErrors{"cursor": "is not a valid cursor"})
+			return
+		}
+	}
+
+	messages, err := s.repo.ListMessagesPage(profile.CharacterID, uint(before), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	// Pictures, recordings and spoken replies are listed without their data; fetch them from /media/{media_id}
+	ids := make([]uint, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].ID
+	}
+	attached, err := s.repo.ListMessageMedia(ids)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	for i := range messages {
+		messages[i].Attachments = attached[messages[i].ID]
+	}
+	res := page{Data: nonNil(messages)}
+	if len(messages) == limit {
+		res.NextCursor = encodeCursor(strconv.FormatUint(uint64(messages[len(messages)-1].ID), 10))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// --- Relationship ---
+
+func (s *Server) handleGetRelationship(w http.ResponseWriter, r *http.Request) {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	rel, err := s.repo.GetRelationshipState(profile.CharacterID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	if rel == nil {
+		writeError(w, http.StatusNotFound, "not_found", "no relationship yet; start a conversation first")
+		return
+	}
+	writeJSON(w, http.StatusOK, rel)
+}
+
+func (s *Server) handlePutRelationship(w http.ResponseWriter, r *http.Request) {
+	defer s.lockPathCharacter(r)()
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+
+	var rel models.RelationshipState
+	if !decodeBody(w, r, &rel) {
+		return
+	}
+	rel.CharacterID = profile.CharacterID
+	rel.UpdatedAt = time.Now()
+
+	errs := fieldErrors{}
+	if ceiling := s.orch.Progression(profile).Ceiling; rel.IntimacyLevel < 1 || rel.IntimacyLevel > ceiling {
+		errs["intimacy_level"] = fmt.Sprintf("must be between 1 and %d, the ceiling of the character's relationship type", ceiling)
+	}
+	if rel.IntimacyScore < 0 || rel.IntimacyScore > 100 {
+		errs["intimacy_score"] = "must be between 0 and 100"
+	}
+	if len(errs) > 0 {
+		writeValidation(w, errs)
+		return
+	}
+
+	if err := s.repo.SaveRelationshipState(&rel); err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, rel)
+}
+
+// --- Memory ---
+
+func (s *Server) handleListFacts(w http.ResponseWriter, r *http.Request) {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	facts, err := s.repo.ListMemoryFactsByCharacter(profile.CharacterID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, page{Data: nonNil(facts)})
+}
+
+func (s *Server) handleCreateFact(w http.ResponseWriter, r *http.Request) {
+	defer s.lockPathCharacter(r)()
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+
+	fact := models.MemoryFact{Confidence: 1}
+	if !decodeBody(w, r, &fact) {
+		return
+	}
+	fact.FactID = orchestrator.GenerateFactID()
+	fact.CharacterID = profile.CharacterID
+	fact.SessionID = "" // facts added here are shared by every session
+	fact.LastSeenAt = time.Now()
+
+	if errs := validateFact(&fact); len(errs) > 0 {
+		writeValidation(w, errs)
+		return
+	}
+	if err := s.repo.AppendMemoryFact(&fact); err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, fact)
+}
+
+func (s *Server) handleGetFact(w http.ResponseWriter, r *http.Request) {
+	if fact := s.pathFact(w, r); fact != nil {
+		writeJSON(w, http.StatusOK, fact)
+	}
+}
+
+func (s *Server) handleUpdateFact(w http.ResponseWriter, r *http.Request) {
+	defer s.lockPathCharacter(r)()
+	fact := s.pathFact(w, r)
+	if fact == nil {
+		return
+	}
+	id, characterID, sessionID := fact.FactID, fact.CharacterID, fact.SessionID
+	if !decodeBody(w, r, fact) {
+		return
+	}
+	fact.FactID, fact.CharacterID, fact.SessionID = id, characterID, sessionID
+	fact.LastSeenAt = time.Now()
+
+	if errs := validateFact(fact); len(errs) > 0 {
+		writeValidation(w, errs)
+		return
+	}
+	if err := s.repo.SaveMemoryFact(fact); err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, fact)
+}
+
+func (s *Server) handleDeleteFact(w http.ResponseWriter, r *http.Request) {
+	defer s.lockPathCharacter(r)()
+	fact := s.pathFact(w, r)
+	if fact == nil {
+		return
+	}
+	if err := s.repo.DeleteMemoryFact(fact.FactID); err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleListSummaries(w http.ResponseWriter, r *http.Request) {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return
+	}
+	summaries, err := s.repo.ListMemorySummariesByCharacter(profile.CharacterID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, page{Data: nonNil(summaries)})
+}
+
+// --- Helpers ---
+
+// pathCharacter resolves {id}; it writes a 404 and returns nil when missing or not accessible
+func (s *Server) pathCharacter(w http.ResponseWriter, r *http.Request) *models.CharacterProfile {
+	profile, err := s.accessibleCharacter(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return nil
+	}
+	if profile == nil {
+		writeError(w, http.StatusNotFound, "not_found", "character not found")
+		return nil
+	}
+	return profile
+}
+
+// lockPathCharacter takes the turn lock of the character at {id} when the caller may see it, so a write
+// neither lands in the middle of a turn nor works on rows a turn is changing: handlers load what they
+// change after taking it. The returned func releases the lock.
+func (s *Server) lockPathCharacter(r *http.Request) (unlock func()) {
+	profile, _ := s.accessibleCharacter(r.Context(), r.PathValue("id"))
+	if profile == nil {
+		return func() {}
+	}
+	mu := s.CharacterLock(profile.CharacterID)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// pathFact resolves {fact_id} under {id}
+func (s *Server) pathFact(w http.ResponseWriter, r *http.Request) *models.MemoryFact {
+	profile := s.pathCharacter(w, r)
+	if profile == nil {
+		return nil
+	}
+	fact, err := s.repo.GetMemoryFact(r.PathValue("fact_id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "storage_error", err.Error())
+		return nil
+	}
+	if fact == nil || fact.CharacterID != profile.CharacterID {
+		writeError(w, http.StatusNotFound, "not_found", "fact not found")
+		return nil
+	}
+	return fact
+}
+
+// pageParams reads ?limit= and ?cursor=, writing a validation error when they are malformed
+func pageParams(w http.ResponseWriter, r *http.Request) (limit int, cursor string, ok bool) {
+	limit = defaultPageSize
+	errs := fieldErrors{}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			errs["limit"] = fmt.Sprintf("must be an integer between 1 and %d", maxPageSize)
+		}
+		limit = n
+	}
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			errs["cursor"] = "is not a valid cursor"
+		}
+		cursor = string(raw)
+	}
+
+	if len(errs) > 0 {
+		writeValidation(w, errs)
+		return 0, "", false
+	}
+	return limit, cursor, true
+}
+
+func encodeCursor(v string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(v))
+}
+
+// decodeBody strictly decodes a JSON body, rejecting unknown fields
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			writeValidation(w, fieldErrors{typeErr.Field: "must be of type " + typeErr.Type.String()})
+			return false
+		}
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeValidation replies 422 with per-field messages
+func writeValidation(w http.ResponseWriter, errs fieldErrors) {
+	fields := make([]string, 0, len(errs))
+	for f := range errs {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": "validation failed: " + strings.Join(fields, ", "),
+			"type":    http.StatusText(http.StatusUnprocessableEntity),
+			"code":    "validation_failed",
+			"fields":  errs,
+		},
+	})
+}
+
+func validateCharacter(p *models.CharacterProfile) fieldErrors {
+	errs := fieldErrors{}
+	if strings.TrimSpace(p.Name) == "" {
+		errs["name"] = "is required"
+	} else if len([]rune(p.Name)) > 64 {
+		errs["name"] = "must be at most 64 characters"
+	}
+	if p.Age < 0 || p.Age > 150 {
+		errs["age"] = "must be between 0 and 150"
+	}
+	return errs
+}
+
+func validateFact(f *models.MemoryFact) fieldErrors {
+	errs := fieldErrors{}
+	if strings.TrimSpace(f.FactKey) == "" {
+		errs["fact_key"] = "is required"
+	}
+	if strings.TrimSpace(f.FactValue.String()) == "" {
+		errs["fact_value"] = "is required"
+	}
+	if f.Confidence < 0 || f.Confidence > 1 {
+		errs["confidence"] = "must be between 0 and 1"
+	}
+	return errs
+}
+
+// nonNil makes empty listings encode as [] instead of null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
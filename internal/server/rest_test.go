@@ -0,0 +1,339 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// newTestServer runs the API in process against a fresh SQLite database and the model endpoint at baseURL
+func newTestServer(t *testing.T, keys *KeyStore, baseURL string) (*httptest.Server, *storage.Repository) {
+	t.Helper()
+	_, ts, repo := newTestAPI(t, keys, baseURL)
+	return ts, repo
+}
+
+// newTestAPI is newTestServer also returning the Server behind it
+func newTestAPI(t *testing.T, keys *KeyStore, baseURL string) (*Server, *httptest.Server, *storage.Repository) {
+	t.Helper()
+	db := storage.NewDB(filepath.Join(t.TempDir(), "companion.db"))
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		if conn, err := db.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	repo := storage.NewRepository(db)
+	orch := orchestrator.NewOrchestrator(repo, llm.NewClient("sk-test", models.ModelProfile{PrimaryModel: "gpt-test", BaseURL: baseURL}))
+	srv := NewServer(repo, orch, keys)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return srv, ts, repo
+}
+
+// call sends a JSON request and decodes the JSON answer into out (when not nil), returning the status
+func call(t *testing.T, ts *httptest.Server, method, path, token string, body, out interface{}) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if s, ok := body.(string); ok {
+		reader = bytes.NewReader([]byte(s))
+	} else {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+type errorBody struct {
+	Error struct {
+		Code   string            `json:"code"`
+		Fields map[string]string `json:"fields"`
+	} `json:"error"`
+}
+
+func TestCharacterCRUD(t *testing.T) {
+	ts, _ := newTestServer(t, nil, "")
+
+	var created models.CharacterProfile
+	if code := call(t, ts, "POST", "/api/v1/characters", "", map[string]interface{}{"name": "Aoi", "age": 20}, &created); code != http.StatusCreated {
+		t.Fatalf("create = %d", code)
+	}
+	if created.CharacterID == "" || created.Name != "Aoi" {
+		t.Fatalf("created = %+v", created)
+	}
+	path := "/api/v1/characters/" + created.CharacterID
+
+	var got models.CharacterProfile
+	if code := call(t, ts, "GET", path, "", nil, &got); code != http.StatusOK || got.Name != "Aoi" {
+		t.Fatalf("get = %d %+v", code, got)
+	}
+
+	var updated models.CharacterProfile
+	if code := call(t, ts, "PATCH", path, "", map[string]interface{}{"age": 21, "character_id": "hijacked"}, &updated); code != http.StatusOK {
+		t.Fatalf("patch = %d", code)
+	}
+	if updated.Age != 21 || updated.Name != "Aoi" || updated.CharacterID != created.CharacterID {
+		t.Fatalf("patched = %+v, want age changed, name and ID kept", updated)
+	}
+
+	if code := call(t, ts, "DELETE", path, "", nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete = %d", code)
+	}
+	var e errorBody
+	if code := call(t, ts, "GET", path, "", nil, &e); code != http.StatusNotFound || e.Error.Code != "not_found" {
+		t.Fatalf("get after delete = %d %+v", code, e)
+	}
+}
+
+func TestFactCRUD(t *testing.T) {
+	ts, repo := newTestServer(t, nil, "")
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var fact models.MemoryFact
+	body := map[string]interface{}{"fact_key": "pet", "fact_value": "a cat", "session_id": "sess_c_telegram_1"}
+	if code := call(t, ts, "POST", "/api/v1/characters/c/facts", "", body, &fact); code != http.StatusCreated {
+		t.Fatalf("create = %d", code)
+	}
+	if fact.FactID == "" || fact.SessionID != "" || fact.Confidence != 1 {
+		t.Fatalf("created = %+v, want a shared fact with full confidence", fact)
+	}
+	path := "/api/v1/characters/c/facts/" + fact.FactID
+
+	if code := call(t, ts, "PATCH", path, "", map[string]interface{}{"fact_value": "two cats"}, &fact); code != http.StatusOK || fact.FactValue.String() != "two cats" {
+		t.Fatalf("patch = %d %+v", code, fact)
+	}
+
+	var list struct{ Data []models.MemoryFact }
+	if code := call(t, ts, "GET", "/api/v1/characters/c/facts", "", nil, &list); code != http.StatusOK || len(list.Data) != 1 {
+		t.Fatalf("list = %d %+v", code, list)
+	}
+
+	if code := call(t, ts, "DELETE", path, "", nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete = %d", code)
+	}
+	if code := call(t, ts, "GET", path, "", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("get after delete = %d", code)
+	}
+}
+
+func TestCharacterPagination(t *testing.T) {
+	ts, repo := newTestServer(t, nil, "")
+	for i := range 5 {
+		if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: fmt.Sprintf("c%d", i), Name: "Aoi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var seen []string
+	cursor := ""
+	for range 5 {
+		var res struct {
+			Data       []models.CharacterProfile
+			NextCursor string `json:"next_cursor"`
+		}
+		if code := call(t, ts, "GET", "/api/v1/characters?limit=2&cursor="+cursor, "", nil, &res); code != http.StatusOK {
+			t.Fatalf("list = %d", code)
+		}
+		for _, c := range res.Data {
+			seen = append(seen, c.CharacterID)
+		}
+		if cursor = res.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if got := strings.Join(seen, ","); got != "c0,c1,c2,c3,c4" {
+		t.Fatalf("paged through %s, want every character once in order", got)
+	}
+}
+
+func TestMessagePagination(t *testing.T) {
+	ts, repo := newTestServer(t, nil, "")
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-time.Hour)
+	for i := range 5 {
+		msg := &models.ChatMessage{SessionID: "sess_c", CharacterID: "c", Role: "user",
+			Content: models.EncryptedString(fmt.Sprintf("m%d", i)), Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		if err := repo.AppendMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var seen []string
+	cursor := ""
+	for range 5 {
+		var res struct {
+			Data       []models.ChatMessage
+			NextCursor string `json:"next_cursor"`
+		}
+		if code := call(t, ts, "GET", "/api/v1/characters/c/messages?limit=2&cursor="+cursor, "", nil, &res); code != http.StatusOK {
+			t.Fatalf("list = %d", code)
+		}
+		for _, m := range res.Data {
+			seen = append(seen, m.Content.String())
+		}
+		if cursor = res.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if got := strings.Join(seen, ","); got != "m4,m3,m2,m1,m0" {
+		t.Fatalf("paged through %s, want newest first without gaps", got)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	ts, repo := newTestServer(t, nil, "")
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, method, path string
+		body               interface{}
+		fields             []string
+	}{
+		{"missing name", "POST", "/api/v1/characters", map[string]interface{}{"age": 20}, []string{"name"}},
+		{"bad age", "POST", "/api/v1/characters", map[string]interface{}{"name": "Aoi", "age": 200}, []string{"age"}},
+		{"wrong type", "POST", "/api/v1/characters", `{"name": 5}`, []string{"name"}},
+		{"blank name on patch", "PATCH", "/api/v1/characters/c", map[string]interface{}{"name": " "}, []string{"name"}},
+		{"empty fact", "POST", "/api/v1/characters/c/facts", map[string]interface{}{"confidence": 2}, []string{"fact_key", "fact_value", "confidence"}},
+		{"bad limit", "GET", "/api/v1/characters?limit=0", nil, []string{"limit"}},
+		{"bad cursor", "GET", "/api/v1/characters/c/messages?cursor=***", nil, []string{"cursor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e errorBody
+			if code := call(t, ts, tt.method, tt.path, "", tt.body, &e); code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want 422", code)
+			}
+			if e.Error.Code != "validation_failed" || len(e.Error.Fields) != len(tt.fields) {
+				t.Fatalf("error = %+v, want fields %v", e.Error, tt.fields)
+			}
+			for _, f := range tt.fields {
+				if e.Error.Fields[f] == "" {
+					t.Errorf("no message for field %s in %v", f, e.Error.Fields)
+				}
+			}
+		})
+	}
+
+	var e errorBody
+	if code := call(t, ts, "POST", "/api/v1/characters", "", `{"name": "Aoi", "nickname": "x"}`, &e); code != http.StatusBadRequest {
+		t.Fatalf("unknown field = %d, want 400", code)
+	}
+}
+
+func TestKeysScopeCharacters(t *testing.T) {
+	keys := &KeyStore{keys: []APIKey{
+		{Key: "admin", Name: "admin", Characters: []string{"*"}},
+		{Key: "aoi", Name: "aoi only", Characters: []string{"c1"}},
+	}}
+	ts, repo := newTestServer(t, keys, "")
+	for _, id := range []string{"c1", "c2"} {
+		if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: id, Name: "Aoi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if code := call(t, ts, "GET", "/api/v1/characters", "", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("no token = %d, want 401", code)
+	}
+	var res struct{ Data []models.CharacterProfile }
+	if code := call(t, ts, "GET", "/api/v1/characters", "aoi", nil, &res); code != http.StatusOK || len(res.Data) != 1 || res.Data[0].CharacterID != "c1" {
+		t.Fatalf("scoped list = %d %+v, want only c1", code, res.Data)
+	}
+	if code := call(t, ts, "GET", "/api/v1/characters/c2", "aoi", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("other character = %d, want 404", code)
+	}
+	if code := call(t, ts, "POST", "/api/v1/characters", "aoi", map[string]interface{}{"name": "Mio"}, nil); code != http.StatusForbidden {
+		t.Fatalf("scoped create = %d, want 403", code)
+	}
+	if code := call(t, ts, "GET", "/api/v1/characters/c2", "admin", nil, nil); code != http.StatusOK {
+		t.Fatalf("admin get = %d", code)
+	}
+}
+
+func TestRelationshipCeiling(t *testing.T) {
+	ts, repo := newTestServer(t, nil, "")
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi", RelationshipType: "friend"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var e errorBody
+	if code := call(t, ts, "PUT", "/api/v1/characters/c/relationship", "", map[string]interface{}{"intimacy_level": 10, "intimacy_score": 50}, &e); code != http.StatusUnprocessableEntity || e.Error.Fields["intimacy_level"] == "" {
+		t.Fatalf("level 10 for a friend = %d %+v, want 422 on intimacy_level", code, e)
+	}
+	var rel models.RelationshipState
+	if code := call(t, ts, "PUT", "/api/v1/characters/c/relationship", "", map[string]interface{}{"intimacy_level": 9, "intimacy_score": 50}, &rel); code != http.StatusOK || rel.IntimacyLevel != 9 {
+		t.Fatalf("level 9 for a friend = %d %+v", code, rel)
+	}
+}
+
+func TestWritesWaitForTurn(t *testing.T) {
+	srv, ts, repo := newTestAPI(t, nil, "")
+	if err := repo.CreateCharacter(&models.CharacterProfile{CharacterID: "c", Name: "Aoi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := []struct {
+		method, path string
+		body         map[string]interface{}
+	}{
+		{"PUT", "/api/v1/characters/c/relationship", map[string]interface{}{"intimacy_level": 6, "intimacy_score": 10}},
+		{"PATCH", "/api/v1/characters/c", map[string]interface{}{"age": 21}},
+		{"POST", "/api/v1/characters/c/facts", map[string]interface{}{"fact_key": "pet", "fact_value": "a cat"}},
+	}
+	for _, w := range writes {
+		mu := srv.CharacterLock("c")
+		mu.Lock()
+		done := make(chan int, 1)
+		go func() { done <- call(t, ts, w.method, w.path, "", w.body, nil) }()
+		select {
+		case code := <-done:
+			mu.Unlock()
+			t.Fatalf("%s %s = %d during a turn, want it to wait", w.method, w.path, code)
+		case <-time.After(100 * time.Millisecond):
+		}
+		mu.Unlock()
+		if code := <-done; code >= 300 {
+			t.Fatalf("%s %s = %d after the turn", w.method, w.path, code)
+		}
+	}
+}
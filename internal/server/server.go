@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"ai-companion-cli-go/internal/metrics"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/orchestrator"
+	"ai-companion-cli-go/internal/storage"
+)
+
+type ctxKey int
+
+const apiKeyCtxKey ctxKey = iota
+
+// Server exposes companions over HTTP
+type Server struct {
+	repo *storage.Repository
+	orch *orchestrator.Orchestrator
+	keys *KeyStore // nil disables authentication
+
+	mux *http.ServeMux
+
+	// turns on the same character are serialized so session state stays consistent
+	locksMu sync.Mutex
+	locks   map[string]*sync.Mutex
+
+	// live realtime connections, for pushing proactive messages
+	realtimeMu    sync.Mutex
+	realtimeConns map[*realtimeConn]struct{}
+}
+
+// NewServer wires the HTTP routes; keys may be nil to run without authentication
+func NewServer(repo *storage.Repository, orch *orchestrator.Orchestrator, keys *KeyStore) *Server {
+	s := &Server{
+		repo:  repo,
+		orch:  orch,
+		keys:  keys,
+		mux:   http.NewServeMux(),
+		locks: make(map[string]*sync.Mutex),
+
+		realtimeConns: make(map[*realtimeConn]struct{}),
+	}
+
+	s.mux.Handle("GET /v1/models", s.authed(s.handleListModels))
+	s.mux.Handle("POST /v1/chat/completions", s.authed(s.handleChatCompletions))
+	s.mux.Handle("GET /v1/realtime", tokenFromQuery(s.authed(s.handleRealtime)))
+	s.registerREST()
+
+	// Prometheus scrapes carry no chat content, so they stay open like /openapi.json
+	s.mux.Handle("GET /metrics", metrics.Handler())
+
+	return s
+}
+
+// Handler returns the root HTTP handler
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// ListenAndServe serves until ctx is cancelled, then shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// authed checks the bearer token and stores the resolved key in the request context
+func (s *Server) authed(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := openAccess
+		if s.keys != nil {
+			if key = s.keys.Authenticate(r); key == nil {
+				writeError(w, http.StatusUnauthorized, "invalid_api_key", "missing or invalid API key")
+				return
+			}
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), apiKeyCtxKey, key)))
+	})
+}
+
+func apiKeyFrom(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyCtxKey).(*APIKey)
+	return key
+}
+
+// CharacterLock returns the mutex guarding turns on one character, for the proactive scheduler to share
+func (s *Server) CharacterLock(characterID string) *sync.Mutex {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+	mu, ok := s.locks[characterID]
+	if !ok {
+		mu = &sync.Mutex{}
+		s.locks[characterID] = mu
+	}
+	return mu
+}
+
+// accessibleCharacter resolves a character the caller's key is allowed to use; nil if none
+func (s *Server) accessibleCharacter(ctx context.Context, characterID string) (*models.CharacterProfile, error) {
+	key := apiKeyFrom(ctx)
+	if key == nil || !key.Allows(characterID) {
+		return nil, nil
+	}
+	return s.repo.GetCharacter(characterID)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("server: write response: %v", err)
+	}
+}
+
+// writeError replies with an OpenAI-style error envelope
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    http.StatusText(status),
+			"code":    code,
+		},
+	})
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// BackupTo copies the live database into path using SQLite's online backup API
+func (db *DB) BackupTo(path string) error {
+	src, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	dst, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	return copyDatabase(dst, src)
+}
+
+// RestoreFrom overwrites the live database with the contents of the backup at path
+func (db *DB) RestoreFrom(path string) error {
+	if err := IntegrityCheck(path); err != nil {
+		return err
+	}
+	src, err := sql.Open("sqlite3", readOnlyDSN(path))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	return copyDatabase(dst, src)
+}
+
+// IntegrityCheck runs PRAGMA integrity_check against the database file at path
+func IntegrityCheck(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	conn, err := sql.Open("sqlite3", readOnlyDSN(path))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return integrityCheck(conn)
+}
+
+// IntegrityCheck runs PRAGMA integrity_check against the live database
+func (db *DB) IntegrityCheck() error {
+	conn, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	return integrityCheck(conn)
+}
+
+// readOnlyDSN opens path read-only; the driver only honours mode=ro in a file: URI, and would
+// otherwise create an empty database when path is missing
+func readOnlyDSN(path string) string {
+	escaped := strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+	return "file:" + escaped + "?mode=ro"
+}
+
+func integrityCheck(conn *sql.DB) error {
+	rows, err := conn.Query("PRAGMA integrity_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// copyDatabase streams every page of src's main database into dst's main database
+func copyDatabase(dst, src *sql.DB) error {
+	ctx := context.Background()
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dstRaw interface{}) error {
+		return srcConn.Raw(func(srcRaw interface{}) error {
+			d, ok := dstRaw.(*sqlite3.SQLiteConn)
+			s, ok2 := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return errors.New("online backup requires the sqlite3 driver")
+			}
+
+			b, err := d.Backup("main", s, "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := b.Step(-1)
+				if err != nil {
+					b.Finish()
+					return err
+				}
+				if done {
+					break
+				}
+			}
+			return b.Finish()
+		})
+	})
+}
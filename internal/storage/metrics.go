@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"ai-companion-cli-go/internal/metrics"
+	"gorm.io/gorm"
+)
+
+var (
+	queryDuration = metrics.NewHistogramVec("companion_db_query_duration_seconds",
+		"Duration of database statements by operation and table.", metrics.DBBuckets, "operation", "table")
+	queryErrors = metrics.NewCounterVec("companion_db_query_errors_total",
+		"Failed database statements by operation (record-not-found is not an error).", "operation")
+)
+
+const metricsStartKey = "metrics:start"
+
+// instrument times every statement GORM runs through its callback chains
+func instrument(db *gorm.DB) error {
+	cb := db.Callback()
+	chains := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, c := range chains {
+		operation := c.operation
+		if err := c.before("metrics:before_"+operation, startTimer); err != nil {
+			return err
+		}
+		if err := c.after("metrics:after_"+operation, func(tx *gorm.DB) { stopTimer(tx, operation) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startTimer(tx *gorm.DB) {
+	tx.InstanceSet(metricsStartKey, time.Now())
+}
+
+func stopTimer(tx *gorm.DB, operation string) {
+	v, ok := tx.InstanceGet(metricsStartKey)
+	if !ok {
+		return
+	}
+	start, ok := v.(time.Time)
+	if !ok {
+		return
+	}
+	table := tx.Statement.Table
+	if table == "" {
+		table = "-"
+	}
+	queryDuration.ObserveSince(start, operation, table)
+	if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		queryErrors.Inc(operation)
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"time"
 
 	"ai-companion-cli-go/internal/models"
 	"gorm.io/gorm"
@@ -41,6 +42,57 @@ func (r *Repository) ListCharacters() ([]models.CharacterProfile, error) {
 	return profiles, err
 }
 
+// ListCharactersPage returns up to limit characters ordered by ID, starting after the given ID
+func (r *Repository) ListCharactersPage(afterID string, limit int) ([]models.CharacterProfile, error) {
+	var profiles []models.CharacterProfile
+	q := r.db.Order("character_id asc").Limit(limit)
+	if afterID != "" {
+		q = q.Where("character_id > ?", afterID)
+	}
+	err := q.Find(&profiles).Error
+	return profiles, err
+}
+
+// SaveCharacter upserts a character profile
+func (r *Repository) SaveCharacter(character *models.CharacterProfile) error {
+	return r.db.Save(character).Error
+}
+
+// DeleteCharacter removes a character together with all of its history and memory
+func (r *Repository) DeleteCharacter(characterID string) error {
+	return r.Transaction(func(tx *Repository) error {
+		for _, model := range []interface{}{
+			&models.ChatMessage{},
+			&models.SessionState{},
+			&models.RelationshipState{},
+			&models.RelationshipTransition{},
+			&models.PersonaFlag{},
+			&models.Media{},
+			&models.MemoryFact{},
+			&models.MemorySummary{},
+			&models.LorebookEntry{},
+			&models.CharacterEmotionState{},
+			&models.ChatBinding{},
+			&models.ProactiveEvent{},
+			&models.Reminder{},
+			&models.TurnTrace{},
+			&models.CharacterProfile{},
+		} {
+			if err := tx.db.Where("character_id = ?", characterID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Transaction runs fn against a repository bound to a single database transaction
+func (r *Repository) Transaction(fn func(tx *Repository) error) error {
+	return r.db.DB.Transaction(func(gtx *gorm.DB) error {
+		return fn(&Repository{db: &DB{DB: gtx, dbPath: r.db.dbPath}})
+	})
+}
+
 // --- Session State ---
 
 // SaveSessionState upserts session state
@@ -48,6 +100,13 @@ func (r *Repository) SaveSessionState(state *models.SessionState) error {
 	return r.db.Save(state).Error
 }
 
+// ListSessionsByCharacter loads every session held with a character
+func (r *Repository) ListSessionsByCharacter(characterID string) ([]models.SessionState, error) {
+	var states []models.SessionState
+	err := r.db.Where("character_id = ?", characterID).Order("started_at asc").Find(&states).Error
+	return states, err
+}
+
 // GetSessionState loads a session
 func (r *Repository) GetSessionState(sessionID string) (*models.SessionState, error) {
 	var state models.SessionState
@@ -58,27 +117,163 @@ func (r *Repository) GetSessionState(sessionID string) (*models.SessionState, er
 	return &state, err
 }
 
+// --- Chat Bindings ---
+
+// GetChatBinding loads the binding of an external chat; nil if the chat is new
+func (r *Repository) GetChatBinding(platform, externalChatID string) (*models.ChatBinding, error) {
+	var binding models.ChatBinding
+	err := r.db.First(&binding, "platform = ? AND external_chat_id = ?", platform, externalChatID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &binding, err
+}
+
+// SaveChatBinding upserts an external chat binding
+func (r *Repository) SaveChatBinding(binding *models.ChatBinding) error {
+	return r.db.Save(binding).Error
+}
+
+// ListChatBindingsByCharacter returns the external chats of a platform talking to a character
+func (r *Repository) ListChatBindingsByCharacter(platform, characterID string) ([]models.ChatBinding, error) {
+	var bindings []models.ChatBinding
+	err := r.db.Where("platform = ? AND character_id = ?", platform, characterID).Find(&bindings).Error
+	return bindings, err
+}
+
 // --- Messages ---
 
 // AppendMessage appends to the conversation history
 func (r *Repository) AppendMessage(msg *models.ChatMessage) error {
-	return r.db.Create(msg).Error
+	if len(msg.Attachments) == 0 {
+		return r.db.Create(msg).Error
+	}
+	return r.Transaction(func(tx *Repository) error {
+		if err := tx.db.Create(msg).Error; err != nil {
+			return err
+		}
+		for i := range msg.Attachments {
+			a := &msg.Attachments[i]
+			a.MessageID, a.SessionID, a.CharacterID = msg.ID, msg.SessionID, msg.CharacterID
+			if err := tx.db.Create(a).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// GetRecentMessages retrieves N recent messages for context
-func (r *Repository) GetRecentMessages(characterID string, limit int) ([]models.ChatMessage, error) {
+// LoadAttachments fills in the attachments, with their data, of user messages
+func (r *Repository) LoadAttachments(messages []models.ChatMessage) error {
+	var ids []uint
+	for _, m := range messages {
+		if m.Role == "user" {
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var media []models.Media
+	if err := r.db.Where("message_id IN ?", ids).Order("id asc").Find(&media).Error; err != nil {
+		return err
+	}
+	byMessage := map[uint][]models.Media{}
+	for _, m := range media {
+		byMessage[m.MessageID] = append(byMessage[m.MessageID], m)
+	}
+	for i := range messages {
+		messages[i].Attachments = byMessage[messages[i].ID]
+	}
+	return nil
+}
+
+// GetMessage loads one message by ID, or nil
+func (r *Repository) GetMessage(id uint) (*models.ChatMessage, error) {
+	var msg models.ChatMessage
+	err := r.db.First(&msg, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &msg, err
+}
+
+// GetRecentSessionMessages retrieves the N most recent messages of one session in chronological order.
+// Prompts read history through it, so chats with the same character never see each other's messages.
+func (r *Repository) GetRecentSessionMessages(sessionID string, limit int) ([]models.ChatMessage, error) {
 	var messages []models.ChatMessage
-	err := r.db.Where("character_id = ?", characterID).Order("timestamp desc").Limit(limit).Find(&messages).Error
+	err := r.db.Where("session_id = ?", sessionID).Order("timestamp desc, id desc").Limit(limit).Find(&messages).Error
 	if err != nil {
 		return nil, err
 	}
-	// Reverse to chronological order
 	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
 		messages[i], messages[j] = messages[j], messages[i]
 	}
 	return messages, nil
 }
 
+// ListSessionMessages retrieves the full history of one session in chronological order
+func (r *Repository) ListSessionMessages(sessionID string) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := r.db.Where("session_id = ?", sessionID).Order("timestamp asc, id asc").Find(&messages).Error
+	return messages, err
+}
+
+// ListMessagesByCharacter retrieves the full conversation history in chronological order
+func (r *Repository) ListMessagesByCharacter(characterID string) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := r.db.Where("character_id = ?", characterID).Order("timestamp asc, id asc").Find(&messages).Error
+	return messages, err
+}
+
+// ListMessagesPage returns up to limit messages older than beforeID (0 = newest), newest first
+func (r *Repository) ListMessagesPage(characterID string, beforeID uint, limit int) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	q := r.db.Where("character_id = ?", characterID).Order("id desc").Limit(limit)
+	if beforeID > 0 {
+		q = q.Where("id < ?", beforeID)
+	}
+	err := q.Find(&messages).Error
+	return messages, err
+}
+
+// GetLastCharacterMessageByRole returns the newest message of a role across all sessions with a character, or nil
+func (r *Repository) GetLastCharacterMessageByRole(characterID, role string) (*models.ChatMessage, error) {
+	var msg models.ChatMessage
+	err := r.db.Where("character_id = ? AND role = ?", characterID, role).Order("id desc").First(&msg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &msg, err
+}
+
+// HasSessionMessages reports whether anything has been said in a session yet
+func (r *Repository) HasSessionMessages(sessionID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ChatMessage{}).Where("session_id = ?", sessionID).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// GetLastMessageByRole returns the newest message of a role in a session, or nil
+func (r *Repository) GetLastMessageByRole(sessionID, role string) (*models.ChatMessage, error) {
+	var msg models.ChatMessage
+	err := r.db.Where("session_id = ? AND role = ?", sessionID, role).Order("id desc").First(&msg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &msg, err
+}
+
+// DeleteMessagesFrom removes a message and everything after it in the same session
+func (r *Repository) DeleteMessagesFrom(sessionID string, fromID uint) error {
+	return r.Transaction(func(tx *Repository) error {
+		if err := tx.db.Where("session_id = ? AND message_id >= ?", sessionID, fromID).Delete(&models.Media{}).Error; err != nil {
+			return err
+		}
+		return tx.db.Where("session_id = ? AND id >= ?", sessionID, fromID).Delete(&models.ChatMessage{}).Error
+	})
+}
+
 // --- Relationship ---
 
 // SaveRelationshipState upserts love metrics
@@ -96,6 +291,122 @@ func (r *Repository) GetRelationshipState(characterID string) (*models.Relations
 	return &state, err
 }
 
+// AppendRelationshipTransition records a change of relationship type
+func (r *Repository) AppendRelationshipTransition(t *models.RelationshipTransition) error {
+	return r.db.Create(t).Error
+}
+
+// ListRelationshipTransitions returns the relationship type changes of a character, oldest first
+func (r *Repository) ListRelationshipTransitions(characterID string) ([]models.RelationshipTransition, error) {
+	var transitions []models.RelationshipTransition
+	err := r.db.Where("character_id = ?", characterID).Order("id asc").Find(&transitions).Error
+	return transitions, err
+}
+
+// GetLastRelationshipTransition returns the most recent relationship type change, or nil
+func (r *Repository) GetLastRelationshipTransition(characterID string) (*models.RelationshipTransition, error) {
+	var t models.RelationshipTransition
+	err := r.db.Where("character_id = ?", characterID).Order("id desc").First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &t, err
+}
+
+// AppendPersonaFlag records a contradiction of an anchored fact
+func (r *Repository) AppendPersonaFlag(flag *models.PersonaFlag) error {
+	return r.db.Create(flag).Error
+}
+
+// ListPersonaFlags returns the most recent persona consistency flags of a character, newest first
+func (r *Repository) ListPersonaFlags(characterID string, limit int) ([]models.PersonaFlag, error) {
+	var flags []models.PersonaFlag
+	err := r.db.Where("character_id = ?", characterID).Order("id desc").Limit(limit).Find(&flags).Error
+	return flags, err
+}
+
+// AppendMedia stores a picture or other file
+func (r *Repository) AppendMedia(m *models.Media) error {
+	return r.db.Create(m).Error
+}
+
+// GetMedia loads a media item including its data, or nil
+func (r *Repository) GetMedia(id uint) (*models.Media, error) {
+	var m models.Media
+	err := r.db.First(&m, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &m, err
+}
+
+// GetLastMedia loads the newest picture of a character including its data, or nil
+func (r *Repository) GetLastMedia(characterID string) (*models.Media, error) {
+	return r.lastMediaOfType(characterID, "image/%")
+}
+
+// GetLastAudio loads the newest recording or spoken reply of a character including its data, or nil
+func (r *Repository) GetLastAudio(characterID string) (*models.Media, error) {
+	return r.lastMediaOfType(characterID, "audio/%")
+}
+
+func (r *Repository) lastMediaOfType(characterID, mimePattern string) (*models.Media, error) {
+	var m models.Media
+	err := r.db.Where("character_id = ? AND mime_type LIKE ?", characterID, mimePattern).Order("id desc").First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &m, err
+}
+
+// GetMessageMedia loads the media item of a kind attached to a message including its data, or nil
+func (r *Repository) GetMessageMedia(messageID uint, kind string) (*models.Media, error) {
+	var m models.Media
+	err := r.db.Where("message_id = ? AND kind = ?", messageID, kind).Order("id desc").First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &m, err
+}
+
+// ListMediaPage returns media of a character without their data, newest first, before beforeID when non-zero
+// and of the given kinds when there are any
+func (r *Repository) ListMediaPage(characterID string, beforeID uint, limit int, kinds ...string) ([]models.Media, error) {
+	var media []models.Media
+	q := r.db.Omit("data").Where("character_id = ?", characterID).Order("id desc").Limit(limit)
+	if len(kinds) > 0 {
+		q = q.Where("kind IN ?", kinds)
+	}
+	if beforeID > 0 {
+		q = q.Where("id < ?", beforeID)
+	}
+	err := q.Find(&media).Error
+	return media, err
+}
+
+// ListMessageMedia returns the media attached to the given messages without their data, keyed by message ID
+func (r *Repository) ListMessageMedia(messageIDs []uint) (map[uint][]models.Media, error) {
+	out := map[uint][]models.Media{}
+	if len(messageIDs) == 0 {
+		return out, nil
+	}
+	var media []models.Media
+	if err := r.db.Omit("data").Where("message_id IN ?", messageIDs).Order("id asc").Find(&media).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range media {
+		out[m.MessageID] = append(out[m.MessageID], m)
+	}
+	return out, nil
+}
+
+// ListMediaByCharacter returns every media item of a character with its data, oldest first
+func (r *Repository) ListMediaByCharacter(characterID string) ([]models.Media, error) {
+	var media []models.Media
+	err := r.db.Where("character_id = ?", characterID).Order("id asc").Find(&media).Error
+	return media, err
+}
+
 // --- Memory ---
 
 // AppendMemoryFact adds a new fact the AI learned
@@ -109,3 +420,331 @@ func (r *Repository) ListMemoryFactsByCharacter(characterID string) ([]models.Me
 	err := r.db.Where("character_id = ?", characterID).Find(&facts).Error
 	return facts, err
 }
+
+// ListSessionMemoryFacts gets the facts a session may use: the character's shared facts and the ones
+// learned in that session, so what one chat told the character stays out of the others
+func (r *Repository) ListSessionMemoryFacts(characterID, sessionID string) ([]models.MemoryFact, error) {
+	var facts []models.MemoryFact
+	err := r.db.Where("character_id = ? AND (session_id = '' OR session_id IS NULL OR session_id = ?)", characterID, sessionID).
+		Find(&facts).Error
+	return facts, err
+}
+
+// SaveMemoryFact upserts a fact
+func (r *Repository) SaveMemoryFact(fact *models.MemoryFact) error {
+	return r.db.Save(fact).Error
+}
+
+// GetMemoryFact loads a single fact
+func (r *Repository) GetMemoryFact(factID string) (*models.MemoryFact, error) {
+	var fact models.MemoryFact
+	err := r.db.First(&fact, "fact_id = ?", factID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &fact, err
+}
+
+// DeleteMemoryFact makes the AI forget a fact
+func (r *Repository) DeleteMemoryFact(factID string) error {
+	return r.db.Where("fact_id = ?", factID).Delete(&models.MemoryFact{}).Error
+}
+
+// AppendMemorySummary stores a new conversation summary batch
+func (r *Repository) AppendMemorySummary(summary *models.MemorySummary) error {
+	return r.db.Create(summary).Error
+}
+
+// ListMemorySummariesByCharacter gets all summaries ordered by version
+func (r *Repository) ListMemorySummariesByCharacter(characterID string) ([]models.MemorySummary, error) {
+	var summaries []models.MemorySummary
+	err := r.db.Where("character_id = ?", characterID).Order("version asc").Find(&summaries).Error
+	return summaries, err
+}
+
+// --- Proactive Messages ---
+
+// HasProactiveEvent reports whether a trigger key already fired for a character
+func (r *Repository) HasProactiveEvent(characterID, key string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ProactiveEvent{}).Where("character_id = ? AND key = ?", characterID, key).Count(&count).Error
+	return count > 0, err
+}
+
+// GetLastProactiveEvent returns the newest proactive event of a character, or nil
+func (r *Repository) GetLastProactiveEvent(characterID string) (*models.ProactiveEvent, error) {
+	var event models.ProactiveEvent
+	err := r.db.Where("character_id = ?", characterID).Order("id desc").First(&event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &event, err
+}
+
+// CreateProactiveEvent records a sent proactive message
+func (r *Repository) CreateProactiveEvent(event *models.ProactiveEvent) error {
+	return r.db.Create(event).Error
+}
+
+// ListUndeliveredProactive returns the proactive events of a session nobody has seen yet, oldest first
+func (r *Repository) ListUndeliveredProactive(characterID, sessionID string) ([]models.ProactiveEvent, error) {
+	var events []models.ProactiveEvent
+	err := r.db.Where("character_id = ? AND delivered = ?", characterID, false).
+		Where("message_id IN (?)", r.db.Model(&models.ChatMessage{}).Select("id").Where("session_id = ?", sessionID)).
+		Order("id asc").Find(&events).Error
+	return events, err
+}
+
+// MarkProactiveDelivered flags proactive events as seen
+func (r *Repository) MarkProactiveDelivered(ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.ProactiveEvent{}).Where("id IN ?", ids).Update("delivered", true).Error
+}
+
+// --- Reminders ---
+
+// SaveReminder creates or updates a reminder
+func (r *Repository) SaveReminder(reminder *models.Reminder) error {
+	reminder.DueAt = reminder.DueAt.UTC()
+	return r.db.Save(reminder).Error
+}
+
+// GetReminder loads a single reminder, or nil
+func (r *Repository) GetReminder(id uint) (*models.Reminder, error) {
+	var reminder models.Reminder
+	err := r.db.First(&reminder, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &reminder, err
+}
+
+// ListReminders returns the reminders of a character, soonest first; done ones only when all is set
+func (r *Repository) ListReminders(characterID string, all bool) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	q := r.db.Where("character_id = ?", characterID).Order("due_at asc, id asc")
+	if !all {
+		q = q.Where("done = ?", false)
+	}
+	err := q.Find(&reminders).Error
+	return reminders, err
+}
+
+// ListDueReminders returns the open reminders of a character that are due at now, oldest first; a
+// sessionID limits them to that session. SQLite compares the times as text, so due times are stored
+// in UTC (see SaveReminder).
+func (r *Repository) ListDueReminders(characterID, sessionID string, now time.Time) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	q := r.db.Where("character_id = ? AND done = ? AND due_at <= ?", characterID, false, now.UTC())
+	if sessionID != "" {
+		q = q.Where("session_id = ?", sessionID)
+	}
+	err := q.Order("due_at asc, id asc").Find(&reminders).Error
+	return reminders, err
+}
+
+// DeleteReminder cancels a reminder
+func (r *Repository) DeleteReminder(id uint) error {
+	return r.db.Delete(&models.Reminder{}, id).Error
+}
+
+// --- Lorebook ---
+
+// SaveLorebookEntry creates or updates a lorebook entry
+func (r *Repository) SaveLorebookEntry(entry *models.LorebookEntry) error {
+	return r.db.Save(entry).Error
+}
+
+// ListLorebookEntries returns the lorebook of a character in insertion order
+func (r *Repository) ListLorebookEntries(characterID string) ([]models.LorebookEntry, error) {
+	var entries []models.LorebookEntry
+	err := r.db.Where("character_id = ?", characterID).Order("insertion_order asc, id asc").Find(&entries).Error
+	return entries, err
+}
+
+// GetLorebookEntry loads a lorebook entry by ID, or nil
+func (r *Repository) GetLorebookEntry(id uint) (*models.LorebookEntry, error) {
+	var entry models.LorebookEntry
+	err := r.db.First(&entry, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &entry, err
+}
+
+// DeleteLorebookEntry removes a lorebook entry
+func (r *Repository) DeleteLorebookEntry(id uint) error {
+	return r.db.Delete(&models.LorebookEntry{}, id).Error
+}
+
+// --- Turn Traces ---
+
+// AppendTurnTrace stores a trace and drops all but the newest keep traces (keep <= 0 keeps everything)
+func (r *Repository) AppendTurnTrace(trace *models.TurnTrace, keep int) error {
+	if err := r.db.Create(trace).Error; err != nil {
+		return err
+	}
+	if keep <= 0 || trace.ID <= uint(keep) {
+		return nil
+	}
+	return r.db.Where("id <= ?", trace.ID-uint(keep)).Delete(&models.TurnTrace{}).Error
+}
+
+// ListTurnTraces returns the newest traces of a character, newest first
+func (r *Repository) ListTurnTraces(characterID string, limit int) ([]models.TurnTrace, error) {
+	var traces []models.TurnTrace
+	err := r.db.Where("character_id = ?", characterID).Order("id desc").Limit(limit).Find(&traces).Error
+	return traces, err
+}
+
+// GetTurnTrace loads a trace by ID, or nil
+func (r *Repository) GetTurnTrace(id uint) (*models.TurnTrace, error) {
+	var trace models.TurnTrace
+	err := r.db.First(&trace, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &trace, err
+}
+
+// --- Usage ---
+
+// AppendUsageRecord stores the usage of one model call
+func (r *Repository) AppendUsageRecord(rec *models.UsageRecord) error {
+	return r.db.Create(rec).Error
+}
+
+// SumUsageCost totals the cost of all calls since a point in time
+func (r *Repository) SumUsageCost(since time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.UsageRecord{}).
+		Where("created_at >= ?", since.UTC()).
+		Select("COALESCE(SUM(cost_usd), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// ListUsageRecords returns the calls made in [since, until), oldest first
+func (r *Repository) ListUsageRecords(since, until time.Time) ([]models.UsageRecord, error) {
+	var records []models.UsageRecord
+	err := r.db.Where("created_at >= ? AND created_at < ?", since.UTC(), until.UTC()).Order("id asc").Find(&records).Error
+	return records, err
+}
+
+// --- Emotion ---
+
+// SaveEmotionState upserts the current emotion of a character
+func (r *Repository) SaveEmotionState(state *models.CharacterEmotionState) error {
+	return r.db.Save(state).Error
+}
+
+// GetEmotionState fetches the current emotion of a character
+func (r *Repository) GetEmotionState(characterID string) (*models.CharacterEmotionState, error) {
+	var state models.CharacterEmotionState
+	err := r.db.First(&state, "character_id = ?", characterID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &state, err
+}
+
+// --- Encryption ---
+
+// GetEncryptionSettings returns the key-derivation settings, or nil when the database is not encrypted
+func (r *Repository) GetEncryptionSettings() (*models.EncryptionSettings, error) {
+	var settings models.EncryptionSettings
+	err := r.db.First(&settings, 1).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &settings, err
+}
+
+// SaveEncryptionSettings upserts the single encryption settings row
+func (r *Repository) SaveEncryptionSettings(settings *models.EncryptionSettings) error {
+	settings.ID = 1
+	return r.db.Save(settings).Error
+}
+
+// DeleteEncryptionSettings marks the database as unencrypted
+func (r *Repository) DeleteEncryptionSettings() error {
+	return r.db.Where("id = ?", 1).Delete(&models.EncryptionSettings{}).Error
+}
+
+// ReencryptAll loads every encrypted column with the current cipher, calls swap to install the
+// next one, and writes the rows back so they are sealed with the new key
+func (r *Repository) ReencryptAll(swap func(tx *Repository) error) error {
+	return r.Transaction(func(tx *Repository) error {
+		var messages []models.ChatMessage
+		var facts []models.MemoryFact
+		var summaries []models.MemorySummary
+		var traces []models.TurnTrace
+		var flags []models.PersonaFlag
+		var media []models.Media
+		var reminders []models.Reminder
+		if err := tx.db.Find(&messages).Error; err != nil {
+			return err
+		}
+		if err := tx.db.Find(&facts).Error; err != nil {
+			return err
+		}
+		if err := tx.db.Find(&summaries).Error; err != nil {
+			return err
+		}
+		if err := tx.db.Find(&traces).Error; err != nil {
+			return err
+		}
+		if err := tx.db.Find(&flags).Error; err != nil {
+			return err
+		}
+		if err := tx.db.Find(&media).Error; err != nil {
+			return err
+		}
+		if err := tx.db.Find(&reminders).Error; err != nil {
+			return err
+		}
+
+		if err := swap(tx); err != nil {
+			return err
+		}
+
+		for i := range messages {
+			if err := tx.db.Save(&messages[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range facts {
+			if err := tx.db.Save(&facts[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range summaries {
+			if err := tx.db.Save(&summaries[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range traces {
+			if err := tx.db.Save(&traces[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range flags {
+			if err := tx.db.Save(&flags[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range media {
+			if err := tx.db.Save(&media[i]).Error; err != nil {
+				return err
+			}
+		}
+		for i := range reminders {
+			if err := tx.db.Save(&reminders[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
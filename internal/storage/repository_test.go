@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+)
+
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	db := NewDB(filepath.Join(t.TempDir(), "companion.db"))
+	if err := db.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	t.Cleanup(func() {
+		if conn, err := db.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	return NewRepository(db)
+}
+
+func TestGetRecentSessionMessagesStaysInSession(t *testing.T) {
+	repo := newTestRepository(t)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		session := "sess_c_telegram_1"
+		if i%2 == 1 {
+			session = "sess_c_telegram_2"
+		}
+		msg := &models.ChatMessage{
+			SessionID:   session,
+			CharacterID: "c",
+			Role:        "user",
+			Content:     models.EncryptedString(fmt.Sprintf("%s #%d", session, i)),
+			Timestamp:   start.Add(time.Duration(i) * time.Second),
+		}
+		if err := repo.AppendMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgs, err := repo.GetRecentSessionMessages("sess_c_telegram_1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sess_c_telegram_1 #2", "sess_c_telegram_1 #4"}
+	if len(msgs) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(msgs), len(want))
+	}
+	for i, m := range msgs {
+		if m.Content.String() != want[i] {
+			t.Fatalf("message %d = %q, want %q", i, m.Content.String(), want[i])
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package storage
 
 import (
+	"fmt"
 	"log"
+	"log/slog"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"ai-companion-cli-go/internal/models"
 	"gorm.io/driver/sqlite"
@@ -18,7 +22,13 @@ type DB struct {
 
 // NewDB initialize database connection given path
 func NewDB(dbPath string) *DB {
-	newLogger := logger.Default.LogMode(logger.Silent)
+	// Only slow statements and errors are logged, without bound values so chat content stays out of logs
+	newLogger := logger.New(slogWriter{}, logger.Config{
+		SlowThreshold:             200 * time.Millisecond,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+		ParameterizedQueries:      true,
+	})
 
 	gormDB, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
 		Logger: newLogger,
@@ -26,6 +36,9 @@ func NewDB(dbPath string) *DB {
 	if err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
+	if err := instrument(gormDB); err != nil {
+		log.Fatalf("failed to instrument database: %v", err)
+	}
 
 	return &DB{
 		DB:     gormDB,
@@ -33,16 +46,34 @@ func NewDB(dbPath string) *DB {
 	}
 }
 
+// slogWriter feeds GORM's logger into slog
+type slogWriter struct{}
+
+func (slogWriter) Printf(format string, args ...interface{}) {
+	slog.Warn(strings.TrimSpace(fmt.Sprintf(format, args...)), "component", "storage")
+}
+
 // Initialize creates tables if they don't exist
 func (db *DB) Initialize() error {
+	slog.Debug("migrating database", "component", "storage", "path", db.dbPath)
 	return db.AutoMigrate(
 		&models.CharacterProfile{},
 		&models.ChatMessage{},
 		&models.SessionState{},
 		&models.RelationshipState{},
+		&models.RelationshipTransition{},
+		&models.PersonaFlag{},
+		&models.Media{},
 		&models.MemoryFact{},
 		&models.MemorySummary{},
+		&models.LorebookEntry{},
 		&models.CharacterEmotionState{},
+		&models.EncryptionSettings{},
+		&models.ChatBinding{},
+		&models.ProactiveEvent{},
+		&models.Reminder{},
+		&models.TurnTrace{},
+		&models.UsageRecord{},
 	)
 }
 
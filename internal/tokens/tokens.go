@@ -0,0 +1,39 @@
+// Package tokens estimates prompt sizes without a model-specific tokenizer
+package tokens
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Estimate approximates a tokenizer: one token per CJK character, one per four other characters
+func Estimate(s string) int {
+	wide, other := 0, 0
+	for _, r := range s {
+		if isWide(r) {
+			wide++
+		} else {
+			other++
+		}
+	}
+	return wide + (other+3)/4
+}
+
+// Truncate cuts s to roughly n tokens, marking the cut with an ellipsis
+func Truncate(s string, n int) string {
+	budget := n * 4 // in quarter tokens
+	for i, r := range s {
+		cost := 1
+		if isWide(r) {
+			cost = 4
+		}
+		if budget -= cost; budget < 0 {
+			return strings.TrimSpace(s[:i]) + "…"
+		}
+	}
+	return s
+}
+
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
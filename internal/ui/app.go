@@ -3,14 +3,19 @@ package ui
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 
+	"ai-companion-cli-go/internal/audio"
 	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/media"
 	"ai-companion-cli-go/internal/models"
 	"ai-companion-cli-go/internal/orchestrator"
 	"ai-companion-cli-go/internal/storage"
+	"ai-companion-cli-go/internal/vault"
 
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -24,6 +29,8 @@ var (
 			Padding(0, 1).
 			MarginBottom(1)
 
+	stageStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Italic(true).PaddingLeft(1)
+
 	systemStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
 	userStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("39")).Bold(true)
 	aiStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
@@ -38,6 +45,13 @@ type streamMsg string
 // streamDone signals the end of stream
 type streamDone struct{}
 
+// imageMsg brings a generated picture, and for selfies the message it was sent with
+type imageMsg struct {
+	media   *models.Media
+	message *models.ChatMessage
+	err     error
+}
+
 type AppModel struct {
 	viewport viewport.Model
 	messages []string
@@ -51,14 +65,43 @@ type AppModel struct {
 	profile *models.CharacterProfile
 	session *models.SessionState
 
+	// vault gates the chat behind a passphrase prompt when the database is encrypted
+	vault     *vault.Vault
+	passInput textinput.Model
+
 	isStreaming  bool
 	currentReply strings.Builder
 
+	// showDebug swaps the conversation for the latest turn trace (Ctrl+T)
+	showDebug bool
+
+	// stage is the relationship stage shown in the header, e.g. "Lv7 · Crush"
+	stage string
+
+	// imageProtocol is how /image draws pictures (media.ProtocolAuto detects it)
+	imageProtocol string
+
+	// pending are the files added with /attach, sent with the next message
+	pending []models.Media
+
+	// recording is the microphone recording in progress (Ctrl+R); voiceReplies reads replies aloud (/voice)
+	recording     *audio.Recording
+	voiceReplies  bool
+	recordCommand string
+	playCommand   string
+
+	// remind delivers due reminders while the chat is open, see SetReminders
+	remind      func(ctx context.Context) (*models.ChatMessage, error)
+	reminding   bool
+	reminderErr string
+	// reachOut lets the character speak first once the chat is open, see SetProactive
+	reachOut func(ctx context.Context) (*models.ChatMessage, error)
+
 	ctx        context.Context
 	cancelFunc context.CancelFunc
 }
 
-func InitialModel(repo *storage.Repository, llmClient *llm.Client, orch *orchestrator.Orchestrator, profile *models.CharacterProfile, session *models.SessionState) AppModel {
+func InitialModel(repo *storage.Repository, llmClient *llm.Client, orch *orchestrator.Orchestrator, profile *models.CharacterProfile, session *models.SessionState, v *vault.Vault) AppModel {
 	ta := textarea.New()
 	ta.Placeholder = "Type a message..."
 	ta.Focus()
@@ -66,41 +109,116 @@ func InitialModel(repo *storage.Repository, llmClient *llm.Client, orch *orchest
 	ta.SetWidth(80)
 	ta.SetHeight(3)
 
-	vp := viewport.New(80, 20)
-	vp.SetContent(systemStyle.Render(fmt.Sprintf("\nChat with %s started. Intimacy Level: %d. Press Ctrl+C to quit.\n", profile.Name, 7))) // Simplification on intimacy initial
-
-	// Load history
-	hist, _ := repo.GetRecentMessages(profile.CharacterID, 50)
-	var histLines []string
-	histLines = append(histLines, systemStyle.Render(fmt.Sprintf("\nChat with %s started. Press Ctrl+C to quit.\n", profile.Name)))
+	pi := textinput.New()
+	pi.Placeholder = "Passphrase"
+	pi.EchoMode = textinput.EchoPassword
+	pi.EchoCharacter = '•'
+	pi.Width = 40
 
-	for _, m := range hist {
-		if m.Role == "user" {
-			histLines = append(histLines, userStyle.Render("You: ")+m.Content)
-		} else {
-			histLines = append(histLines, aiStyle.Render(profile.Name+": ")+m.Content)
-		}
-	}
-	vp.SetContent(strings.Join(histLines, "\n\n"))
-	vp.GotoBottom()
+	vp := viewport.New(80, 20)
 
-	return AppModel{
+	m := AppModel{
 		textarea:     ta,
-		messages:     histLines,
 		viewport:     vp,
 		repo:         repo,
 		llmClient:    llmClient,
 		orchestrator: orch,
 		profile:      profile,
 		session:      session,
+		vault:        v,
+		passInput:    pi,
+	}
+	m.refreshStage()
+
+	if m.locked() {
+		m.textarea.Blur()
+		m.passInput.Focus()
+		m.viewport.SetContent(systemStyle.Render("\nThis conversation database is encrypted. Enter your passphrase to unlock it.\n"))
+		return m
 	}
+
+	m.loadHistory()
+	return m
+}
+
+// SetImageProtocol chooses how /image draws pictures: auto, kitty, iterm, sixel or text
+func (m *AppModel) SetImageProtocol(protocol string) {
+	m.imageProtocol = protocol
+}
+
+// locked reports whether the passphrase prompt must be shown instead of the chat
+func (m AppModel) locked() bool {
+	return m.vault != nil && m.vault.Locked()
+}
+
+// loadHistory renders the recent conversation into the viewport
+func (m *AppModel) loadHistory() {
+	// Greet only posts into a session without messages, which is the history read below
+	if _, err := m.orchestrator.Greet(m.profile, m.session); err != nil {
+		slog.Error("post greeting", "component", "ui", "session_id", m.session.SessionID, "err", err)
+	}
+	hist, err := m.repo.GetRecentSessionMessages(m.session.SessionID, 50)
+	if err != nil {
+		slog.Error("load history", "component", "ui", "session_id", m.session.SessionID, "err", err)
+	}
+	ids := make([]uint, len(hist))
+	for i, msg := range hist {
+		ids[i] = msg.ID
+	}
+	attached, _ := m.repo.ListMessageMedia(ids)
+
+	var histLines []string
+	histLines = append(histLines, systemStyle.Render(fmt.Sprintf("\nChat with %s started. Press Ctrl+C to quit, Ctrl+T for the debug panel; /selfie [scene], /portrait and /image [id] for pictures, /attach <file> or drop a file to share it, Ctrl+R to talk and /voice to hear replies.\n", m.profile.Name)))
+
+	for _, msg := range hist {
+		if msg.Role == "user" {
+			histLines = append(histLines, userStyle.Render("You: ")+msg.Content.String()+mediaLines(attached[msg.ID]))
+		} else {
+			histLines = append(histLines, aiStyle.Render(m.profile.Name+": ")+msg.Content.String()+mediaLines(attached[msg.ID]))
+		}
+	}
+	m.messages = histLines
+	m.viewport.SetContent(strings.Join(histLines, "\n\n"))
+	m.viewport.GotoBottom()
+}
+
+// updateLocked handles input while the passphrase prompt is shown
+func (m AppModel) updateLocked(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+		case tea.KeyEnter:
+			if err := m.vault.Unlock(m.passInput.Value()); err != nil {
+				m.passInput.Reset()
+				m.viewport.SetContent(systemStyle.Render(fmt.Sprintf("\nUnlock failed: %v. Try again.\n", err)))
+				return m, nil
+			}
+			m.passInput.Reset()
+			m.passInput.Blur()
+			m.textarea.Focus()
+			m.loadHistory()
+			return m, tea.Batch(textarea.Blink, m.reminderTickCmd(), m.proactiveCmd())
+		}
+	}
+
+	var cmd tea.Cmd
+	m.passInput, cmd = m.passInput.Update(msg)
+	return m, cmd
 }
 
 func (m AppModel) Init() tea.Cmd {
-	return textarea.Blink
+	if m.locked() {
+		return textinput.Blink
+	}
+	return tea.Batch(textarea.Blink, m.reminderTickCmd(), m.proactiveCmd())
 }
 
 func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.locked() {
+		return m.updateLocked(msg)
+	}
+
 	var (
 		tiCmd tea.Cmd
 		vpCmd tea.Cmd
@@ -113,7 +231,21 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
+			if m.recording != nil {
+				m.recording.Cancel()
+			}
 			return m, tea.Quit
+		case tea.KeyCtrlR:
+			if m.isStreaming {
+				return m, nil
+			}
+			cmd := m.toggleRecording()
+			m.refreshViewport()
+			return m, cmd
+		case tea.KeyCtrlT:
+			m.showDebug = !m.showDebug
+			m.refreshViewport()
+			return m, nil
 		case tea.KeyEnter:
 			if msg.Alt && !m.isStreaming {
 				// We inject real newline for Alt+Enter
@@ -127,10 +259,30 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 
-				m.messages = append(m.messages, userStyle.Render("You: ")+v)
-				m.viewport.SetContent(strings.Join(m.messages, "\n\n"))
+				if cmd, ok := m.slashCommand(v); ok {
+					m.textarea.Reset()
+					m.refreshViewport()
+					return m, cmd
+				}
+
+				// Files dragged onto the terminal arrive as their paths
+				v, paths := media.ExtractPaths(v)
+				if !m.attach(paths) {
+					m.refreshViewport()
+					return m, nil
+				}
+				if v == "" {
+					// A file dropped on its own waits for the words that go with it
+					m.textarea.Reset()
+					m.refreshViewport()
+					return m, nil
+				}
+				attachments := m.pending
+				m.pending = nil
+
+				m.messages = append(m.messages, userStyle.Render("You: ")+v+mediaLines(attachments))
 				m.textarea.Reset()
-				m.viewport.GotoBottom()
+				m.refreshViewport()
 
 				m.isStreaming = true
 				m.currentReply.Reset()
@@ -138,7 +290,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Start orchestrator logic
 				m.ctx, m.cancelFunc = context.WithCancel(context.Background())
 
-				return m, m.startStreamCmd(v)
+				return m, m.startStreamCmdOverwrite(v, attachments...)
 			}
 		}
 
@@ -152,8 +304,10 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		liveText := m.currentReply.String()
 		displayMsgs = append(displayMsgs, streamingAIStyle.Render(m.profile.Name+": ")+liveText+" █")
 
-		m.viewport.SetContent(strings.Join(displayMsgs, "\n\n"))
-		m.viewport.GotoBottom()
+		if !m.showDebug {
+			m.viewport.SetContent(strings.Join(displayMsgs, "\n\n"))
+			m.viewport.GotoBottom()
+		}
 
 		// Re-trigger view for next token chunk
 		return m, m.waitForNextChunkCmd()
@@ -161,21 +315,96 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case streamDone:
 		m.isStreaming = false
 		m.messages = append(m.messages, aiStyle.Render(m.profile.Name+": ")+m.currentReply.String())
-		m.viewport.SetContent(strings.Join(m.messages, "\n\n"))
-		m.viewport.GotoBottom()
+		m.refreshStage()
+		m.refreshViewport()
+		if m.voiceReplies {
+			return m, m.speakCmd()
+		}
+		return m, nil
+
+	case transcribedMsg:
+		if msg.err != nil {
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", msg.err)))
+		} else {
+			// The words go into the textarea to be checked before sending; the recording goes along
+			if v := strings.TrimSpace(m.textarea.Value()); v != "" {
+				msg.text = v + " " + msg.text
+			}
+			m.textarea.SetValue(msg.text)
+			m.pending = append(m.pending, *msg.media)
+			m.messages = append(m.messages, systemStyle.Render("🎙 Transcribed; edit if needed and press Enter to send."))
+		}
+		m.refreshViewport()
+		return m, nil
+
+	case spokenMsg:
+		if msg.err != nil {
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Voice error: %v", msg.err)))
+			m.refreshViewport()
+		}
+		return m, nil
+
+	case imageMsg:
+		m.isStreaming = false
+		switch {
+		case msg.err != nil:
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", msg.err)))
+		case msg.message != nil:
+			m.messages = append(m.messages, aiStyle.Render(m.profile.Name+": ")+msg.message.Content.String()+mediaLines([]models.Media{*msg.media}))
+		default:
+			m.messages = append(m.messages, systemStyle.Render("Portrait ready:")+mediaLines([]models.Media{*msg.media}))
+		}
+		m.refreshViewport()
 		return m, nil
 
+	case imageShown:
+		if msg.err != nil {
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", msg.err)))
+			m.refreshViewport()
+		}
+		return m, nil
+
+	case reminderTick, reminderMsg, proactiveMsg:
+		return m.updateReminders(msg)
+
 	case errMsg:
 		m.err = msg
 		m.isStreaming = false
 		m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", msg)))
-		m.viewport.SetContent(strings.Join(m.messages, "\n\n"))
+		m.refreshViewport()
 		return m, nil
 	}
 
 	return m, tea.Batch(tiCmd, vpCmd)
 }
 
+// refreshStage reloads the intimacy level and names its stage for the header
+func (m *AppModel) refreshStage() {
+	level := m.orchestrator.Progression(m.profile).StartLevel
+	if rel, err := m.repo.GetRelationshipState(m.profile.CharacterID); err == nil && rel != nil && rel.IntimacyLevel > 0 {
+		level = rel.IntimacyLevel
+	}
+	m.stage = fmt.Sprintf("Lv%d · %s", level, m.orchestrator.Stage(m.profile, level).Name)
+}
+
+// refreshViewport shows either the conversation or the debug panel
+func (m *AppModel) refreshViewport() {
+	if !m.showDebug {
+		m.viewport.SetContent(strings.Join(m.messages, "\n\n"))
+		m.viewport.GotoBottom()
+		return
+	}
+
+	content := "\nNo turn traces yet. Send a message (tracing is off when TRACE_KEEP=0).\n"
+	if traces, err := m.repo.ListTurnTraces(m.profile.CharacterID, 1); err != nil {
+		content = fmt.Sprintf("\nCould not load traces: %v\n", err)
+	} else if len(traces) > 0 {
+		content = lipgloss.NewStyle().Width(m.viewport.Width).Render(orchestrator.FormatTrace(&traces[0]))
+	}
+	m.viewport.SetContent(systemStyle.Render("Debug panel (Ctrl+T to return to the chat)") + "\n\n" + content)
+	m.viewport.GotoTop()
+}
+
 // startStreamCmd initiates the channel listener logic
 func (m AppModel) startStreamCmd(userText string) tea.Cmd {
 	return func() tea.Msg {
@@ -227,8 +456,8 @@ func (m AppModel) startStreamCmd(userText string) tea.Cmd {
 var activeTokenChan <-chan string
 var activeErrChan <-chan error
 
-func (m AppModel) startStreamImprovedCmd(userText string) tea.Cmd {
-	tokenChan, errChan := m.orchestrator.GenerateReplyStream(m.ctx, userText, m.profile, m.session)
+func (m AppModel) startStreamImprovedCmd(userText string, attachments ...models.Media) tea.Cmd {
+	tokenChan, errChan := m.orchestrator.GenerateReplyStream(m.ctx, userText, m.profile, m.session, attachments...)
 	activeTokenChan = tokenChan
 	activeErrChan = errChan
 	return m.waitForNextChunkCmd()
@@ -252,12 +481,18 @@ func (m AppModel) waitForNextChunkCmd() tea.Cmd {
 }
 
 // Overwrite the original
-func (m AppModel) startStreamCmdOverwrite(userText string) tea.Cmd {
-	return m.startStreamImprovedCmd(userText)
+func (m AppModel) startStreamCmdOverwrite(userText string, attachments ...models.Media) tea.Cmd {
+	return m.startStreamImprovedCmd(userText, attachments...)
 }
 
 func (m AppModel) View() string {
-	head := titleStyle.Render(fmt.Sprintf(" ♥ AI Companion: %s ♥ ", m.profile.Name))
+	head := lipgloss.JoinHorizontal(lipgloss.Top,
+		titleStyle.Render(fmt.Sprintf(" ♥ AI Companion: %s ♥ ", m.profile.Name)),
+		stageStyle.Render(m.stage))
+
+	if m.locked() {
+		return fmt.Sprintf("%s\n\n%s\n\n%s", head, m.viewport.View(), m.passInput.View())
+	}
 
 	return fmt.Sprintf(
 		"%s\n\n%s\n\n%s",
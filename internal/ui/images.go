@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// imageShown is sent when the full-screen picture viewer returns to the chat
+type imageShown struct{ err error }
+
+// slashCommand handles the picture, attachment and voice commands typed into the chat; ok is false for ordinary text
+func (m *AppModel) slashCommand(text string) (tea.Cmd, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	arg := strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+
+	switch fields[0] {
+	case "/selfie":
+		m.isStreaming = true
+		m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("%s is taking a selfie…", m.profile.Name)))
+		orch, profile, session := m.orchestrator, m.profile, m.session
+		return func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), llm.ImageTimeout)
+			defer cancel()
+			md, msg, err := orch.Selfie(ctx, profile, session, arg)
+			return imageMsg{media: md, message: msg, err: err}
+		}, true
+	case "/portrait":
+		m.isStreaming = true
+		m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Drawing a portrait of %s…", m.profile.Name)))
+		orch, profile := m.orchestrator, m.profile
+		return func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), llm.ImageTimeout)
+			defer cancel()
+			md, err := orch.Portrait(ctx, profile)
+			return imageMsg{media: md, err: err}
+		}, true
+	case "/attach":
+		if arg == "" {
+			m.messages = append(m.messages, systemStyle.Render("Usage: /attach <image or text file>, or /attach clear"))
+			return nil, true
+		}
+		if arg == "clear" {
+			m.pending = nil
+			m.messages = append(m.messages, systemStyle.Render("Attachments dropped."))
+			return nil, true
+		}
+		if _, paths := media.ExtractPaths(arg); len(paths) > 0 {
+			m.attach(paths)
+		} else {
+			m.attach([]string{arg})
+		}
+		return nil, true
+	case "/image":
+		md, err := m.findImage(arg)
+		if err != nil {
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", err)))
+			return nil, true
+		}
+		// Pictures cannot be drawn inside the full-screen UI, so it steps aside while one is shown
+		return tea.Exec(&imageViewer{media: md, protocol: m.imageProtocol}, func(err error) tea.Msg {
+			return imageShown{err: err}
+		}), true
+	}
+	return m.voiceCommand(fields[0], arg)
+}
+
+// findImage loads the picture with the given ID, or the newest one when ref is empty
+func (m *AppModel) findImage(ref string) (*models.Media, error) {
+	if ref == "" || ref == "last" {
+		md, err := m.repo.GetLastMedia(m.profile.CharacterID)
+		if err == nil && md == nil {
+			err = fmt.Errorf("no pictures yet; try /selfie")
+		}
+		return md, err
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(ref, "#"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("usage: /image [id]")
+	}
+	md, err := m.repo.GetMedia(uint(id))
+	if err == nil && (md == nil || md.CharacterID != m.profile.CharacterID) {
+		err = fmt.Errorf("picture %d not found", id)
+	}
+	return md, err
+}
+
+// attach queues files for the next message, reporting each; false when one of them cannot be attached
+func (m *AppModel) attach(paths []string) bool {
+	for _, path := range paths {
+		md, err := media.Load(path)
+		if err != nil {
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", err)))
+			return false
+		}
+		m.pending = append(m.pending, *md)
+		note := "sent with your next message"
+		if md.Kind == media.KindImage && !m.orchestrator.Vision() {
+			note = "the model cannot see pictures, so it is only told about it"
+		}
+		m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("📎 %s (%s)", md.Name, note)))
+	}
+	return true
+}
+
+// mediaLines renders the placeholders of the pictures, recordings and files attached to a message
+func mediaLines(items []models.Media) string {
+	var b strings.Builder
+	for i := range items {
+		line := media.Placeholder(&items[i])
+		switch {
+		case items[i].ID == 0 || items[i].Kind == media.KindFile:
+		case media.IsAudio(&items[i]):
+			line += fmt.Sprintf(" /play %d to listen", items[i].ID)
+		default:
+			line += fmt.Sprintf(" /image %d to view", items[i].ID)
+		}
+		b.WriteString("\n" + systemStyle.Render(line))
+	}
+	return b.String()
+}
+
+// imageViewer draws a picture on the plain terminal and waits for Enter (a tea.ExecCommand)
+type imageViewer struct {
+	media    *models.Media
+	protocol string
+	stdin    io.Reader
+	stdout   io.Writer
+}
+
+func (v *imageViewer) SetStdin(r io.Reader)  { v.stdin = r }
+func (v *imageViewer) SetStdout(w io.Writer) { v.stdout = w }
+func (v *imageViewer) SetStderr(io.Writer)   {}
+
+func (v *imageViewer) Run() error {
+	fmt.Fprint(v.stdout, "\x1b[2J\x1b[H")
+	if err := media.Render(v.stdout, v.media, v.protocol); err != nil {
+		return err
+	}
+	fmt.Fprintf(v.stdout, "\n%s\nPress Enter to return to the chat.", media.Placeholder(v.media))
+	_, err := bufio.NewReader(v.stdin).ReadString('\n')
+	return err
+}
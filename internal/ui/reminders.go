@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reminderEvery is how often the open chat looks for due reminders
+const reminderEvery = 30 * time.Second
+
+// reminderTick asks for the next reminder check
+type reminderTick struct{}
+
+// reminderMsg brings the message a due reminder was delivered with, if any
+type reminderMsg struct {
+	message *models.ChatMessage
+	err     error
+}
+
+// proactiveMsg brings the message the character opened the chat with, if any
+type proactiveMsg struct {
+	message *models.ChatMessage
+	err     error
+}
+
+// SetProactive lets the character speak first when the chat opens. reachOut writes a due proactive
+// message into the chat's session and returns it, or nil when the character has nothing to say.
+func (m *AppModel) SetProactive(reachOut func(ctx context.Context) (*models.ChatMessage, error)) {
+	m.reachOut = reachOut
+}
+
+// proactiveCmd asks the character once, outside the Update loop, so a slow endpoint never holds up the chat
+func (m AppModel) proactiveCmd() tea.Cmd {
+	reachOut := m.reachOut
+	if reachOut == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		msg, err := reachOut(ctx)
+		return proactiveMsg{message: msg, err: err}
+	}
+}
+
+// SetReminders lets the chat bring up reminders the character promised while it is open. remind delivers
+// the oldest due reminder and returns its message, or nil when none is due.
+func (m *AppModel) SetReminders(remind func(ctx context.Context) (*models.ChatMessage, error)) {
+	m.remind = remind
+}
+
+// reminderTickCmd schedules the next reminder check
+func (m AppModel) reminderTickCmd() tea.Cmd {
+	if m.remind == nil {
+		return nil
+	}
+	return tea.Tick(reminderEvery, func(time.Time) tea.Msg { return reminderTick{} })
+}
+
+// remindCmd delivers a due reminder outside the Update loop
+func (m AppModel) remindCmd() tea.Cmd {
+	remind := m.remind
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		msg, err := remind(ctx)
+		return reminderMsg{message: msg, err: err}
+	}
+}
+
+// updateReminders handles the reminder check and proactive messages; a check waits while a reply is streaming
+func (m AppModel) updateReminders(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case reminderTick:
+		if m.isStreaming || m.reminding {
+			return m, m.reminderTickCmd()
+		}
+		m.reminding = true
+		return m, m.remindCmd()
+	case reminderMsg:
+		m.reminding = false
+		switch {
+		case msg.err != nil:
+			// A failing reminder is retried on every check, but only reported once
+			if msg.err.Error() != m.reminderErr {
+				m.reminderErr = msg.err.Error()
+				m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Reminder error: %v", msg.err)))
+				m.refreshViewport()
+			}
+		case msg.message != nil:
+			m.reminderErr = ""
+			m.messages = append(m.messages, aiStyle.Render(m.profile.Name+": ")+"⏰ "+msg.message.Content.String())
+			m.refreshViewport()
+		}
+		return m, m.reminderTickCmd()
+	case proactiveMsg:
+		if msg.err != nil {
+			slog.Warn("proactive message failed", "component", "ui", "session_id", m.session.SessionID, "err", msg.err)
+		} else if msg.message != nil {
+			m.messages = append(m.messages, aiStyle.Render(m.profile.Name+": ")+msg.message.Content.String())
+			m.refreshViewport()
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-companion-cli-go/internal/audio"
+	"ai-companion-cli-go/internal/media"
+	"ai-companion-cli-go/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sashabaranov/go-openai"
+)
+
+// voiceTimeout bounds one transcription, or one synthesis and its playback
+const voiceTimeout = 2 * time.Minute
+
+// transcribedMsg brings the text of a recording, and the recording to send along with it
+type transcribedMsg struct {
+	text  string
+	media *models.Media
+	err   error
+}
+
+// spokenMsg is sent when a reply has been read aloud
+type spokenMsg struct{ err error }
+
+// SetVoice sets the recorder and player commands (audio.Auto detects them) and whether replies are
+// read aloud from the start
+func (m *AppModel) SetVoice(recordCommand, playCommand string, replies bool) {
+	m.recordCommand = recordCommand
+	m.playCommand = playCommand
+	m.voiceReplies = replies
+}
+
+// voiceCommand handles the voice commands typed into the chat; ok is false for anything else
+func (m *AppModel) voiceCommand(name, arg string) (tea.Cmd, bool) {
+	switch name {
+	case "/voice":
+		switch arg {
+		case "on":
+			m.voiceReplies = true
+		case "off":
+			m.voiceReplies = false
+		case "":
+			m.voiceReplies = !m.voiceReplies
+		default:
+			m.messages = append(m.messages, systemStyle.Render("Usage: /voice [on|off]"))
+			return nil, true
+		}
+		state := "off"
+		if m.voiceReplies {
+			state = "on: replies are read aloud"
+		}
+		m.messages = append(m.messages, systemStyle.Render("Voice replies "+state+". Ctrl+R records a message."))
+		return nil, true
+	case "/transcribe":
+		if arg == "" {
+			m.messages = append(m.messages, systemStyle.Render("Usage: /transcribe <file.wav>"))
+			return nil, true
+		}
+		if _, paths := media.ExtractPaths(arg); len(paths) > 0 {
+			arg = paths[0]
+		}
+		data, err := os.ReadFile(arg)
+		if err == nil {
+			_, err = audio.Duration(data)
+		}
+		if err != nil {
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", err)))
+			return nil, true
+		}
+		m.messages = append(m.messages, systemStyle.Render("Transcribing "+filepath.Base(arg)+"…"))
+		return m.transcribeCmd(filepath.Base(arg), data), true
+	case "/play":
+		md, err := m.findAudio(arg)
+		if err != nil {
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", err)))
+			return nil, true
+		}
+		return m.playCmd(md), true
+	}
+	return nil, false
+}
+
+// toggleRecording starts recording, or stops it and transcribes what was said
+func (m *AppModel) toggleRecording() tea.Cmd {
+	if m.recording == nil {
+		rec, err := audio.Record(m.recordCommand)
+		if err != nil {
+			m.messages = append(m.messages, systemStyle.Render(fmt.Sprintf("Error: %v", err)))
+			return nil
+		}
+		m.recording = rec
+		m.messages = append(m.messages, systemStyle.Render("● Recording… press Ctrl+R again to stop."))
+		return nil
+	}
+
+	rec, transcribe := m.recording, m.transcriber()
+	m.recording = nil
+	m.messages = append(m.messages, systemStyle.Render("Transcribing…"))
+	return func() tea.Msg {
+		data, err := rec.Stop()
+		if err != nil {
+			return transcribedMsg{err: err}
+		}
+		return transcribe("voice.wav", data)
+	}
+}
+
+// transcribeCmd turns a WAV file into text for the textarea
+func (m *AppModel) transcribeCmd(name string, data []byte) tea.Cmd {
+	transcribe := m.transcriber()
+	return func() tea.Msg { return transcribe(name, data) }
+}
+
+// transcriber captures what a transcription needs, so it can run outside the Update loop
+func (m *AppModel) transcriber() func(name string, data []byte) tea.Msg {
+	orch, profile, session := m.orchestrator, m.profile, m.session
+	return func(name string, data []byte) tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), voiceTimeout)
+		defer cancel()
+		text, md, err := orch.Transcribe(ctx, profile, session, name, data)
+		return transcribedMsg{text: text, media: md, err: err}
+	}
+}
+
+// speakCmd reads the newest reply aloud and keeps the audio with it
+func (m *AppModel) speakCmd() tea.Cmd {
+	orch, repo, profile, session, player := m.orchestrator, m.repo, m.profile, m.session, m.playCommand
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), voiceTimeout)
+		defer cancel()
+		msg, err := repo.GetLastMessageByRole(session.SessionID, openai.ChatMessageRoleAssistant)
+		if err != nil || msg == nil {
+			return spokenMsg{err: err}
+		}
+		md, err := orch.Speak(ctx, profile, msg)
+		if err != nil {
+			return spokenMsg{err: err}
+		}
+		return spokenMsg{err: play(ctx, player, md)}
+	}
+}
+
+// playCmd plays a recording or spoken reply
+func (m *AppModel) playCmd(md *models.Media) tea.Cmd {
+	player := m.playCommand
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), voiceTimeout)
+		defer cancel()
+		return spokenMsg{err: play(ctx, player, md)}
+	}
+}
+
+func play(ctx context.Context, player string, md *models.Media) error {
+	data, err := media.Bytes(md)
+	if err != nil {
+		return err
+	}
+	return audio.Play(ctx, player, data, media.Extension(md))
+}
+
+// findAudio loads the recording or spoken reply with the given ID, or the newest one when ref is empty
+func (m *AppModel) findAudio(ref string) (*models.Media, error) {
+	if ref == "" || ref == "last" {
+		md, err := m.repo.GetLastAudio(m.profile.CharacterID)
+		if err == nil && md == nil {
+			err = fmt.Errorf("nothing to play yet; try /voice")
+		}
+		return md, err
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(ref, "#"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("usage: /play [id]")
+	}
+	md, err := m.repo.GetMedia(uint(id))
+	if err == nil && (md == nil || md.CharacterID != m.profile.CharacterID || !media.IsAudio(md)) {
+		err = fmt.Errorf("audio %d not found", id)
+	}
+	return md, err
+}
@@ -0,0 +1,137 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ai-companion-cli-go/internal/llm"
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// ErrBudgetExceeded is returned for calls a budget cap blocks
+var ErrBudgetExceeded = errors.New("usage budget exceeded")
+
+// Budget action names
+const (
+	ActionBlock     = "block"
+	ActionDowngrade = "downgrade"
+)
+
+// Budget caps spending; a zero limit means no cap
+type Budget struct {
+	DailyUSD    float64
+	MonthlyUSD  float64
+	Action      string // block or downgrade
+	DowngradeTo string // cheaper model used once a cap is hit (Action downgrade)
+}
+
+// Accountant prices and stores every model call and enforces the budget; it implements llm.Meter
+type Accountant struct {
+	repo   *storage.Repository
+	prices PriceTable
+	budget Budget
+}
+
+var _ llm.Meter = (*Accountant)(nil)
+
+// NewAccountant creates an accountant with a price table and budget
+func NewAccountant(repo *storage.Repository, prices PriceTable, budget Budget) *Accountant {
+	return &Accountant{repo: repo, prices: prices, budget: budget}
+}
+
+// Prices returns the price table in use
+func (a *Accountant) Prices() PriceTable {
+	return a.prices
+}
+
+// Budget returns the configured caps
+func (a *Accountant) Budget() Budget {
+	return a.budget
+}
+
+// Spend returns what was spent today and this month (local calendar)
+func (a *Accountant) Spend() (today, month float64, err error) {
+	dayStart, monthStart := periodStarts(time.Now())
+	if today, err = a.repo.SumUsageCost(dayStart); err != nil {
+		return 0, 0, err
+	}
+	month, err = a.repo.SumUsageCost(monthStart)
+	return today, month, err
+}
+
+// overBudget names the cap that has been reached, or "" when spending is within budget
+func (a *Accountant) overBudget() (string, error) {
+	if a.budget.DailyUSD <= 0 && a.budget.MonthlyUSD <= 0 {
+		return "", nil
+	}
+	today, month, err := a.Spend()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case a.budget.DailyUSD > 0 && today >= a.budget.DailyUSD:
+		return fmt.Sprintf("daily budget of $%.2f reached ($%.4f spent)", a.budget.DailyUSD, today), nil
+	case a.budget.MonthlyUSD > 0 && month >= a.budget.MonthlyUSD:
+		return fmt.Sprintf("monthly budget of $%.2f reached ($%.4f spent)", a.budget.MonthlyUSD, month), nil
+	}
+	return "", nil
+}
+
+// Admit implements llm.Meter
+func (a *Accountant) Admit(ctx context.Context, model string) (string, error) {
+	reason, err := a.overBudget()
+	if err != nil {
+		// accounting problems must not take the companion down
+		slog.Warn("budget check failed", "component", "usage", "err", err)
+		return model, nil
+	}
+	if reason == "" {
+		return model, nil
+	}
+
+	if a.budget.Action == ActionDowngrade && a.budget.DowngradeTo != "" {
+		if model != a.budget.DowngradeTo {
+			slog.Warn("budget reached, downgrading model", "component", "usage", "from", model, "to", a.budget.DowngradeTo, "reason", reason)
+		}
+		return a.budget.DowngradeTo, nil
+	}
+	slog.Warn("budget reached, blocking call", "component", "usage", "model", model, "reason", reason)
+	return "", fmt.Errorf("%w: %s", ErrBudgetExceeded, reason)
+}
+
+// Record implements llm.Meter
+func (a *Accountant) Record(ctx context.Context, stats llm.StreamStats) {
+	call := llm.CallFrom(ctx)
+	feature := call.Feature
+	if feature == "" {
+		feature = "other"
+	}
+	if _, ok := a.prices.Lookup(stats.Model); !ok {
+		slog.Warn("no price for model, counting it as free", "component", "usage", "model", stats.Model)
+	}
+
+	rec := &models.UsageRecord{
+		CharacterID:      call.CharacterID,
+		SessionID:        call.SessionID,
+		Feature:          feature,
+		Model:            stats.Model,
+		PromptTokens:     stats.PromptTokens,
+		CompletionTokens: stats.CompletionTokens,
+		TotalTokens:      stats.TotalTokens,
+		CostUSD:          a.prices.Cost(stats.Model, stats.PromptTokens, stats.CompletionTokens),
+		CreatedAt:        time.Now().UTC(),
+	}
+	if err := a.repo.AppendUsageRecord(rec); err != nil {
+		slog.Error("store usage record", "component", "usage", "err", err)
+	}
+}
+
+// periodStarts returns local midnight today and the first of this month
+func periodStarts(now time.Time) (day, month time.Time) {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,85 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Price is what a model costs in USD per million tokens
+type Price struct {
+	InputPerMTok  float64 `json:"input_per_mtok"`
+	OutputPerMTok float64 `json:"output_per_mtok"`
+}
+
+// PriceTable maps model names to prices. A name also matches dated variants
+// ("gpt-4o-mini" prices "gpt-4o-mini-2024-07-18"); the longest match wins.
+type PriceTable map[string]Price
+
+// DefaultPrices are list prices of common OpenAI models; override them with a price file
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		"gpt-4o-mini":   {InputPerMTok: 0.15, OutputPerMTok: 0.60},
+		"gpt-4o":        {InputPerMTok: 2.50, OutputPerMTok: 10.00},
+		"gpt-4.1":       {InputPerMTok: 2.00, OutputPerMTok: 8.00},
+		"gpt-4.1-mini":  {InputPerMTok: 0.40, OutputPerMTok: 1.60},
+		"gpt-4.1-nano":  {InputPerMTok: 0.10, OutputPerMTok: 0.40},
+		"gpt-3.5-turbo": {InputPerMTok: 0.50, OutputPerMTok: 1.50},
+	}
+}
+
+// LoadPrices reads a JSON price file ({"model": {"input_per_mtok": 0.15, "output_per_mtok": 0.6}})
+// on top of the defaults
+func LoadPrices(path string) (PriceTable, error) {
+	prices := DefaultPrices()
+	if path == "" {
+		return prices, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var custom PriceTable
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for model, p := range custom {
+		prices[model] = p
+	}
+	return prices, nil
+}
+
+// Lookup finds the price of a model; ok is false for unknown models, which are counted as free
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+	best := ""
+	for name := range t {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t[best], true
+}
+
+// Cost prices a call in USD
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	p, _ := t.Lookup(model)
+	return (float64(promptTokens)*p.InputPerMTok + float64(completionTokens)*p.OutputPerMTok) / 1e6
+}
+
+// Models lists the priced models alphabetically
+func (t PriceTable) Models() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"sort"
+	"time"
+
+	"ai-companion-cli-go/internal/storage"
+)
+
+// Report grouping periods and dimensions
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+
+	ByModel     = "model"
+	ByCharacter = "character"
+	ByFeature   = "feature"
+)
+
+// ReportRow aggregates the calls of one period and group
+type ReportRow struct {
+	Period           string  `json:"period"` // 2006-01-02 or 2006-01
+	Group            string  `json:"group"`  // model, character ID or feature
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// BuildReport aggregates usage in [since, until) by local day or month and by a dimension
+func BuildReport(repo *storage.Repository, since, until time.Time, period, by string) ([]ReportRow, error) {
+	records, err := repo.ListUsageRecords(since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := "2006-01-02"
+	if period == PeriodMonth {
+		layout = "2006-01"
+	}
+
+	type key struct{ period, group string }
+	rows := make(map[key]*ReportRow)
+	for _, r := range records {
+		group := r.Model
+		switch by {
+		case ByCharacter:
+			group = r.CharacterID
+		case ByFeature:
+			group = r.Feature
+		}
+		if group == "" {
+			group = "-"
+		}
+
+		k := key{r.CreatedAt.Local().Format(layout), group}
+		row, ok := rows[k]
+		if !ok {
+			row = &ReportRow{Period: k.period, Group: k.group}
+			rows[k] = row
+		}
+		row.Calls++
+		row.PromptTokens += r.PromptTokens
+		row.CompletionTokens += r.CompletionTokens
+		row.CostUSD += r.CostUSD
+	}
+
+	out := make([]ReportRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Period != out[j].Period {
+			return out[i].Period < out[j].Period
+		}
+		return out[i].CostUSD > out[j].CostUSD
+	})
+	return out, nil
+}
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KDF parameters for new keys (argon2id, RFC 9106 second recommended profile)
+const (
+	kdfName       = "argon2id"
+	kdfTime       = 3
+	kdfMemoryKiB  = 64 * 1024
+	kdfThreads    = 4
+	keyLength     = 32
+	saltLength    = 16
+	verifierPlain = "ai-companion-vault"
+)
+
+// Cipher seals individual column values with AES-256-GCM; it implements models.FieldCipher
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher builds a field cipher from a 32-byte key
+func NewCipher(key []byte) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// DeriveKey stretches a passphrase into an AES key with argon2id
+func DeriveKey(passphrase string, salt []byte, time, memoryKiB uint32, threads uint8) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, time, memoryKiB, threads, keyLength)
+}
+
+// Encrypt returns base64(nonce || ciphertext) using a fresh random nonce
+func (c *Cipher) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt and authenticates the value
+func (c *Cipher) Decrypt(ciphertext string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	n := c.aead.NonceSize()
+	if len(raw) < n {
+		return nil, errors.New("ciphertext too short")
+	}
+	return c.aead.Open(nil, raw[:n], raw[n:], nil)
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltLength)
+	_, err := rand.Read(salt)
+	return salt, err
+}
@@ -0,0 +1,47 @@
+package vault
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Stdin is the buffered standard input ReadPassphrase reads from. Anything else reading piped
+// input after a passphrase prompt must read through it too, or the lines buffered here are lost.
+var Stdin = bufio.NewReader(os.Stdin)
+
+// ReadPassphrase asks for a passphrase on the terminal without echoing it.
+// When stdin is not a terminal the first line of input is used instead.
+func ReadPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		raw, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(raw), err
+	}
+
+	line, err := Stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// UnlockInteractive unlocks using $COMPANION_PASSPHRASE, or prompts for the passphrase
+func (v *Vault) UnlockInteractive() error {
+	if !v.Locked() {
+		return nil
+	}
+	if pass := os.Getenv(PassphraseEnv); pass != "" {
+		return v.Unlock(pass)
+	}
+	pass, err := ReadPassphrase("Passphrase: ")
+	if err != nil {
+		return err
+	}
+	return v.Unlock(pass)
+}
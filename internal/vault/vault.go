@@ -0,0 +1,127 @@
+package vault
+
+import (
+	"errors"
+	"time"
+
+	"ai-companion-cli-go/internal/models"
+	"ai-companion-cli-go/internal/storage"
+)
+
+// ErrWrongPassphrase is returned when a passphrase does not match the stored verifier
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// PassphraseEnv lets scripts unlock an encrypted database without a prompt
+const PassphraseEnv = "COMPANION_PASSPHRASE"
+
+// Vault manages the passphrase-derived key protecting message content, facts and summaries
+type Vault struct {
+	repo     *storage.Repository
+	settings *models.EncryptionSettings
+	cipher   models.FieldCipher // nil until unlocked
+}
+
+// Open reads the encryption settings of the database
+func Open(repo *storage.Repository) (*Vault, error) {
+	settings, err := repo.GetEncryptionSettings()
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{repo: repo, settings: settings}, nil
+}
+
+// Enabled reports whether the database is encrypted
+func (v *Vault) Enabled() bool {
+	return v.settings != nil
+}
+
+// Locked reports whether encrypted data cannot be read yet
+func (v *Vault) Locked() bool {
+	return v.Enabled() && v.cipher == nil
+}
+
+// Unlock derives the key from the passphrase and installs it for all encrypted columns
+func (v *Vault) Unlock(passphrase string) error {
+	if !v.Enabled() {
+		return nil
+	}
+	c, err := cipherFor(passphrase, v.settings)
+	if err != nil {
+		return err
+	}
+	models.SetFieldCipher(c)
+	v.cipher = c
+	return nil
+}
+
+// Enable encrypts every existing message, fact and summary with a key derived from passphrase
+func (v *Vault) Enable(passphrase string) error {
+	if v.Enabled() {
+		return errors.New("database is already encrypted; use rekey to change the passphrase")
+	}
+	return v.rekey(passphrase)
+}
+
+// Rekey re-encrypts all data under a new passphrase; the vault must be unlocked first
+func (v *Vault) Rekey(newPassphrase string) error {
+	if v.Locked() {
+		return models.ErrVaultLocked
+	}
+	return v.rekey(newPassphrase)
+}
+
+func (v *Vault) rekey(passphrase string) error {
+	if passphrase == "" {
+		return errors.New("passphrase must not be empty")
+	}
+
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+	next := &models.EncryptionSettings{
+		KDF:       kdfName,
+		Salt:      salt,
+		Time:      kdfTime,
+		MemoryKiB: kdfMemoryKiB,
+		Threads:   kdfThreads,
+		UpdatedAt: time.Now(),
+	}
+	c, err := NewCipher(DeriveKey(passphrase, salt, next.Time, next.MemoryKiB, next.Threads))
+	if err != nil {
+		return err
+	}
+	if next.Verifier, err = c.Encrypt([]byte(verifierPlain)); err != nil {
+		return err
+	}
+
+	err = v.repo.ReencryptAll(func(tx *storage.Repository) error {
+		models.SetFieldCipher(c)
+		return tx.SaveEncryptionSettings(next)
+	})
+	if err != nil {
+		// The transaction rolled back, so the old key is still the right one
+		models.SetFieldCipher(v.cipher)
+		return err
+	}
+
+	v.settings = next
+	v.cipher = c
+	return nil
+}
+
+func cipherFor(passphrase string, settings *models.EncryptionSettings) (*Cipher, error) {
+	if settings == nil || settings.KDF != kdfName {
+		return nil, errors.New("unsupported key derivation settings")
+	}
+	key := DeriveKey(passphrase, settings.Salt, settings.Time, settings.MemoryKiB, settings.Threads)
+	c, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := c.Decrypt(settings.Verifier)
+	if err != nil || string(plain) != verifierPlain {
+		return nil, ErrWrongPassphrase
+	}
+	return c, nil
+}